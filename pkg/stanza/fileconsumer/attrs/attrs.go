@@ -11,14 +11,53 @@ import (
 )
 
 const (
-	LogFileName           = "log.file.name"
-	LogFilePath           = "log.file.path"
-	LogFileNameResolved   = "log.file.name_resolved"
-	LogFilePathResolved   = "log.file.path_resolved"
-	LogFileOwnerName      = "log.file.owner.name"
-	LogFileOwnerGroupName = "log.file.owner.group.name"
-	LogFileRecordNumber   = "log.file.record_number"
-	LogFileRecordOffset   = "log.file.record_offset"
+	LogFileName                    = "log.file.name"
+	LogFilePath                    = "log.file.path"
+	LogFileNameResolved            = "log.file.name_resolved"
+	LogFilePathResolved            = "log.file.path_resolved"
+	LogFileOwnerName               = "log.file.owner.name"
+	LogFileOwnerGroupName          = "log.file.owner.group.name"
+	LogFileOwnerUID                = "log.file.owner_uid"
+	LogFileOwnerGID                = "log.file.owner_gid"
+	LogFileMode                    = "log.file.mode"
+	LogFileRecordNumber            = "log.file.record_number"
+	LogFileRecordOffset            = "log.file.record_offset"
+	LogFileUUID                    = "log.file.uuid"
+	LogFileID                      = "log.file.id"
+	LogFileIdentity                = "log.file.identity"
+	LogFileByteRange               = "log.file.byte_range"
+	LogFileHostSeq                 = "log.file.host_seq"
+	LogFileDeltaNs                 = "log.file.delta_ns"
+	LogFileTimestamp               = "log.file.timestamp"
+	LogFileAutoDetectedCompression = "log.file.auto_detected_compression"
+	LogFileSourceCodec             = "log.file.source_codec"
+	LogFileRepeatCount             = "log.file.repeat_count"
+	LogFileFirstRecord             = "log.file.first_record"
+	LogFileGzipOriginalName        = "log.file.gzip.original_name"
+	LogFileGzipMtime               = "log.file.gzip.mtime"
+	LogFileGzipCompressedOffset    = "log.file.gzip.compressed_offset"
+	LogFileCumulativeRecords       = "log.file.cumulative_records"
+	LogFileCumulativeBytes         = "log.file.cumulative_bytes"
+	LogFilePartial                 = "log.file.partial"
+	LogFileSymlinkName             = "log.file.symlink.name"
+	LogFileTruncated               = "log.file.truncated"
+	LogFileLineTooLong             = "log.file.line_too_long"
+	LogFileScanTimeUnixNano        = "log.file.scan_time_unix_nano"
+	LogFilePollCycle               = "log.file.poll_cycle"
+	LogFileError                   = "log.file.error"
+	LogFileEvent                   = "log.file.event"
+	LogFileIsHeader                = "log.file.is_header"
+	LogFileSize                    = "log.file.size"
+	LogFileDecodeFallback          = "log.file.decode_fallback"
+	LogFileContextBefore           = "log.file.context_before"
+	LogDecodeError                 = "log.decode_error"
+	LogDecodeErrorBytes            = "log.decode_error.bytes"
+	LogChecksumValid               = "log.checksum_valid"
+	// LogRecordSeverityNumber holds a severity inferred for the record, which consumers set as its severity
+	LogRecordSeverityNumber = "log.record.severity_number"
+	// LogFileResourceAttributes holds a map of the attributes which belong to the resource rather than the record,
+	// which consumers set on the resource
+	LogFileResourceAttributes = "log.file.resource_attributes"
 )
 
 type Resolver struct {
@@ -28,6 +67,17 @@ type Resolver struct {
 	IncludeFilePathResolved   bool `mapstructure:"include_file_path_resolved,omitempty"`
 	IncludeFileOwnerName      bool `mapstructure:"include_file_owner_name,omitempty"`
 	IncludeFileOwnerGroupName bool `mapstructure:"include_file_owner_group_name,omitempty"`
+	// IncludeFilePermissions adds the numeric owner and group of the file, and its mode.
+	// Only the mode is available on windows.
+	IncludeFilePermissions bool `mapstructure:"include_file_permissions,omitempty"`
+	// DirectoryAttribute is the name of an attribute set to the name of a directory containing the file.
+	DirectoryAttribute string `mapstructure:"directory_attribute,omitempty"`
+	// DirectoryAttributeDepth selects the directory by how many levels it is above the file.
+	// The default of 1 is the file's parent directory.
+	DirectoryAttributeDepth int `mapstructure:"directory_attribute_depth,omitempty"`
+	// FileNameParentDirs is how many of the file's parent directories are included in the file name
+	// attribute, so that files with the same name in different directories can be told apart.
+	FileNameParentDirs int `mapstructure:"file_name_parent_dirs,omitempty"`
 }
 
 func (r *Resolver) Resolve(file *os.File) (attributes map[string]any, err error) {
@@ -35,15 +85,29 @@ func (r *Resolver) Resolve(file *os.File) (attributes map[string]any, err error)
 	// size 2 is sufficient if not resolving symlinks. This optimizes for the most performant cases.
 	attributes = make(map[string]any, 2)
 	if r.IncludeFileName {
-		attributes[LogFileName] = filepath.Base(path)
+		attributes[LogFileName] = nameWithParents(path, r.FileNameParentDirs)
 	}
 	if r.IncludeFilePath {
 		attributes[LogFilePath] = path
 	}
-	if r.IncludeFileOwnerName || r.IncludeFileOwnerGroupName {
-		err = r.addOwnerInfo(file, attributes)
-		if err != nil {
-			return nil, err
+	if r.DirectoryAttribute != "" {
+		if dir, ok := directoryName(path, r.DirectoryAttributeDepth); ok {
+			attributes[r.DirectoryAttribute] = dir
+		}
+	}
+	if r.IncludeFileOwnerName || r.IncludeFileOwnerGroupName || r.IncludeFilePermissions {
+		info, errStat := file.Stat()
+		if errStat != nil {
+			return nil, fmt.Errorf("resolve file stat: %w", errStat)
+		}
+		if r.IncludeFileOwnerName || r.IncludeFileOwnerGroupName {
+			err = r.addOwnerInfo(info, attributes)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if r.IncludeFilePermissions {
+			addPermissions(info, attributes)
 		}
 	}
 	if !r.IncludeFileNameResolved && !r.IncludeFilePathResolved {
@@ -72,3 +136,36 @@ func (r *Resolver) Resolve(file *os.File) (attributes map[string]any, err error)
 	}
 	return attributes, nil
 }
+
+// directoryName returns the name of the directory the given number of levels above the file. A depth of zero
+// is treated as one. Nothing is returned if the path does not have that many directories.
+func directoryName(path string, depth int) (string, bool) {
+	dir := filepath.Clean(path)
+	for i := 0; i < max(depth, 1); i++ {
+		parent := filepath.Dir(dir)
+		if parent == dir || parent == "." {
+			return "", false
+		}
+		dir = parent
+	}
+	name := filepath.Base(dir)
+	if name == string(filepath.Separator) {
+		return "", false
+	}
+	return name, true
+}
+
+// nameWithParents returns the name of the file, preceded by up to the given number of its parent directories.
+func nameWithParents(path string, parents int) string {
+	name := filepath.Base(path)
+	dir := filepath.Dir(filepath.Clean(path))
+	for i := 0; i < parents; i++ {
+		parent := filepath.Base(dir)
+		if parent == "." || parent == string(filepath.Separator) {
+			break
+		}
+		name = filepath.Join(parent, name)
+		dir = filepath.Dir(dir)
+	}
+	return name
+}
@@ -5,11 +5,13 @@ package attrs
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
 )
@@ -89,3 +91,125 @@ func TestResolver(t *testing.T) {
 		})
 	}
 }
+
+func TestResolverDirectoryAttribute(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		depth    int
+		expected string
+	}{
+		{"Default", 0, "app"},
+		{"Parent", 1, "app"},
+		{"Grandparent", 2, "tenant-a"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := filepath.Join(t.TempDir(), "tenant-a", "app")
+			require.NoError(t, os.MkdirAll(tempDir, 0o700))
+			temp := filetest.OpenTemp(t, tempDir)
+
+			r := Resolver{DirectoryAttribute: "tenant", DirectoryAttributeDepth: tc.depth}
+			attributes, err := r.Resolve(temp)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]any{"tenant": tc.expected}, attributes)
+		})
+	}
+}
+
+func TestResolverFileNameParentDirs(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	first := filepath.Join(root, "tenant-a", "app")
+	second := filepath.Join(root, "tenant-b", "app")
+	require.NoError(t, os.MkdirAll(first, 0o700))
+	require.NoError(t, os.MkdirAll(second, 0o700))
+	firstFile, err := os.Create(filepath.Join(first, "app.log"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = firstFile.Close() })
+	secondFile, err := os.Create(filepath.Join(second, "app.log"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = secondFile.Close() })
+
+	testCases := []struct {
+		name          string
+		parents       int
+		expectFirst   string
+		expectSecond  string
+		expectMatches bool
+	}{
+		{"Default", 0, "app.log", "app.log", true},
+		// The files are in directories with the same name, so one parent is not enough
+		{"Parent", 1, filepath.Join("app", "app.log"), filepath.Join("app", "app.log"), true},
+		{"Grandparent", 2, filepath.Join("tenant-a", "app", "app.log"), filepath.Join("tenant-b", "app", "app.log"), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := Resolver{IncludeFileName: true, FileNameParentDirs: tc.parents}
+			firstAttrs, err := r.Resolve(firstFile)
+			require.NoError(t, err)
+			secondAttrs, err := r.Resolve(secondFile)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectFirst, firstAttrs[LogFileName])
+			assert.Equal(t, tc.expectSecond, secondAttrs[LogFileName])
+			assert.Equal(t, tc.expectMatches, firstAttrs[LogFileName] == secondAttrs[LogFileName])
+		})
+	}
+}
+
+func TestNameWithParents(t *testing.T) {
+	t.Parallel()
+
+	sep := string(filepath.Separator)
+	path := filepath.Join(sep+"var", "log", "tenant-a", "app.log")
+	testCases := []struct {
+		name     string
+		path     string
+		parents  int
+		expected string
+	}{
+		{"None", path, 0, "app.log"},
+		{"Parent", path, 1, filepath.Join("tenant-a", "app.log")},
+		{"Top", path, 3, filepath.Join("var", "log", "tenant-a", "app.log")},
+		{"BeyondRoot", path, 5, filepath.Join("var", "log", "tenant-a", "app.log")},
+		{"Relative", filepath.Join("tenant-a", "app.log"), 2, filepath.Join("tenant-a", "app.log")},
+		{"NoDirectory", "app.log", 1, "app.log"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, nameWithParents(tc.path, tc.parents))
+		})
+	}
+}
+
+func TestDirectoryName(t *testing.T) {
+	t.Parallel()
+
+	sep := string(filepath.Separator)
+	path := filepath.Join(sep+"var", "log", "tenant-a", "app.log")
+	testCases := []struct {
+		name     string
+		path     string
+		depth    int
+		expected string
+		ok       bool
+	}{
+		{"Parent", path, 1, "tenant-a", true},
+		{"Grandparent", path, 2, "log", true},
+		{"Top", path, 3, "var", true},
+		{"Root", path, 4, "", false},
+		{"BeyondRoot", path, 5, "", false},
+		{"Relative", filepath.Join("tenant-a", "app.log"), 1, "tenant-a", true},
+		{"RelativeBeyondStart", filepath.Join("tenant-a", "app.log"), 2, "", false},
+		{"NoDirectory", "app.log", 1, "", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, ok := directoryName(tc.path, tc.depth)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, dir)
+		})
+	}
+}
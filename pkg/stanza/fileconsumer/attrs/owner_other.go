@@ -12,11 +12,7 @@ import (
 	"syscall"
 )
 
-func (r *Resolver) addOwnerInfo(file *os.File, attributes map[string]any) error {
-	fileInfo, errStat := file.Stat()
-	if errStat != nil {
-		return fmt.Errorf("resolve file stat: %w", errStat)
-	}
+func (r *Resolver) addOwnerInfo(fileInfo os.FileInfo, attributes map[string]any) error {
 	fileStat := fileInfo.Sys().(*syscall.Stat_t)
 
 	if r.IncludeFileOwnerName {
@@ -35,3 +31,10 @@ func (r *Resolver) addOwnerInfo(file *os.File, attributes map[string]any) error
 	}
 	return nil
 }
+
+func addPermissions(fileInfo os.FileInfo, attributes map[string]any) {
+	fileStat := fileInfo.Sys().(*syscall.Stat_t)
+	attributes[LogFileOwnerUID] = int64(fileStat.Uid)
+	attributes[LogFileOwnerGID] = int64(fileStat.Gid)
+	attributes[LogFileMode] = fmt.Sprintf("%04o", fileStat.Mode&0o7777)
+}
@@ -0,0 +1,30 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package attrs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestResolverPermissions(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	require.NoError(t, os.Chmod(temp.Name(), 0o640))
+
+	r := Resolver{IncludeFilePermissions: true}
+	attributes, err := r.Resolve(temp)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		LogFileOwnerUID: int64(os.Getuid()),
+		LogFileOwnerGID: int64(os.Getgid()),
+		LogFileMode:     "0640",
+	}, attributes)
+}
@@ -7,9 +7,15 @@ package attrs // import "github.com/open-telemetry/opentelemetry-collector-contr
 
 import (
 	"errors"
+	"fmt"
 	"os"
 )
 
-func (*Resolver) addOwnerInfo(_ *os.File, _ map[string]any) error {
+func (*Resolver) addOwnerInfo(_ os.FileInfo, _ map[string]any) error {
 	return errors.New("owner info not implemented for windows")
 }
+
+// addPermissions adds only the mode, as files do not have a numeric owner and group on windows.
+func addPermissions(fileInfo os.FileInfo, attributes map[string]any) {
+	attributes[LogFileMode] = fmt.Sprintf("%04o", fileInfo.Mode().Perm())
+}
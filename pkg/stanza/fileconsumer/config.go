@@ -9,9 +9,11 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"runtime"
 	"time"
 
+	"github.com/bmatcuk/doublestar/v4"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/featuregate"
 	"go.uber.org/zap"
@@ -33,9 +35,10 @@ import (
 )
 
 const (
-	defaultMaxConcurrentFiles = 1024
-	defaultEncoding           = "utf-8"
-	defaultPollInterval       = 200 * time.Millisecond
+	defaultMaxConcurrentFiles    = 1024
+	defaultEncoding              = "utf-8"
+	defaultPollInterval          = 200 * time.Millisecond
+	defaultPermissionDeniedRetry = time.Minute
 )
 
 var allowFileDeletion = featuregate.GlobalRegistry().MustRegister(
@@ -78,6 +81,7 @@ type Config struct {
 	MaxBatches              int             `mapstructure:"max_batches,omitempty"`
 	StartAt                 string          `mapstructure:"start_at,omitempty"`
 	FingerprintSize         helper.ByteSize `mapstructure:"fingerprint_size,omitempty"`
+	MaxFingerprintSize      helper.ByteSize `mapstructure:"max_fingerprint_size,omitempty"`
 	InitialBufferSize       helper.ByteSize `mapstructure:"initial_buffer_size,omitempty"`
 	MaxLogSize              helper.ByteSize `mapstructure:"max_log_size,omitempty"`
 	Encoding                string          `mapstructure:"encoding,omitempty"`
@@ -86,16 +90,23 @@ type Config struct {
 	FlushPeriod             time.Duration   `mapstructure:"force_flush_period,omitempty"`
 	Header                  *HeaderConfig   `mapstructure:"header,omitempty"`
 	DeleteAfterRead         bool            `mapstructure:"delete_after_read,omitempty"`
+	DeleteArchiveDir        string          `mapstructure:"delete_archive_dir,omitempty"`
 	IncludeFileRecordNumber bool            `mapstructure:"include_file_record_number,omitempty"`
 	IncludeFileRecordOffset bool            `mapstructure:"include_file_record_offset,omitempty"`
 	Compression             string          `mapstructure:"compression,omitempty"`
 	PollsToArchive          int             `mapstructure:"-"` // TODO: activate this config once archiving is set up
 	AcquireFSLock           bool            `mapstructure:"acquire_fs_lock,omitempty"`
+	MaxStalledScans         int             `mapstructure:"max_stalled_scans,omitempty"`
 }
 
 type HeaderConfig struct {
 	Pattern           string            `mapstructure:"pattern"`
 	MetadataOperators []operator.Config `mapstructure:"metadata_operators"`
+	// ResourceAttributes maps attributes parsed from the header to the names of resource attributes.
+	ResourceAttributes map[string]string `mapstructure:"resource_attributes,omitempty"`
+	// EncodingAttribute is the name of an attribute parsed from the header which declares the encoding
+	// of the rest of the file.
+	EncodingAttribute string `mapstructure:"encoding_attribute,omitempty"`
 }
 
 func (c Config) Build(set component.TelemetrySettings, emit emit.Callback, opts ...Option) (*Manager, error) {
@@ -140,11 +151,15 @@ func (c Config) Build(set component.TelemetrySettings, emit emit.Callback, opts
 	}
 
 	var hCfg *header.Config
+	var headerResourceAttributes map[string]string
+	var headerEncodingAttribute string
 	if c.Header != nil {
 		hCfg, err = header.NewConfig(set, c.Header.Pattern, c.Header.MetadataOperators, enc)
 		if err != nil {
 			return nil, fmt.Errorf("failed to build header config: %w", err)
 		}
+		headerResourceAttributes = c.Header.ResourceAttributes
+		headerEncodingAttribute = c.Header.EncodingAttribute
 	}
 
 	fileMatcher, err := matcher.New(c.Criteria)
@@ -153,30 +168,43 @@ func (c Config) Build(set component.TelemetrySettings, emit emit.Callback, opts
 	}
 
 	set.Logger = set.Logger.With(zap.String("component", "fileconsumer"))
-	readerFactory := &reader.Factory{
-		TelemetrySettings:       set,
-		FromBeginning:           startAtBeginning,
-		FingerprintSize:         int(c.FingerprintSize),
-		InitialBufferSize:       int(c.InitialBufferSize),
-		MaxLogSize:              int(c.MaxLogSize),
-		Encoding:                enc,
-		SplitFunc:               splitFunc,
-		TrimFunc:                trimFunc,
-		FlushTimeout:            c.FlushPeriod,
-		EmitFunc:                emit,
-		Attributes:              c.Resolver,
-		HeaderConfig:            hCfg,
-		DeleteAtEOF:             c.DeleteAfterRead,
-		IncludeFileRecordNumber: c.IncludeFileRecordNumber,
-		Compression:             c.Compression,
-		AcquireFSLock:           c.AcquireFSLock,
-	}
-
 	telemetryBuilder, err := metadata.NewTelemetryBuilder(set)
 	if err != nil {
 		return nil, err
 	}
 
+	readerFactory := &reader.Factory{
+		TelemetrySettings:        set,
+		FromBeginning:            startAtBeginning,
+		FingerprintSize:          int(c.FingerprintSize),
+		MaxFingerprintSize:       int(c.MaxFingerprintSize),
+		InitialBufferSize:        int(c.InitialBufferSize),
+		MaxLogSize:               int(c.MaxLogSize),
+		Encoding:                 enc,
+		SplitFunc:                splitFunc,
+		TrimFunc:                 trimFunc,
+		FlushTimeout:             c.FlushPeriod,
+		EmitFunc:                 emit,
+		FanOut:                   o.fanOut,
+		Attributes:               c.Resolver,
+		HeaderConfig:             hCfg,
+		HeaderResourceAttributes: headerResourceAttributes,
+		HeaderEncodingAttribute:  headerEncodingAttribute,
+		DeleteAtEOF:              c.DeleteAfterRead,
+		DeleteArchiveDir:         c.DeleteArchiveDir,
+		IncludeFileRecordNumber:  c.IncludeFileRecordNumber,
+		Compression:              c.Compression,
+		AcquireFSLock:            c.AcquireFSLock,
+		MaxStalledScans:          c.MaxStalledScans,
+		TelemetryBuilder:         telemetryBuilder,
+	}
+	if o.splitFunc == nil {
+		// The split func must follow the encoding which a header declares, unless it was provided
+		readerFactory.HeaderEncodingSplitFunc = func(enc encoding.Encoding) (bufio.SplitFunc, error) {
+			return c.SplitConfig.Func(enc, false, int(c.MaxLogSize))
+		}
+	}
+
 	maxBatchFiles := c.MaxConcurrentFiles / 2
 	if maxBatchFiles == 0 {
 		maxBatchFiles = 1
@@ -191,6 +219,9 @@ func (c Config) Build(set component.TelemetrySettings, emit emit.Callback, opts
 		maxBatches:       c.MaxBatches,
 		telemetryBuilder: telemetryBuilder,
 		noTracking:       o.noTracking,
+
+		permissionDenied:      make(map[string]time.Time),
+		permissionDeniedRetry: defaultPermissionDeniedRetry,
 	}, nil
 }
 
@@ -203,6 +234,10 @@ func (c Config) validate() error {
 		return fmt.Errorf("'fingerprint_size' must be at least %d bytes", fingerprint.MinSize)
 	}
 
+	if c.MaxFingerprintSize != 0 && c.MaxFingerprintSize < c.FingerprintSize {
+		return errors.New("'max_fingerprint_size' must not be less than 'fingerprint_size'")
+	}
+
 	if c.MaxLogSize <= 0 {
 		return errors.New("'max_log_size' must be positive")
 	}
@@ -215,6 +250,18 @@ func (c Config) validate() error {
 		return errors.New("'max_batches' must not be negative")
 	}
 
+	if c.MaxStalledScans < 0 {
+		return errors.New("'max_stalled_scans' must not be negative")
+	}
+
+	if c.DirectoryAttributeDepth < 0 {
+		return errors.New("'directory_attribute_depth' must not be negative")
+	}
+
+	if c.FileNameParentDirs < 0 {
+		return errors.New("'file_name_parent_dirs' must not be negative")
+	}
+
 	enc, err := textutils.LookupEncoding(c.Encoding)
 	if err != nil {
 		return err
@@ -229,6 +276,15 @@ func (c Config) validate() error {
 		}
 	}
 
+	if c.DeleteArchiveDir != "" {
+		if !c.DeleteAfterRead {
+			return errors.New("'delete_archive_dir' requires 'delete_after_read'")
+		}
+		if include, ok := matchesArchiveDir(c.Include, c.DeleteArchiveDir); ok {
+			return fmt.Errorf("'delete_archive_dir' must not be matched by include pattern '%s', or archived files would be read again", include)
+		}
+	}
+
 	if c.Header != nil {
 		if !AllowHeaderMetadataParsing.IsEnabled() {
 			return fmt.Errorf("'header' requires feature gate '%s'", AllowHeaderMetadataParsing.ID())
@@ -240,6 +296,11 @@ func (c Config) validate() error {
 		if _, errConfig := header.NewConfig(set, c.Header.Pattern, c.Header.MetadataOperators, enc); errConfig != nil {
 			return fmt.Errorf("invalid config for 'header': %w", errConfig)
 		}
+		for key, resourceKey := range c.Header.ResourceAttributes {
+			if resourceKey == "" {
+				return fmt.Errorf("'header.resource_attributes' has an empty resource attribute name for '%s'", key)
+			}
+		}
 	}
 
 	if runtime.GOOS == "windows" && (c.IncludeFileOwnerName || c.IncludeFileOwnerGroupName) {
@@ -249,9 +310,34 @@ func (c Config) validate() error {
 	return nil
 }
 
+// matchesArchiveDir returns the first include pattern which could match the files which are moved into dir.
+// Archived files keep their names, so any pattern which matches dir as a directory of files is assumed to match them.
+func matchesArchiveDir(include []string, dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for _, pattern := range include {
+		if abs, err := filepath.Abs(pattern); err == nil {
+			pattern = abs
+		}
+		if ok, _ := doublestar.PathMatch(filepath.Dir(pattern), dir); ok {
+			return pattern, true
+		}
+		// A trailing "**" matches every file below its directory
+		if filepath.Base(pattern) == "**" {
+			if ok, _ := doublestar.PathMatch(pattern, dir); ok {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
 type options struct {
 	splitFunc  bufio.SplitFunc
 	noTracking bool
+	fanOut     []emit.Callback
 }
 
 type Option func(*options)
@@ -263,6 +349,17 @@ func WithSplitFunc(f bufio.SplitFunc) Option {
 	}
 }
 
+// WithFanOut passes each batch to the given callbacks as well, in order, after the emit callback which is passed to
+// Build. The callbacks share the tokens, attributes and offsets, so they must not modify them. If a call is
+// interrupted by the emit timeout or a cancelled context, the batch is passed again on the next poll only to the
+// callbacks which it was not yet passed to, so none of them receive it twice. Any other error is handled as the
+// error of a single callback would be, once the batch has been passed to every callback.
+func WithFanOut(callbacks ...emit.Callback) Option {
+	return func(o *options) {
+		o.fanOut = callbacks
+	}
+}
+
 // WithNoTracking forces the readerFactory to not keep track of files in memory. When used, the reader will
 // read from the beginning of each file every time it is polled.
 func WithNoTracking() Option {
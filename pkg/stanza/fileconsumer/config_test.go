@@ -13,6 +13,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/featuregate"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/emittest"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
@@ -491,6 +492,23 @@ func TestBuild(t *testing.T) {
 			require.Error,
 			nil,
 		},
+		{
+			"NegativeDirectoryAttributeDepth",
+			func(cfg *Config) {
+				cfg.DirectoryAttribute = "tenant"
+				cfg.DirectoryAttributeDepth = -1
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"NegativeFileNameParentDirs",
+			func(cfg *Config) {
+				cfg.FileNameParentDirs = -1
+			},
+			require.Error,
+			nil,
+		},
 		{
 			"MultilineConfiguredStartAndEndPatterns",
 			func(cfg *Config) {
@@ -582,6 +600,42 @@ func TestBuild(t *testing.T) {
 				require.Equal(t, 6, m.maxBatches)
 			},
 		},
+		{
+			"InvalidMaxStalledScans",
+			func(cfg *Config) {
+				cfg.MaxStalledScans = -1
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"ValidMaxStalledScans",
+			func(cfg *Config) {
+				cfg.MaxStalledScans = 10
+			},
+			require.NoError,
+			func(t *testing.T, m *Manager) {
+				require.Equal(t, 10, m.readerFactory.MaxStalledScans)
+			},
+		},
+		{
+			"InvalidMaxFingerprintSize",
+			func(cfg *Config) {
+				cfg.MaxFingerprintSize = cfg.FingerprintSize - 1
+			},
+			require.Error,
+			nil,
+		},
+		{
+			"ValidMaxFingerprintSize",
+			func(cfg *Config) {
+				cfg.MaxFingerprintSize = 4 * cfg.FingerprintSize
+			},
+			require.NoError,
+			func(t *testing.T, m *Manager) {
+				require.Equal(t, 4*fingerprint.DefaultSize, m.readerFactory.MaxFingerprintSize)
+			},
+		},
 		{
 			"HeaderConfigNoFlag",
 			func(cfg *Config) {
@@ -720,6 +774,46 @@ func TestBuildWithHeader(t *testing.T) {
 				require.NotNil(t, m.readerFactory.HeaderConfig.SplitFunc)
 			},
 		},
+		{
+			"HeaderResourceAttributes",
+			func(cfg *Config) {
+				regexCfg := regex.NewConfig()
+				regexCfg.Regex = "^(?P<field>.*)"
+				cfg.Header = &HeaderConfig{
+					Pattern: "^#",
+					MetadataOperators: []operator.Config{
+						{
+							Builder: regexCfg,
+						},
+					},
+					ResourceAttributes: map[string]string{"field": "service.name"},
+				}
+				cfg.StartAt = "beginning"
+			},
+			require.NoError,
+			func(t *testing.T, m *Manager) {
+				require.Equal(t, map[string]string{"field": "service.name"}, m.readerFactory.HeaderResourceAttributes)
+			},
+		},
+		{
+			"HeaderResourceAttributesEmptyName",
+			func(cfg *Config) {
+				regexCfg := regex.NewConfig()
+				regexCfg.Regex = "^(?P<field>.*)"
+				cfg.Header = &HeaderConfig{
+					Pattern: "^#",
+					MetadataOperators: []operator.Config{
+						{
+							Builder: regexCfg,
+						},
+					},
+					ResourceAttributes: map[string]string{"field": ""},
+				}
+				cfg.StartAt = "beginning"
+			},
+			require.Error,
+			nil,
+		},
 	}
 
 	for _, tc := range cases {
@@ -739,6 +833,46 @@ func TestBuildWithHeader(t *testing.T) {
 	}
 }
 
+func TestBuildWithDeleteArchiveDir(t *testing.T) {
+	require.NoError(t, featuregate.GlobalRegistry().Set(allowFileDeletion.ID(), true))
+	defer func() {
+		require.NoError(t, featuregate.GlobalRegistry().Set(allowFileDeletion.ID(), false))
+	}()
+
+	cases := []struct {
+		name        string
+		include     string
+		deleteAfter bool
+		archiveDir  string
+		expectErr   string
+	}{
+		{"Valid", "/var/log/*.log", true, "/var/archive", ""},
+		{"ValidBelowInclude", "/var/log/*.log", true, "/var/log/archive", ""},
+		{"WithoutDelete", "/var/log/*.log", false, "/var/archive", "requires 'delete_after_read'"},
+		{"Included", "/var/log/*.log", true, "/var/log", "must not be matched by include pattern"},
+		{"IncludedRecursively", "/var/log/**/*.log", true, "/var/log/archive", "must not be matched by include pattern"},
+		{"IncludedByTrailingDoubleStar", "/var/log/**", true, "/var/log/archive", "must not be matched by include pattern"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.Include = []string{tc.include}
+			cfg.StartAt = "beginning"
+			cfg.DeleteAfterRead = tc.deleteAfter
+			cfg.DeleteArchiveDir = tc.archiveDir
+
+			m, err := cfg.Build(componenttest.NewNopTelemetrySettings(), emittest.Nop)
+			if tc.expectErr != "" {
+				require.ErrorContains(t, err, tc.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.archiveDir, m.readerFactory.DeleteArchiveDir)
+		})
+	}
+}
+
 // includeDir is a builder-like helper for quickly setting up a test config
 func (c *Config) includeDir(dir string) *Config {
 	c.Include = append(c.Include, fmt.Sprintf("%s/*", dir))
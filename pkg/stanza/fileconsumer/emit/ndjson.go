@@ -0,0 +1,39 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package emit // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/emit"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type envelope struct {
+	Body   string         `json:"body"`
+	Attrs  map[string]any `json:"attrs"`
+	Offset int64          `json:"offset"`
+}
+
+// NewNDJSONEnvelopeEmitter returns a Callback which wraps each token in a JSON object holding its body,
+// the attributes it was emitted with, and the offset in the file at which it starts. Each object is passed
+// to inner as a single newline terminated line. Bodies which are not valid UTF-8 have the invalid bytes
+// replaced with U+FFFD.
+func NewNDJSONEnvelopeEmitter(inner func([]byte) error) Callback {
+	return func(_ context.Context, tokens [][]byte, attributes map[string]any, _ int64, offsets []int64) error {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		for i, token := range tokens {
+			buf.Reset()
+			if err := encoder.Encode(envelope{Body: string(token), Attrs: attributes, Offset: offsets[i]}); err != nil {
+				return fmt.Errorf("encode envelope: %w", err)
+			}
+			if err := inner(buf.Bytes()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package emit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSONEnvelopeEmitter(t *testing.T) {
+	var lines [][]byte
+	callback := NewNDJSONEnvelopeEmitter(func(line []byte) error {
+		lines = append(lines, bytes.Clone(line))
+		return nil
+	})
+
+	tokens := [][]byte{
+		[]byte("plain"),
+		[]byte(`quoted "body" with \ and <html>`),
+		[]byte("control\tcharacters\x00\x1f"),
+		[]byte("unicode é 日本"),
+	}
+	offsets := []int64{0, 6, 38, 58, 75}
+	attributes := map[string]any{"log.file.name": "app.log", "count": int64(3)}
+	require.NoError(t, callback(context.Background(), tokens, attributes, 4, offsets))
+
+	require.Len(t, lines, len(tokens))
+	for i, line := range lines {
+		require.True(t, bytes.HasSuffix(line, []byte("\n")))
+		require.Equal(t, 1, bytes.Count(line, []byte("\n")), "each envelope is a single line")
+
+		var parsed struct {
+			Body   string         `json:"body"`
+			Attrs  map[string]any `json:"attrs"`
+			Offset int64          `json:"offset"`
+		}
+		require.NoError(t, json.Unmarshal(line, &parsed))
+		assert.Equal(t, string(tokens[i]), parsed.Body)
+		assert.Equal(t, offsets[i], parsed.Offset)
+		assert.Equal(t, map[string]any{"log.file.name": "app.log", "count": float64(3)}, parsed.Attrs)
+	}
+}
+
+func TestNDJSONEnvelopeEmitterError(t *testing.T) {
+	expected := errors.New("sink failed")
+	var calls int
+	callback := NewNDJSONEnvelopeEmitter(func([]byte) error {
+		calls++
+		return expected
+	})
+
+	err := callback(context.Background(), [][]byte{[]byte("a"), []byte("b")}, nil, 2, []int64{0, 2, 4})
+	require.ErrorIs(t, err, expected)
+	assert.Equal(t, 1, calls)
+}
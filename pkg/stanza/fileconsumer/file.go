@@ -5,8 +5,11 @@ package fileconsumer // import "github.com/open-telemetry/opentelemetry-collecto
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
@@ -38,6 +41,10 @@ type Manager struct {
 	maxBatchFiles  int
 	pollsToArchive int
 
+	// permissionDenied holds the last time each file which could not be opened due to permissions was tried
+	permissionDenied      map[string]time.Time
+	permissionDeniedRetry time.Duration
+
 	telemetryBuilder *metadata.TelemetryBuilder
 }
 
@@ -61,6 +68,9 @@ func (m *Manager) Start(persister operator.Persister) error {
 		if len(offsets) > 0 {
 			m.set.Logger.Info("Resuming from previously known offset(s). 'start_at' setting is not applicable.")
 			m.readerFactory.FromBeginning = true
+			for _, offset := range offsets {
+				offset.MarkRestored()
+			}
 			m.tracker.LoadMetadata(offsets)
 		}
 	} else if m.pollsToArchive > 0 {
@@ -123,6 +133,11 @@ func (m *Manager) poll(ctx context.Context) {
 		m.set.Logger.Debug("finding files", zap.Error(err))
 	}
 	m.set.Logger.Debug("matched files", zap.Strings("paths", matches))
+	for path := range m.permissionDenied {
+		if !slices.Contains(matches, path) {
+			delete(m.permissionDenied, path)
+		}
+	}
 
 	for len(matches) > m.maxBatchFiles {
 		m.consume(ctx, matches[:m.maxBatchFiles])
@@ -176,11 +191,23 @@ func (m *Manager) consume(ctx context.Context, paths []string) {
 }
 
 func (m *Manager) makeFingerprint(path string) (*fingerprint.Fingerprint, *os.File) {
-	file, err := os.Open(path) // #nosec - operator must read in files defined by user
+	if lastTried, ok := m.permissionDenied[path]; ok && time.Since(lastTried) < m.permissionDeniedRetry {
+		return nil, nil
+	}
+
+	file, err := m.readerFactory.Open(path)
 	if err != nil {
-		m.set.Logger.Error("Failed to open file", zap.Error(err))
+		switch {
+		case errors.Is(err, reader.ErrSymlinkSkipped):
+			m.set.Logger.Debug("Skipping symlink", zap.String("path", path))
+		case errors.Is(err, fs.ErrPermission):
+			m.permissionDeniedOpening(path, err)
+		default:
+			m.set.Logger.Error("Failed to open file", zap.Error(err))
+		}
 		return nil, nil
 	}
+	delete(m.permissionDenied, path)
 
 	fp, err := m.readerFactory.NewFingerprint(file)
 	if err != nil {
@@ -200,6 +227,17 @@ func (m *Manager) makeFingerprint(path string) (*fingerprint.Fingerprint, *os.Fi
 	return fp, file
 }
 
+// permissionDeniedOpening warns the first time a file cannot be opened due to permissions.
+// The file is then only retried periodically, and further failures are not logged above debug level.
+func (m *Manager) permissionDeniedOpening(path string, err error) {
+	if _, ok := m.permissionDenied[path]; ok {
+		m.set.Logger.Debug("Permission still denied opening file", zap.String("path", path), zap.Error(err))
+	} else {
+		m.set.Logger.Warn("Permission denied opening file, will retry periodically", zap.String("path", path), zap.Error(err), zap.Duration("retry_interval", m.permissionDeniedRetry))
+	}
+	m.permissionDenied[path] = time.Now()
+}
+
 // makeReader take a file path, then creates reader,
 // discarding any that have a duplicate fingerprint to other files that have already
 // been read this polling interval
@@ -210,12 +248,27 @@ func (m *Manager) makeReaders(ctx context.Context, paths []string) {
 			continue
 		}
 
+		fp, size := m.widenFingerprint(file, fp)
+
 		// Exclude duplicate paths with the same content. This can happen when files are
 		// being rotated with copy/truncate strategy. (After copy, prior to truncate.)
 		if r := m.tracker.GetCurrentFile(fp); r != nil {
-			m.set.Logger.Debug("Skipping duplicate file", zap.String("path", file.Name()))
 			// re-add the reader as Match() removes duplicates
 			m.tracker.Add(r)
+			var grown *fingerprint.Fingerprint
+			if grown, size = m.growOnCollision(r, file, fp, size); grown == nil {
+				m.set.Logger.Debug("Skipping duplicate file", zap.String("path", file.Name()))
+				if err := file.Close(); err != nil {
+					m.set.Logger.Debug("problem closing file", zap.Error(err))
+				}
+				continue
+			}
+			fp = grown
+		}
+
+		// Exclude hard links to a file which is already being read in this poll
+		if r := m.hardLinkedReader(file, fp); r != nil {
+			m.set.Logger.Debug("Skipping hard link to file", zap.String("path", file.Name()), zap.String("linked_path", r.GetFileName()))
 			if err := file.Close(); err != nil {
 				m.set.Logger.Debug("problem closing file", zap.Error(err))
 			}
@@ -227,11 +280,109 @@ func (m *Manager) makeReaders(ctx context.Context, paths []string) {
 			m.set.Logger.Error("Failed to create reader", zap.Error(err))
 			continue
 		}
+		if err = r.GrowFingerprint(size); err != nil {
+			m.set.Logger.Debug("Failed to grow fingerprint", zap.String("path", file.Name()), zap.Error(err))
+		}
 
 		m.tracker.Add(r)
 	}
 }
 
+// growOnCollision grows the fingerprints of a file and of the reader of another file with the same fingerprint,
+// which was computed over the given size, until neither starts with the other or the maximum fingerprint size is
+// reached. The reader's fingerprint is only grown once the files are told apart. It returns the fingerprint of the
+// file and its size, or nil if the files could not be told apart, as is the case for a copy of the file.
+func (m *Manager) growOnCollision(r *reader.Reader, file *os.File, fp *fingerprint.Fingerprint, size int) (*fingerprint.Fingerprint, int) {
+	other := r.GetFingerprint()
+	// A fingerprint which is shorter than the size it was computed over covers the whole file
+	for size < m.readerFactory.MaxFingerprintSize && fp.Len() == size && other.Len() == size {
+		size = min(size*2, m.readerFactory.MaxFingerprintSize)
+		var err error
+		if fp, err = m.readerFactory.NewFingerprintWithSize(file, size); err != nil {
+			m.set.Logger.Debug("Failed to grow fingerprint", zap.String("path", file.Name()), zap.Error(err))
+			return nil, 0
+		}
+		if other, err = r.FingerprintWithSize(size); err != nil {
+			m.set.Logger.Debug("Failed to grow fingerprint", zap.String("path", r.GetFileName()), zap.Error(err))
+			return nil, 0
+		}
+		if fp.StartsWith(other) || other.StartsWith(fp) {
+			continue
+		}
+		if err = r.GrowFingerprint(size); err != nil {
+			m.set.Logger.Debug("Failed to grow fingerprint", zap.String("path", r.GetFileName()), zap.Error(err))
+			return nil, 0
+		}
+		m.set.Logger.Debug("Grew fingerprints to tell files apart", zap.String("path", file.Name()), zap.String("other_path", r.GetFileName()), zap.Int("fingerprint_size", size))
+		return fp, size
+	}
+	return nil, 0
+}
+
+// widenFingerprint recomputes the fingerprint of a file at the size of a known file whose fingerprint was grown
+// and starts with it, so that the file can still be matched to it. It returns the fingerprint and its size.
+func (m *Manager) widenFingerprint(file *os.File, fp *fingerprint.Fingerprint) (*fingerprint.Fingerprint, int) {
+	size := m.readerFactory.FingerprintSize
+	if m.readerFactory.MaxFingerprintSize <= size || fp.Len() < size {
+		return fp, size
+	}
+	wide := size
+	for _, metadata := range m.tracker.GetMetadata() {
+		if metadata.FingerprintSize > wide && metadata.Fingerprint.StartsWith(fp) {
+			wide = metadata.FingerprintSize
+		}
+	}
+	if wide == size {
+		return fp, size
+	}
+	wider, err := m.readerFactory.NewFingerprintWithSize(file, wide)
+	if err != nil {
+		m.set.Logger.Debug("Failed to grow fingerprint", zap.String("path", file.Name()), zap.Error(err))
+		return fp, size
+	}
+	return wider, wide
+}
+
+// hardLinkedReader returns the reader in the current poll which reads the same file through another hard link.
+// Hard links have the same content, but a reader's fingerprint may not yet include content written since it
+// was last updated, so the fingerprints only need to share a prefix.
+func (m *Manager) hardLinkedReader(file *os.File, fp *fingerprint.Fingerprint) *reader.Reader {
+	var info os.FileInfo
+	for _, r := range m.tracker.CurrentPollFiles() {
+		if !fp.StartsWith(r.GetFingerprint()) && !r.GetFingerprint().StartsWith(fp) {
+			continue
+		}
+		if info == nil {
+			var err error
+			if info, err = file.Stat(); err != nil {
+				return nil
+			}
+		}
+		if r.SameFile(info) {
+			return r
+		}
+	}
+	return nil
+}
+
+// truncatedReader returns the reader in the last poll of the file which now holds new content, as it does once
+// it is truncated by a copytruncate rotation. It is only looked for when rotation overlaps are suppressed.
+func (m *Manager) truncatedReader(file *os.File) *reader.Reader {
+	if m.readerFactory.RotationOverlapSize <= 0 {
+		return nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil
+	}
+	for _, r := range m.tracker.PreviousPollFiles() {
+		if r.GetFileName() == file.Name() && r.SameFile(info) {
+			return r
+		}
+	}
+	return nil
+}
+
 func (m *Manager) newReader(ctx context.Context, file *os.File, fp *fingerprint.Fingerprint) (*reader.Reader, error) {
 	// Check previous poll cycle for match
 	if oldReader := m.tracker.GetOpenFile(fp); oldReader != nil {
@@ -272,6 +423,9 @@ func (m *Manager) newReader(ctx context.Context, file *os.File, fp *fingerprint.
 	if err != nil {
 		return nil, err
 	}
+	if prev := m.truncatedReader(file); prev != nil {
+		r.ContinueRotationOverlap(prev)
+	}
 	m.telemetryBuilder.FileconsumerOpenFiles.Add(ctx, 1)
 	return r, nil
 }
@@ -279,9 +433,9 @@ func (m *Manager) newReader(ctx context.Context, file *os.File, fp *fingerprint.
 func (m *Manager) instantiateTracker(ctx context.Context, persister operator.Persister) {
 	var t tracker.Tracker
 	if m.noTracking {
-		t = tracker.NewNoStateTracker(m.set, m.maxBatchFiles)
+		t = tracker.NewNoStateTracker(m.set, m.telemetryBuilder, m.maxBatchFiles, m.readerFactory.FingerprintMatch)
 	} else {
-		t = tracker.NewFileTracker(ctx, m.set, m.maxBatchFiles, m.pollsToArchive, persister)
+		t = tracker.NewFileTracker(ctx, m.set, m.telemetryBuilder, m.maxBatchFiles, m.pollsToArchive, persister, m.readerFactory.FingerprintMatch)
 	}
 	m.tracker = t
 }
@@ -17,7 +17,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/featuregate"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/emit"
@@ -1434,6 +1438,87 @@ func TestDelayedDisambiguation(t *testing.T) {
 	sink.ExpectCalls(t, sameTokenOtherFile, newFromFile1, newFromFile2)
 }
 
+func TestGrowFingerprintOnCollision(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.FingerprintSize = 16
+	cfg.MaxFingerprintSize = 1024
+	cfg.StartAt = "beginning"
+	operator, sink := testManager(t, cfg)
+
+	// The files share a header which is longer than the configured fingerprint size
+	header := "# generated by the same tool, version 1.2.3"
+	file1 := filetest.OpenTempWithPattern(t, tempDir, "*.log1")
+	file2 := filetest.OpenTempWithPattern(t, tempDir, "*.log2")
+	filetest.WriteString(t, file1, header+"\nfile1 first\n")
+	filetest.WriteString(t, file2, header+"\nfile2 first\n")
+
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte(header), []byte("file1 first"), []byte(header), []byte("file2 first"))
+	sink.ExpectNoCalls(t)
+
+	// The files are still told apart, and are not read again
+	filetest.WriteString(t, file1, "file1 second\n")
+	filetest.WriteString(t, file2, "file2 second\n")
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte("file1 second"), []byte("file2 second"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestGrowFingerprintOnCopy(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.FingerprintSize = 16
+	cfg.MaxFingerprintSize = 1024
+	cfg.StartAt = "beginning"
+	operator, sink := testManager(t, cfg)
+
+	// A copy cannot be told apart from the file, nor can a file which is the start of another
+	content := "a line which is longer than the fingerprint\n"
+	file1 := filetest.OpenTempWithPattern(t, tempDir, "1-*.log")
+	file2 := filetest.OpenTempWithPattern(t, tempDir, "2-*.log")
+	file3 := filetest.OpenTempWithPattern(t, tempDir, "3-*.log")
+	filetest.WriteString(t, file1, content+"more\n")
+	filetest.WriteString(t, file2, content+"more\n")
+	filetest.WriteString(t, file3, content)
+
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte("a line which is longer than the fingerprint"), []byte("more"))
+	sink.ExpectNoCalls(t)
+
+	// The fingerprint of the file which is read is not grown by the collisions
+	metadata := operator.tracker.GetMetadata()
+	require.Len(t, metadata, 1)
+	assert.Equal(t, 16, metadata[0].Fingerprint.Len())
+	assert.Zero(t, metadata[0].FingerprintSize)
+}
+
+func TestFingerprintMatchAcrossPolls(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.FingerprintSize = 16
+	cfg.StartAt = "beginning"
+	operator, sink := testManager(t, cfg)
+	operator.readerFactory.FingerprintMatch = reader.HammingFingerprintMatch(2)
+	operator.instantiateTracker(context.Background(), testutil.NewUnscopedMockPersister())
+
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "banner 12:00:00\ntestlog1\n")
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte("banner 12:00:00"), []byte("testlog1"))
+
+	// The banner is rewritten within the tolerance, so the file is still matched to its reader
+	_, err := temp.WriteAt([]byte("banner 12:00:01"), 0)
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "testlog2\n")
+	operator.poll(context.Background())
+	sink.ExpectToken(t, []byte("testlog2"))
+	sink.ExpectNoCalls(t)
+}
+
 func TestNoLostPartial(t *testing.T) {
 	t.Parallel()
 	tempDir := t.TempDir()
@@ -1606,3 +1691,80 @@ func TestReadGzipCompressedLogsFromEnd(t *testing.T) {
 	operator.poll(context.TODO())
 	sink.ExpectToken(t, []byte("testlog4"))
 }
+
+func TestPermissionDeniedWarnsOnce(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("Permissions cannot be denied with chmod on Windows")
+	}
+	if os.Geteuid() == 0 {
+		t.Skip("Permissions are not enforced for root")
+	}
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.StartAt = "beginning"
+	operator, sink := testManager(t, cfg)
+	core, observedLogs := observer.New(zap.DebugLevel)
+	operator.set.Logger = zap.New(core)
+
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog\n")
+	require.NoError(t, os.Chmod(temp.Name(), 0o000))
+
+	for i := 0; i < 3; i++ {
+		operator.poll(context.Background())
+	}
+	sink.ExpectNoCalls(t)
+	require.Equal(t, 1, observedLogs.FilterMessage("Permission denied opening file, will retry periodically").Len())
+	require.Zero(t, observedLogs.FilterMessage("Failed to open file").Len())
+
+	// Once the retry interval has elapsed the file is tried again, without logging another warning
+	operator.permissionDenied[temp.Name()] = time.Now().Add(-operator.permissionDeniedRetry)
+	operator.poll(context.Background())
+	require.Equal(t, 1, observedLogs.FilterMessage("Permission denied opening file, will retry periodically").Len())
+	require.Equal(t, 1, observedLogs.FilterMessage("Permission still denied opening file").Len())
+
+	// The file is read after permissions are fixed and the retry interval has elapsed
+	require.NoError(t, os.Chmod(temp.Name(), 0o600))
+	operator.permissionDenied[temp.Name()] = time.Now().Add(-operator.permissionDeniedRetry)
+	operator.poll(context.Background())
+	sink.ExpectToken(t, []byte("testlog"))
+	require.Empty(t, operator.permissionDenied)
+}
+
+func TestFileSummaryOnceUntracked(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.StartAt = "beginning"
+	tel := componenttest.NewTelemetry()
+	defer func() { require.NoError(t, tel.Shutdown(context.Background())) }()
+	sink := emittest.NewSink()
+	operator, err := cfg.Build(tel.NewTelemetrySettings(), sink.Callback)
+	require.NoError(t, err)
+	operator.instantiateTracker(context.Background(), testutil.NewUnscopedMockPersister())
+	t.Cleanup(func() { operator.tracker.ClosePreviousFiles() })
+
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+
+	// The summary is not recorded while the file is still tracked, though its reader is closed after each poll
+	operator.poll(context.Background())
+	operator.poll(context.Background())
+	_, err = tel.GetMetric("otelcol_fileconsumer_file_records")
+	require.Error(t, err)
+
+	require.NoError(t, os.Remove(temp.Name()))
+	for range 5 {
+		operator.poll(context.Background())
+	}
+	got, err := tel.GetMetric("otelcol_fileconsumer_file_records")
+	require.NoError(t, err)
+	dps := got.Data.(metricdata.Histogram[int64]).DataPoints
+	require.Len(t, dps, 1)
+	assert.Equal(t, uint64(1), dps[0].Count)
+	assert.Equal(t, int64(2), dps[0].Sum)
+}
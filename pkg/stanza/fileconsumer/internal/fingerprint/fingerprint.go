@@ -6,6 +6,7 @@ package fingerprint // import "github.com/open-telemetry/opentelemetry-collector
 import (
 	"bytes"
 	"compress/gzip"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/cespare/xxhash/v2"
 	"go.opentelemetry.io/collector/featuregate"
 )
 
@@ -20,6 +22,18 @@ const DefaultSize = 1000 // bytes
 
 const MinSize = 16 // bytes
 
+const (
+	// AlgorithmPrefix identifies files by the first bytes of the file
+	AlgorithmPrefix = "prefix"
+	// AlgorithmXXHash identifies files by a hash of the first bytes of the file
+	AlgorithmXXHash = "xxhash"
+)
+
+const (
+	hashSize = 16
+	hashSeed = 0x6f74656c
+)
+
 var DecompressedFingerprintFeatureGate = featuregate.GlobalRegistry().MustRegister(
 	"filelog.decompressFingerprint",
 	featuregate.StageAlpha,
@@ -29,9 +43,12 @@ var DecompressedFingerprintFeatureGate = featuregate.GlobalRegistry().MustRegist
 )
 
 // Fingerprint is used to identify a file
-// A file's fingerprint is the first N bytes of the file
+// A file's fingerprint is the first N bytes of the file, or a hash of them
 type Fingerprint struct {
 	firstBytes []byte
+	// hashLen is the number of bytes which were hashed, or zero if the fingerprint is not hashed
+	hashLen int
+	hash    [hashSize]byte
 }
 
 func New(first []byte) *Fingerprint {
@@ -41,26 +58,16 @@ func New(first []byte) *Fingerprint {
 // NewFromFile computes fingerprint of the given file using first 'N' bytes
 // Set decompressData to true to compute fingerprint of compressed files by decompressing its data first
 func NewFromFile(file *os.File, size int, decompressData bool) (*Fingerprint, error) {
-	buf := make([]byte, size)
 	if DecompressedFingerprintFeatureGate.IsEnabled() {
 		if decompressData {
 			if hasGzipExtension(file.Name()) {
 				// If the file is of compressed type, uncompress the data before creating its fingerprint
-				uncompressedData, err := gzip.NewReader(file)
-				if err != nil {
-					return nil, fmt.Errorf("error uncompressing gzip file: %w", err)
-				}
-				defer uncompressedData.Close()
-
-				n, err := uncompressedData.Read(buf)
-				if err != nil && !errors.Is(err, io.EOF) {
-					return nil, fmt.Errorf("error reading fingerprint bytes: %w", err)
-				}
-				return New(buf[:n]), nil
+				return NewFromDecompressedFile(file, size)
 			}
 		}
 	}
 
+	buf := make([]byte, size)
 	n, err := file.ReadAt(buf, 0)
 	if err != nil && !errors.Is(err, io.EOF) {
 		return nil, fmt.Errorf("reading fingerprint bytes: %w", err)
@@ -68,18 +75,85 @@ func NewFromFile(file *os.File, size int, decompressData bool) (*Fingerprint, er
 	return New(buf[:n]), nil
 }
 
+// NewFromDecompressedFile computes fingerprint of the given gzip compressed file using its first 'N' decompressed bytes.
+// Files with the same content therefore have the same fingerprint, regardless of how they were compressed.
+func NewFromDecompressedFile(file *os.File, size int) (*Fingerprint, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	if info.Size() == 0 {
+		return New([]byte{}), nil
+	}
+
+	uncompressedData, err := gzip.NewReader(io.NewSectionReader(file, 0, info.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("error uncompressing gzip file: %w", err)
+	}
+	defer uncompressedData.Close()
+
+	buf := make([]byte, size)
+	n, err := io.ReadFull(uncompressedData, buf)
+	// The file may still be being written, in which case use what is available so far
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("error reading fingerprint bytes: %w", err)
+	}
+	return New(buf[:n]), nil
+}
+
 func hasGzipExtension(filename string) bool {
 	return filepath.Ext(filename) == ".gz"
 }
 
+// Hashed returns a fingerprint which identifies the same bytes by their hash, so that it takes a fixed amount
+// of memory regardless of the fingerprint size. A hashed fingerprint can only be compared to the bytes it was
+// computed from, so a hashed fingerprint only starts with another hashed fingerprint if they are equal.
+// An empty fingerprint is not hashed.
+func (f *Fingerprint) Hashed() *Fingerprint {
+	if f.IsHashed() || len(f.firstBytes) == 0 {
+		return f.Copy()
+	}
+	return &Fingerprint{hashLen: len(f.firstBytes), hash: hashOf(f.firstBytes)}
+}
+
+// IsHashed returns true if the fingerprint holds a hash rather than the bytes themselves.
+func (f *Fingerprint) IsHashed() bool {
+	return f.hashLen > 0
+}
+
+func hashOf(b []byte) [hashSize]byte {
+	var sum [hashSize]byte
+	binary.BigEndian.PutUint64(sum[:8], xxhash.Sum64(b))
+	seeded := xxhash.NewWithSeed(hashSeed)
+	_, _ = seeded.Write(b)
+	binary.BigEndian.PutUint64(sum[8:], seeded.Sum64())
+	return sum
+}
+
 // Copy creates a new copy of the fingerprint
 func (f Fingerprint) Copy() *Fingerprint {
+	if f.IsHashed() {
+		return &Fingerprint{hashLen: f.hashLen, hash: f.hash}
+	}
 	buf := make([]byte, len(f.firstBytes), cap(f.firstBytes))
 	n := copy(buf, f.firstBytes)
 	return New(buf[:n])
 }
 
+// Bytes returns the bytes which make up the fingerprint, which are the hash for a hashed fingerprint.
+// The returned slice must not be modified.
+func (f *Fingerprint) Bytes() []byte {
+	if f.IsHashed() {
+		return f.hash[:]
+	}
+	return f.firstBytes
+}
+
+// Len returns the number of bytes of the file which the fingerprint identifies.
 func (f *Fingerprint) Len() int {
+	if f.IsHashed() {
+		return f.hashLen
+	}
 	return len(f.firstBytes)
 }
 
@@ -88,6 +162,9 @@ func (f *Fingerprint) Len() int {
 // because the primary purpose of a fingerprint is to convey a unique
 // identity, and only the FirstBytes field contributes to this goal.
 func (f Fingerprint) Equal(other *Fingerprint) bool {
+	if f.IsHashed() || other.IsHashed() {
+		return f.Len() == other.Len() && f.Hashed().hash == other.Hashed().hash
+	}
 	l0 := len(other.firstBytes)
 	l1 := len(f.firstBytes)
 	if l0 != l1 {
@@ -107,20 +184,32 @@ func (f Fingerprint) Equal(other *Fingerprint) bool {
 // since their initial size is typically less than that of
 // a fingerprint. As the file grows, its fingerprint is updated
 // until it reaches a maximum size, as configured on the operator
+// A hashed fingerprint is compared by hashing the same number of
+// bytes of the new fingerprint, which is only possible if the new
+// fingerprint is not itself hashed or is the same length.
 func (f Fingerprint) StartsWith(old *Fingerprint) bool {
-	l0 := len(old.firstBytes)
+	l0 := old.Len()
 	if l0 == 0 {
 		return false
 	}
-	l1 := len(f.firstBytes)
+	l1 := f.Len()
 	if l0 > l1 {
 		return false
 	}
+	switch {
+	case f.IsHashed():
+		return l0 == l1 && f.Equal(old)
+	case old.IsHashed():
+		return hashOf(f.firstBytes[:l0]) == old.hash
+	}
 	return bytes.Equal(old.firstBytes[:l0], f.firstBytes[:l0])
 }
 
 func (f *Fingerprint) MarshalJSON() ([]byte, error) {
 	m := marshal{FirstBytes: f.firstBytes}
+	if f.IsHashed() {
+		m.Hash, m.HashLen = f.hash[:], f.hashLen
+	}
 	return json.Marshal(&m)
 }
 
@@ -130,9 +219,18 @@ func (f *Fingerprint) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	f.firstBytes = m.FirstBytes
+	if m.HashLen > 0 {
+		if len(m.Hash) != hashSize {
+			return fmt.Errorf("invalid fingerprint hash length %d", len(m.Hash))
+		}
+		f.hashLen = m.HashLen
+		copy(f.hash[:], m.Hash)
+	}
 	return nil
 }
 
 type marshal struct {
 	FirstBytes []byte `json:"first_bytes"`
+	Hash       []byte `json:"hash,omitempty"`
+	HashLen    int    `json:"hash_len,omitempty"`
 }
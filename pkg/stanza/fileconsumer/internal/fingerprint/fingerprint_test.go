@@ -339,3 +339,88 @@ func TestCompressionFingerprint(t *testing.T) {
 	uncompressedFP := New(data)
 	uncompressedFP.Equal(compressedFP)
 }
+
+func TestNewFromDecompressedFile(t *testing.T) {
+	tmp := t.TempDir()
+	compressedFile := filetest.OpenTempWithPattern(t, tmp, "*.gz")
+
+	// An empty file has an empty fingerprint
+	fp, err := NewFromDecompressedFile(compressedFile, 10)
+	require.NoError(t, err)
+	require.Equal(t, New([]byte{}), fp)
+
+	data := []byte("this is a first test line")
+	gzipWriter := gzip.NewWriter(compressedFile)
+	_, err = gzipWriter.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	// The fingerprint does not depend on the current position in the file
+	fp, err = NewFromDecompressedFile(compressedFile, 10)
+	require.NoError(t, err)
+	require.Equal(t, New(data[:10]), fp)
+
+	fp, err = NewFromDecompressedFile(compressedFile, 2*len(data))
+	require.NoError(t, err)
+	require.Equal(t, New(data), fp)
+}
+
+func TestHashed(t *testing.T) {
+	empty := New([]byte(""))
+	hello := New([]byte("hello"))
+	world := New([]byte("world"))
+	helloworld := New([]byte("helloworld"))
+
+	require.False(t, empty.Hashed().IsHashed())
+	require.True(t, hello.Hashed().IsHashed())
+	require.Equal(t, hello.Len(), hello.Hashed().Len())
+	require.Len(t, helloworld.Hashed().Bytes(), hashSize)
+
+	// Identity is preserved for the same bytes, whether or not either side is hashed
+	require.True(t, hello.Hashed().Equal(hello.Hashed()))
+	require.True(t, hello.Hashed().Equal(hello))
+	require.True(t, hello.Equal(hello.Hashed()))
+	require.True(t, hello.StartsWith(hello.Hashed()))
+	require.True(t, hello.Hashed().StartsWith(hello.Hashed()))
+	require.True(t, hello.Hashed().StartsWith(hello))
+
+	// A file which has grown still starts with the hash of its earlier content
+	require.True(t, helloworld.StartsWith(hello.Hashed()))
+
+	// Different content does not match
+	require.False(t, hello.Hashed().Equal(world.Hashed()))
+	require.False(t, world.StartsWith(hello.Hashed()))
+	require.False(t, hello.StartsWith(world.Hashed()))
+	require.False(t, hello.Hashed().StartsWith(helloworld.Hashed()))
+	require.False(t, hello.StartsWith(helloworld.Hashed()))
+
+	// The bytes which were hashed are not retained, so a hash cannot be compared to a shorter prefix
+	require.False(t, helloworld.Hashed().StartsWith(hello))
+	require.False(t, helloworld.Hashed().StartsWith(hello.Hashed()))
+	require.False(t, helloworld.Hashed().Equal(hello.Hashed()))
+
+	// Empty never matches
+	require.False(t, hello.Hashed().StartsWith(empty))
+	require.False(t, empty.StartsWith(hello.Hashed()))
+}
+
+func TestHashedCopy(t *testing.T) {
+	fp := New([]byte("hello")).Hashed()
+	cp := fp.Copy()
+	require.Equal(t, fp, cp)
+	require.NotSame(t, fp, cp)
+	require.True(t, cp.IsHashed())
+}
+
+func TestHashedMarshalUnmarshal(t *testing.T) {
+	fp := New([]byte("hello")).Hashed()
+	b, err := fp.MarshalJSON()
+	require.NoError(t, err)
+
+	fp2 := new(Fingerprint)
+	require.NoError(t, fp2.UnmarshalJSON(b))
+	require.Equal(t, fp, fp2)
+	require.True(t, New([]byte("hello world")).StartsWith(fp2))
+
+	require.Error(t, new(Fingerprint).UnmarshalJSON([]byte(`{"hash":"AAEC","hash_len":5}`)))
+}
@@ -23,11 +23,17 @@ func Tracer(settings component.TelemetrySettings) trace.Tracer {
 // TelemetryBuilder provides an interface for components to report telemetry
 // as defined in metadata and user config.
 type TelemetryBuilder struct {
-	meter                    metric.Meter
-	mu                       sync.Mutex
-	registrations            []metric.Registration
-	FileconsumerOpenFiles    metric.Int64UpDownCounter
-	FileconsumerReadingFiles metric.Int64UpDownCounter
+	meter                        metric.Meter
+	mu                           sync.Mutex
+	registrations                []metric.Registration
+	FileconsumerCompressionRatio metric.Float64Histogram
+	FileconsumerFileBytes        metric.Int64Histogram
+	FileconsumerFileDecodeErrors metric.Int64Counter
+	FileconsumerFileReadDuration metric.Float64Histogram
+	FileconsumerFileRecords      metric.Int64Histogram
+	FileconsumerFilesInterrupted metric.Int64Counter
+	FileconsumerOpenFiles        metric.Int64UpDownCounter
+	FileconsumerReadingFiles     metric.Int64UpDownCounter
 }
 
 // TelemetryBuilderOption applies changes to default builder.
@@ -59,6 +65,43 @@ func NewTelemetryBuilder(settings component.TelemetrySettings, options ...Teleme
 	}
 	builder.meter = Meter(settings)
 	var err, errs error
+	builder.FileconsumerCompressionRatio, err = builder.meter.Float64Histogram(
+		"otelcol_fileconsumer_compression_ratio",
+		metric.WithDescription("Ratio of the decompressed bytes to the compressed bytes read from a compressed file in a poll"),
+		metric.WithUnit("1"),
+		metric.WithExplicitBucketBoundaries([]float64{1, 1.5, 2, 3, 4, 5, 7.5, 10, 15, 20, 50, 100}...),
+	)
+	errs = errors.Join(errs, err)
+	builder.FileconsumerFileBytes, err = builder.meter.Int64Histogram(
+		"otelcol_fileconsumer_file_bytes",
+		metric.WithDescription("Bytes read from a file, recorded once the file is no longer tracked"),
+		metric.WithUnit("By"),
+	)
+	errs = errors.Join(errs, err)
+	builder.FileconsumerFileDecodeErrors, err = builder.meter.Int64Counter(
+		"otelcol_fileconsumer_file_decode_errors",
+		metric.WithDescription("Number of tokens of a file which could not be decoded, recorded once the file is no longer tracked"),
+		metric.WithUnit("{errors}"),
+	)
+	errs = errors.Join(errs, err)
+	builder.FileconsumerFileReadDuration, err = builder.meter.Float64Histogram(
+		"otelcol_fileconsumer_file_read_duration",
+		metric.WithDescription("Time spent reading a file, recorded once the file is no longer tracked"),
+		metric.WithUnit("s"),
+	)
+	errs = errors.Join(errs, err)
+	builder.FileconsumerFileRecords, err = builder.meter.Int64Histogram(
+		"otelcol_fileconsumer_file_records",
+		metric.WithDescription("Number of records read from a file, recorded once the file is no longer tracked"),
+		metric.WithUnit("{records}"),
+	)
+	errs = errors.Join(errs, err)
+	builder.FileconsumerFilesInterrupted, err = builder.meter.Int64Counter(
+		"otelcol_fileconsumer_files_interrupted",
+		metric.WithDescription("Number of files which were no longer tracked before they were read to the end"),
+		metric.WithUnit("{files}"),
+	)
+	errs = errors.Join(errs, err)
 	builder.FileconsumerOpenFiles, err = builder.meter.Int64UpDownCounter(
 		"otelcol_fileconsumer_open_files",
 		metric.WithDescription("Number of open files"),
@@ -20,8 +20,32 @@ func TestSetupTelemetry(t *testing.T) {
 	tb, err := metadata.NewTelemetryBuilder(testTel.NewTelemetrySettings())
 	require.NoError(t, err)
 	defer tb.Shutdown()
+	tb.FileconsumerCompressionRatio.Record(context.Background(), 1)
+	tb.FileconsumerFileBytes.Record(context.Background(), 1)
+	tb.FileconsumerFileDecodeErrors.Add(context.Background(), 1)
+	tb.FileconsumerFileReadDuration.Record(context.Background(), 1)
+	tb.FileconsumerFileRecords.Record(context.Background(), 1)
+	tb.FileconsumerFilesInterrupted.Add(context.Background(), 1)
 	tb.FileconsumerOpenFiles.Add(context.Background(), 1)
 	tb.FileconsumerReadingFiles.Add(context.Background(), 1)
+	AssertEqualFileconsumerCompressionRatio(t, testTel,
+		[]metricdata.HistogramDataPoint[float64]{{}}, metricdatatest.IgnoreValue(),
+		metricdatatest.IgnoreTimestamp())
+	AssertEqualFileconsumerFileBytes(t, testTel,
+		[]metricdata.HistogramDataPoint[int64]{{}}, metricdatatest.IgnoreValue(),
+		metricdatatest.IgnoreTimestamp())
+	AssertEqualFileconsumerFileDecodeErrors(t, testTel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
+	AssertEqualFileconsumerFileReadDuration(t, testTel,
+		[]metricdata.HistogramDataPoint[float64]{{}}, metricdatatest.IgnoreValue(),
+		metricdatatest.IgnoreTimestamp())
+	AssertEqualFileconsumerFileRecords(t, testTel,
+		[]metricdata.HistogramDataPoint[int64]{{}}, metricdatatest.IgnoreValue(),
+		metricdatatest.IgnoreTimestamp())
+	AssertEqualFileconsumerFilesInterrupted(t, testTel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
 	AssertEqualFileconsumerOpenFiles(t, testTel,
 		[]metricdata.DataPoint[int64]{{Value: 1}},
 		metricdatatest.IgnoreTimestamp())
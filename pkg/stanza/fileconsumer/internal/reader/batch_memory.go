@@ -0,0 +1,14 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+// batchFull returns true if the batch should be emitted before any more tokens are added to it. Besides the
+// maximum batch size, the bodies of the batch are held to the maximum batch memory, which guards against a
+// burst of tokens near max_log_size. The token which reaches the ceiling is kept in the batch.
+func (r *Reader) batchFull(numTokens, batchBytes int) bool {
+	if r.maxBatchSize > 0 && numTokens >= r.maxBatchSize {
+		return true
+	}
+	return r.maxBatchMemory > 0 && batchBytes >= r.maxBatchMemory
+}
@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestMaxBatchMemory(t *testing.T) {
+	testCases := []struct {
+		name           string
+		maxBatchMemory int
+		expectBatches  []int
+	}{
+		{name: "Unlimited", expectBatches: []int{10}},
+		// The large tokens fill the batch long before the maximum batch size is reached
+		{name: "Ceiling", maxBatchMemory: 3000, expectBatches: []int{3, 3, 3, 1}},
+		{name: "CeilingOfOneToken", maxBatchMemory: 1, expectBatches: []int{1, 1, 1, 1, 1, 1, 1, 1, 1, 1}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			temp := filetest.OpenTemp(t, t.TempDir())
+			line := strings.Repeat("x", 1000)
+			filetest.WriteString(t, temp, strings.Repeat(line+"\n", 10))
+
+			var batches []int
+			var emitted int
+			f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+				batches = append(batches, len(tokens))
+				for _, token := range tokens {
+					assert.Equal(t, line, string(token))
+					emitted++
+				}
+				return nil
+			})
+			f.MaxBatchMemory = tc.maxBatchMemory
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			assert.Equal(t, tc.expectBatches, batches)
+			assert.Equal(t, 10, emitted)
+			assert.Equal(t, int64(10*len(line+"\n")), r.Offset)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "time"
+
+// batchState is the state of a reader which changes as the tokens of a batch are read. It is saved at the start
+// of each batch, so that the effects of reading the tokens of a batch which is not emitted can be undone, and
+// the tokens read again.
+type batchState struct {
+	recordNum        int64
+	lastTimestamp    time.Time
+	repeatRun        *RepeatRun
+	skippedBytes     int64
+	sessionPending   time.Time
+	cumulativeBytes  int64
+	bytesRead        int64
+	switchBOMPending bool
+	dedupPending     int
+	decodeErrors     int64
+	overlapPending   int
+	overlapSeam      map[uint64]struct{}
+}
+
+func (r *Reader) saveBatchState() batchState {
+	b := batchState{
+		recordNum:        r.RecordNum,
+		lastTimestamp:    r.LastTimestamp,
+		repeatRun:        r.RepeatRun.clone(),
+		skippedBytes:     r.skippedBytes,
+		sessionPending:   r.sessionPending,
+		cumulativeBytes:  r.CumulativeBytes,
+		bytesRead:        r.BytesRead,
+		switchBOMPending: r.SwitchBOMPending,
+		dedupPending:     len(r.dedupPending),
+		decodeErrors:     r.DecodeErrors,
+	}
+	if r.rotationOverlap != nil {
+		b.overlapPending, b.overlapSeam = len(r.rotationOverlap.pending), r.rotationOverlap.seam
+	}
+	return b
+}
+
+func (r *Reader) restoreBatchState(b batchState) {
+	r.RecordNum, r.LastTimestamp, r.RepeatRun = b.recordNum, b.lastTimestamp, b.repeatRun.clone()
+	r.skippedBytes, r.sessionPending, r.CumulativeBytes = b.skippedBytes, b.sessionPending, b.cumulativeBytes
+	r.dedupPending = r.dedupPending[:b.dedupPending]
+	r.DecodeErrors, r.BytesRead, r.SwitchBOMPending = b.decodeErrors, b.bytesRead, b.switchBOMPending
+	if r.rotationOverlap != nil {
+		r.rotationOverlap.pending, r.rotationOverlap.seam = r.rotationOverlap.pending[:b.overlapPending], b.overlapSeam
+	}
+}
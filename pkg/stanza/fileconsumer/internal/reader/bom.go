@@ -0,0 +1,22 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "bytes"
+
+// utf8BOM is the encoding of U+FEFF in decoded tokens, regardless of the encoding of the file.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// stripBOM removes byte order marks from the start of a decoded token. Some tools write a BOM each
+// time they append to a file, so a BOM may appear at the start of any record rather than only at
+// the start of the file.
+func (r *Reader) stripBOM(token []byte) []byte {
+	if !r.stripRecordBOM {
+		return token
+	}
+	for bytes.HasPrefix(token, utf8BOM) {
+		token = token[len(utf8BOM):]
+	}
+	return token
+}
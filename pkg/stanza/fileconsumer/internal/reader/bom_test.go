@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/split"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/trim"
+)
+
+func TestStripRecordBOM(t *testing.T) {
+	testCases := []struct {
+		name     string
+		strip    bool
+		expected []string
+	}{
+		{name: "Disabled", strip: false, expected: []string{"\ufefffirst", "\ufeffsecond", "third"}},
+		{name: "Enabled", strip: true, expected: []string{"first", "second", "third"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			enc, err := textutils.LookupEncoding("utf-16le")
+			require.NoError(t, err)
+
+			// Each append starts with a BOM
+			content, err := enc.NewEncoder().String("\ufefffirst\n\ufeffsecond\nthird\n")
+			require.NoError(t, err)
+
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+			filetest.WriteString(t, temp, content)
+
+			f, sink := testFactory(t)
+			f.Encoding = enc
+			f.SplitFunc, err = split.Config{}.Func(enc, false, defaultMaxLogSize)
+			require.NoError(t, err)
+			f.TrimFunc = trim.Nop
+			f.StripRecordBOM = tc.strip
+
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+
+			r.ReadToEnd(context.Background())
+
+			for _, token := range tc.expected {
+				sink.ExpectCall(t, []byte(token), map[string]any{attrs.LogFileName: r.FileAttributes[attrs.LogFileName]})
+			}
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
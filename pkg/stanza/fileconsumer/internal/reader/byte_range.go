@@ -0,0 +1,35 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"fmt"
+)
+
+// byteRange returns a key for the bytes of the file between the given offsets, made of the identity of the file
+// followed by the start and end of the range. The same bytes produce the same key however many times they are read,
+// so records which are read again after a restart or a rotation can be deduplicated downstream. A token which
+// is emitted in several pieces shares its range, so each piece after the first is distinguished by its index.
+//
+// The key only holds once the identity of the file is final, which is when the file has at least as many bytes
+// as the identity is derived from. The keys of the records read from a file before then differ from the keys
+// of the same records when the file is read again after it has grown.
+func (r *Reader) byteRange(start, end int64, piece int) string {
+	if piece > 0 {
+		return fmt.Sprintf("%s:%d-%d/%d", r.Identity(), start, end, piece)
+	}
+	return fmt.Sprintf("%s:%d-%d", r.Identity(), start, end)
+}
+
+// firstPiece returns the index of the first of the decoded tokens which is a piece of the token which was read.
+// A run of repeated tokens which ended comes first, and a session boundary comes right before the pieces.
+func firstPiece(endedRun bool, marker int) int {
+	if marker >= 0 {
+		return marker + 1
+	}
+	if endedRun {
+		return 1
+	}
+	return 0
+}
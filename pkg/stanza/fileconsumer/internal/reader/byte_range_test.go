@@ -0,0 +1,106 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestByteRange(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	// The first line fills the fingerprint which the identity is derived from, so the identity is final
+	lines := []string{strings.Repeat("a", identityTestSize), "b", "c"}
+	filetest.WriteString(t, temp, strings.Join(lines, "\n")+"\n")
+
+	f, sink := testFactory(t, withFingerprintSize(identityTestSize))
+	f.IncludeByteRange = true
+	read := func() []string {
+		fp, err := f.NewFingerprint(temp)
+		require.NoError(t, err)
+		r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+		require.NoError(t, err)
+		defer r.Close()
+		r.ReadToEnd(context.Background())
+
+		var keys []string
+		for range lines {
+			_, attributes := sink.NextCall(t)
+			keys = append(keys, attributes[attrs.LogFileByteRange].(string))
+		}
+		sink.ExpectNoCalls(t)
+		identity := r.Identity()
+		assert.Equal(t, []string{identity + ":0-65", identity + ":65-67", identity + ":67-69"}, keys)
+		return keys
+	}
+
+	// The same bytes produce the same keys when they are read again
+	assert.Equal(t, read(), read())
+}
+
+func TestByteRangePieces(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "aaaaabbbbbcc\nd\n")
+
+	f, sink := testFactory(t)
+	f.IncludeByteRange = true
+	f.MaxDecodedSize = 5
+	f.DecodedSizePolicy = DecodedSizePolicySplit
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+
+	// The pieces of a token share its byte range, so they are distinguished by their index
+	var keys []string
+	for range 4 {
+		_, attributes := sink.NextCall(t)
+		keys = append(keys, attributes[attrs.LogFileByteRange].(string))
+	}
+	identity := r.Identity()
+	assert.Equal(t, []string{identity + ":0-13", identity + ":0-13/1", identity + ":0-13/2", identity + ":13-15"}, keys)
+}
+
+func TestByteRangeShortFile(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "a\n")
+
+	f, sink := testFactory(t, withFingerprintSize(identityTestSize))
+	f.IncludeByteRange = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	_, attributes := sink.NextCall(t)
+	short := attributes[attrs.LogFileByteRange].(string)
+
+	// The file was shorter than the fingerprint which its identity is derived from, so its identity was not final
+	filetest.WriteString(t, temp, strings.Repeat("b", identityTestSize)+"\n")
+	r.ReadToEnd(context.Background())
+	sink.NextCall(t)
+
+	fp, err = f.NewFingerprint(temp)
+	require.NoError(t, err)
+	reread, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer reread.Close()
+	reread.ReadToEnd(context.Background())
+	_, attributes = sink.NextCall(t)
+	assert.Equal(t, reread.Identity()+":0-2", attributes[attrs.LogFileByteRange])
+	assert.NotEqual(t, short, attributes[attrs.LogFileByteRange])
+
+	// Once it is final, the identity no longer changes as the file grows
+	assert.Equal(t, reread.Identity(), r.Identity())
+}
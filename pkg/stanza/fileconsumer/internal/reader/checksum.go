@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"regexp"
+	"strconv"
+)
+
+// Algorithms which an inline checksum may be computed with.
+const (
+	ChecksumCRC32   = "crc32"
+	ChecksumCRC32C  = "crc32c"
+	ChecksumAdler32 = "adler32"
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumVerifier verifies the checksums which some formats append to each line. The first submatch of the
+// pattern is the checksum in hex, and the checksum is computed over the rest of the line, without the match.
+// The algorithm defaults to crc32.
+type ChecksumVerifier struct {
+	Pattern   *regexp.Regexp
+	Algorithm string
+}
+
+// Verify returns whether the inline checksum of a token is valid. It returns false for ok if the
+// token does not have an inline checksum.
+func (v *ChecksumVerifier) Verify(token []byte) (valid, ok bool) {
+	match := v.Pattern.FindSubmatchIndex(token)
+	if len(match) < 4 || match[2] < 0 {
+		return false, false
+	}
+	expected, err := strconv.ParseUint(string(token[match[2]:match[3]]), 16, 32)
+	if err != nil {
+		return false, true
+	}
+
+	h := v.newHash()
+	_, _ = h.Write(token[:match[0]])
+	_, _ = h.Write(token[match[1]:])
+	return h.Sum32() == uint32(expected), true
+}
+
+func (v *ChecksumVerifier) newHash() hash.Hash32 {
+	switch v.Algorithm {
+	case ChecksumCRC32C:
+		return crc32.New(castagnoliTable)
+	case ChecksumAdler32:
+		return adler32.New()
+	default:
+		return crc32.NewIEEE()
+	}
+}
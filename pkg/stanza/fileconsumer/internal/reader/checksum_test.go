@@ -0,0 +1,70 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"hash/adler32"
+	"hash/crc32"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestChecksumVerifier(t *testing.T) {
+	checksumLine := fmt.Sprintf("first line;crc=%08x\n", crc32.ChecksumIEEE([]byte("first line")))
+	tamperedLine := fmt.Sprintf("first lime;crc=%08x\n", crc32.ChecksumIEEE([]byte("first line")))
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, checksumLine+tamperedLine+"no checksum\nbad checksum;crc=zzzz\n")
+
+	f, sink := testFactory(t)
+	f.ChecksumVerifier = &ChecksumVerifier{Pattern: regexp.MustCompile(`;crc=(\w+)$`)}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+
+	fileName := filepath.Base(temp.Name())
+	sink.ExpectCall(t, []byte(checksumLine[:len(checksumLine)-1]), map[string]any{attrs.LogFileName: fileName, attrs.LogChecksumValid: true})
+	sink.ExpectCall(t, []byte(tamperedLine[:len(tamperedLine)-1]), map[string]any{attrs.LogFileName: fileName, attrs.LogChecksumValid: false})
+	sink.ExpectCall(t, []byte("no checksum"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectCall(t, []byte("bad checksum;crc=zzzz"), map[string]any{attrs.LogFileName: fileName, attrs.LogChecksumValid: false})
+	sink.ExpectNoCalls(t)
+}
+
+func TestChecksumAlgorithms(t *testing.T) {
+	line := []byte("crc=%08x the checksum may be anywhere in the line")
+	pattern := regexp.MustCompile(`crc=([0-9a-f]{8}) `)
+	rest := []byte("the checksum may be anywhere in the line")
+	testCases := []struct {
+		algorithm string
+		sum       uint32
+	}{
+		{algorithm: "", sum: crc32.ChecksumIEEE(rest)},
+		{algorithm: ChecksumCRC32, sum: crc32.ChecksumIEEE(rest)},
+		{algorithm: ChecksumCRC32C, sum: crc32.Checksum(rest, crc32.MakeTable(crc32.Castagnoli))},
+		{algorithm: ChecksumAdler32, sum: adler32.Checksum(rest)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			v := &ChecksumVerifier{Pattern: pattern, Algorithm: tc.algorithm}
+			valid, ok := v.Verify(fmt.Appendf(nil, string(line), tc.sum))
+			require.True(t, ok)
+			require.True(t, valid)
+
+			valid, ok = v.Verify(fmt.Appendf(nil, string(line), tc.sum+1))
+			require.True(t, ok)
+			require.False(t, valid)
+		})
+	}
+}
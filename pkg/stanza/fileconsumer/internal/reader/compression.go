@@ -0,0 +1,92 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+)
+
+const (
+	detectedGzip = "gzip"
+	detectedZstd = "zstd"
+	detectedNone = "none"
+)
+
+var magicNumbers = []struct {
+	compression string
+	magic       []byte
+}{
+	{detectedGzip, []byte{0x1f, 0x8b}},
+	{detectedZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// sniffCompression identifies the compression of a file from the magic number at the start of its content.
+// It returns false if there is not yet enough content to tell.
+func sniffCompression(prefix []byte) (string, bool) {
+	for _, m := range magicNumbers {
+		if bytes.HasPrefix(prefix, m.magic) {
+			return m.compression, true
+		}
+		if len(prefix) < len(m.magic) && bytes.HasPrefix(m.magic, prefix) {
+			return "", false
+		}
+	}
+	return detectedNone, true
+}
+
+// detectCompression decides whether a file is read as gzip or zstd when the compression is detected automatically.
+// It returns false if the decision cannot be made yet, in which case the file should not be read.
+func (r *Reader) detectCompression() bool {
+	switch {
+	case !r.sniffCompression:
+		// Identifying a filename by its extension may not always be correct. We could have a compressed file without the .gz extension
+		r.DetectedCompression = detectedNone
+		if r.FileType == gzipExtension {
+			r.DetectedCompression = detectedGzip
+		}
+	case r.DetectedCompression == "":
+		prefix := make([]byte, 4)
+		n, err := r.file.ReadAt(prefix, 0)
+		if err != nil && !errors.Is(err, io.EOF) {
+			r.set.Logger.Error("failed to read magic number", zap.Error(err))
+			return false
+		}
+		detected, ok := sniffCompression(prefix[:n])
+		if !ok {
+			return false
+		}
+		r.DetectedCompression = detected
+		// Anything which is not gzip or zstd compressed is read as it is
+		switch detected {
+		case detectedGzip:
+			r.FileType = gzipExtension
+		case detectedZstd:
+			r.FileType = zstdExtension
+		default:
+			r.FileType = ""
+		}
+	}
+
+	if r.includeDetectedCompression {
+		r.FileAttributes[attrs.LogFileAutoDetectedCompression] = r.DetectedCompression
+	}
+	return true
+}
+
+// sourceCodec returns the compression of the file which is read, as a hint for exporters.
+func (r *Reader) sourceCodec() string {
+	switch r.compression {
+	case "gzip":
+		return detectedGzip
+	case "auto":
+		return r.DetectedCompression
+	}
+	return detectedNone
+}
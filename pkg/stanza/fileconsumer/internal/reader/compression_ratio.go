@@ -0,0 +1,16 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "context"
+
+// recordCompressionRatio records the ratio of the bytes decompressed in a poll to the size of the
+// compressed window which they were read from, then resets the counts for the next poll.
+func (r *Reader) recordCompressionRatio(ctx context.Context) {
+	if r.telemetryBuilder != nil && r.compressedWindow > 0 && r.decompressedBytes > 0 {
+		ratio := float64(r.decompressedBytes) / float64(r.compressedWindow)
+		r.telemetryBuilder.FileconsumerCompressionRatio.Record(ctx, ratio)
+	}
+	r.compressedWindow, r.decompressedBytes = 0, 0
+}
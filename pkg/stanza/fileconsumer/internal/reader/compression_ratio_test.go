@@ -0,0 +1,67 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestCompressionRatio(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	defer func() { require.NoError(t, tel.Shutdown(context.Background())) }()
+	tb, err := metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	defer tb.Shutdown()
+
+	content := strings.Repeat("a highly repetitive line\n", 1000)
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err = gzipWriter.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	name := filepath.Join(t.TempDir(), "app.log.gz")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o600))
+
+	f, sink := testFactory(t, withSinkChanSize(1000))
+	f.Compression = "gzip"
+	f.TelemetryBuilder = tb
+	file := filetest.OpenFile(t, name)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	for range 1000 {
+		sink.ExpectToken(t, []byte("a highly repetitive line"))
+	}
+
+	got, err := tel.GetMetric("otelcol_fileconsumer_compression_ratio")
+	require.NoError(t, err)
+	dps := got.Data.(metricdata.Histogram[float64]).DataPoints
+	require.Len(t, dps, 1)
+	require.Equal(t, uint64(1), dps[0].Count)
+	require.InDelta(t, float64(len(content))/float64(buf.Len()), dps[0].Sum, 0.001)
+	require.Greater(t, dps[0].Sum, 10.0)
+
+	// Nothing is recorded for a poll which does not read anything
+	r.ReadToEnd(context.Background())
+	got, err = tel.GetMetric("otelcol_fileconsumer_compression_ratio")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), got.Data.(metricdata.Histogram[float64]).DataPoints[0].Count)
+}
@@ -0,0 +1,249 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestSniffCompression(t *testing.T) {
+	testCases := []struct {
+		name     string
+		prefix   []byte
+		expected string
+		ok       bool
+	}{
+		{"Empty", nil, "", false},
+		{"PartialGzip", []byte{0x1f}, "", false},
+		{"Gzip", []byte{0x1f, 0x8b, 0x08, 0x00}, detectedGzip, true},
+		{"PartialZstd", []byte{0x28, 0xb5, 0x2f}, "", false},
+		{"Zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}, detectedZstd, true},
+		{"Plaintext", []byte("plai"), detectedNone, true},
+		{"ShortPlaintext", []byte("a"), detectedNone, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			compression, ok := sniffCompression(tc.prefix)
+			assert.Equal(t, tc.expected, compression)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestAutoDetectedCompression(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := gzipWriter.Write([]byte("compressed line\n"))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	var zstdBuf bytes.Buffer
+	appendZstdFrame(t, &zstdBuf, "compressed line\n")
+
+	testCases := []struct {
+		name     string
+		fileName string
+		content  []byte
+		sniff    bool
+		expected string
+	}{
+		{"GzipWithoutExtension", "compressed.log", buf.Bytes(), true, detectedGzip},
+		{"PlaintextWithExtension", "plaintext.log.gz", []byte("plaintext line\n"), true, detectedNone},
+		{"Zstd", "compressed.log.zst", zstdBuf.Bytes(), true, detectedZstd},
+		{"GzipByExtension", "compressed.log.gz", buf.Bytes(), false, detectedGzip},
+		{"PlaintextByExtension", "plaintext.log", []byte("plaintext line\n"), false, detectedNone},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name := filepath.Join(tempDir, tc.fileName)
+			require.NoError(t, os.WriteFile(name, tc.content, 0o600))
+
+			f, sink := testFactory(t)
+			f.Compression = "auto"
+			f.SniffCompression = tc.sniff
+			f.IncludeAutoDetectedCompression = true
+			file := filetest.OpenFile(t, name)
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err := f.NewReader(file, fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			token, attributes := sink.NextCall(t)
+			assert.Equal(t, tc.expected, attributes[attrs.LogFileAutoDetectedCompression])
+			if tc.expected == detectedGzip || tc.expected == detectedZstd {
+				assert.Equal(t, []byte("compressed line"), token)
+			}
+			if tc.expected == detectedNone {
+				assert.Equal(t, []byte("plaintext line"), token)
+			}
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
+
+func TestAutoDetectedZstdAppended(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "compressed.log")
+	var buf bytes.Buffer
+	appendZstdFrame(t, &buf, "zstd line 1\nzstd line 2\n")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o600))
+
+	f, sink := testFactory(t)
+	f.Compression = "auto"
+	f.SniffCompression = true
+	file := filetest.OpenFile(t, name)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("zstd line 1"), []byte("zstd line 2"))
+	assert.Equal(t, int64(buf.Len()), r.Offset)
+
+	// Nothing is read again until another frame is appended, which is decompressed from where the last one ended
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	buf.Reset()
+	appendZstdFrame(t, &buf, "zstd line 3\n")
+	appended, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	defer appended.Close()
+	filetest.WriteString(t, appended, buf.String())
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("zstd line 3"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestSniffCompressionWaitsForMagicNumber(t *testing.T) {
+	tempDir := t.TempDir()
+	name := filepath.Join(tempDir, "compressed.log")
+	file, err := os.Create(name)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err = gzipWriter.Write([]byte("compressed line\n"))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	// Only the first byte of the magic number has been written
+	_, err = file.Write(buf.Bytes()[:1])
+	require.NoError(t, err)
+
+	f, sink := testFactory(t)
+	f.Compression = "auto"
+	f.SniffCompression = true
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, name), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	assert.Empty(t, r.DetectedCompression)
+
+	_, err = file.Write(buf.Bytes()[1:])
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("compressed line"))
+	assert.Equal(t, detectedGzip, r.DetectedCompression)
+}
+
+func TestGzipHeaderAttributes(t *testing.T) {
+	tempDir := t.TempDir()
+	modTime := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	gzipWriter.Name = "app.log"
+	gzipWriter.ModTime = modTime
+	_, err := gzipWriter.Write([]byte("compressed line\n"))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	// The file has been renamed since it was compressed
+	name := filepath.Join(tempDir, "app.log.1.gz")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o600))
+
+	f, sink := testFactory(t)
+	f.Compression = "gzip"
+	f.IncludeGzipHeader = true
+	file := filetest.OpenFile(t, name)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("compressed line"), map[string]any{
+		attrs.LogFileName:             "app.log.1.gz",
+		attrs.LogFileGzipOriginalName: "app.log",
+		attrs.LogFileGzipMtime:        "2024-03-01T12:30:00Z",
+	})
+	sink.ExpectNoCalls(t)
+}
+
+func TestSourceCodec(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := gzipWriter.Write([]byte("compressed line\n"))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+
+	testCases := []struct {
+		name        string
+		fileName    string
+		content     []byte
+		compression string
+		expected    string
+	}{
+		{"Gzip", "compressed.log.gz", buf.Bytes(), "gzip", "gzip"},
+		{"AutoGzip", "compressed.log", buf.Bytes(), "auto", "gzip"},
+		{"AutoPlaintext", "plaintext.log", []byte("plaintext line\n"), "auto", "none"},
+		{"Uncompressed", "plaintext.log", []byte("plaintext line\n"), "", "none"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name := filepath.Join(tempDir, tc.name+"-"+tc.fileName)
+			require.NoError(t, os.WriteFile(name, tc.content, 0o600))
+
+			f, sink := testFactory(t)
+			f.Compression = tc.compression
+			f.SniffCompression = true
+			f.IncludeSourceCodec = true
+			file := filetest.OpenFile(t, name)
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err := f.NewReader(file, fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			_, attributes := sink.NextCall(t)
+			assert.Equal(t, tc.expected, attributes[attrs.LogFileSourceCodec])
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestContextBefore(t *testing.T) {
+	const contextSize = 10
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	content := "first\nsecond\n\nthird line is long\nfourth\n"
+	filetest.WriteString(t, temp, content)
+
+	type emitted struct {
+		offset  int64
+		context any
+	}
+	var tokens []emitted
+	f := newTestFactory(t, func(_ context.Context, batch [][]byte, attributes map[string]any, _ int64, offsets []int64) error {
+		for i := range batch {
+			ctx, ok := attributes[attrs.LogFileContextBefore]
+			if !ok {
+				ctx = nil
+			}
+			tokens = append(tokens, emitted{offset: offsets[i], context: ctx})
+		}
+		return nil
+	})
+	f.ContextBeforeSize = contextSize
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	require.Len(t, tokens, 5)
+	// Nothing precedes the first token
+	assert.Nil(t, tokens[0].context)
+	for _, token := range tokens[1:] {
+		expected := []byte(content[max(0, token.offset-contextSize):token.offset])
+		assert.Equal(t, expected, token.context, "token at offset %d", token.offset)
+	}
+
+	// Bytes read on an earlier poll are not retained, so the first token of this poll has no context
+	tokens = nil
+	appended := "fifth\nsixth\n"
+	filetest.WriteString(t, temp, appended)
+	content += appended
+	r.ReadToEnd(context.Background())
+	require.Len(t, tokens, 2)
+	assert.Nil(t, tokens[0].context)
+	// Context is truncated to what was read on this poll
+	assert.Equal(t, []byte(content[tokens[0].offset:tokens[1].offset]), tokens[1].context)
+}
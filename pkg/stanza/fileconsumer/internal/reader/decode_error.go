@@ -0,0 +1,44 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"encoding/base64"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+)
+
+// Ways of reporting tokens which cannot be decoded and have no fallback. By default, they are logged.
+const (
+	DecodeErrorLog        = "log"
+	DecodeErrorEmit       = "emit"
+	DecodeErrorLogAndEmit = "log_and_emit"
+)
+
+// decodeError reports a token which could not be decoded. It returns the attributes of an empty error record
+// to emit in place of the token, or nil if no record is emitted.
+func (r *Reader) decodeError(err error, token []byte, offset int64) map[string]any {
+	r.DecodeErrors++
+	if r.decodeErrorAction != DecodeErrorEmit {
+		r.set.Logger.Error("failed to decode token", zap.Error(err))
+	}
+	if r.decodeErrorAction != DecodeErrorEmit && r.decodeErrorAction != DecodeErrorLogAndEmit {
+		return nil
+	}
+	return map[string]any{
+		attrs.LogDecodeError:      true,
+		attrs.LogDecodeErrorBytes: base64.StdEncoding.EncodeToString(token),
+		attrs.LogFileError:        err.Error(),
+		attrs.LogFileRecordOffset: offset,
+	}
+}
+
+// SkippedBytes returns the number of bytes of the file which were skipped because they could not be
+// decoded, since the file was first seen by this process. Tokens which are emitted as error records
+// in place of the undecodable bytes are not counted.
+func (r *Reader) SkippedBytes() int64 {
+	return r.skippedBytes
+}
@@ -0,0 +1,141 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestDecodeErrorAction(t *testing.T) {
+	bad := []byte("caf\xe9 \xff")
+	_, decodeErr := strictUTF8{}.NewDecoder().Bytes(bad)
+	require.Error(t, decodeErr)
+	content := "valid\n" + string(bad) + "\nafter\n"
+	badOffset := int64(len("valid\n"))
+	afterOffset := badOffset + int64(len(bad)+1)
+
+	testCases := []struct {
+		name      string
+		action    string
+		expectLog bool
+		expectRec bool
+	}{
+		{name: "Default", expectLog: true},
+		{name: "Log", action: DecodeErrorLog, expectLog: true},
+		{name: "Emit", action: DecodeErrorEmit, expectRec: true},
+		{name: "LogAndEmit", action: DecodeErrorLogAndEmit, expectLog: true, expectRec: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+			filetest.WriteString(t, temp, content)
+
+			type record struct {
+				body       string
+				attributes map[string]any
+				offset     int64
+			}
+			var records []record
+			f := newTestFactory(t, func(_ context.Context, tokens [][]byte, attributes map[string]any, _ int64, offsets []int64) error {
+				for i, token := range tokens {
+					records = append(records, record{body: string(token), attributes: attributes, offset: offsets[i]})
+				}
+				return nil
+			})
+			f.Encoding = strictUTF8{}
+			f.DecodeErrorAction = tc.action
+			core, logs := observer.New(zapcore.ErrorLevel)
+			f.TelemetrySettings.Logger = zap.New(core)
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+
+			r.ReadToEnd(context.Background())
+
+			fileName := r.FileAttributes[attrs.LogFileName]
+			expected := []record{{body: "valid", attributes: map[string]any{attrs.LogFileName: fileName}, offset: 0}}
+			if tc.expectRec {
+				expected = append(expected, record{
+					body: "",
+					attributes: map[string]any{
+						attrs.LogFileName:         fileName,
+						attrs.LogDecodeError:      true,
+						attrs.LogDecodeErrorBytes: base64.StdEncoding.EncodeToString(bad),
+						attrs.LogFileError:        decodeErr.Error(),
+						attrs.LogFileRecordOffset: badOffset,
+					},
+					offset: badOffset,
+				})
+			}
+			expected = append(expected, record{body: "after", attributes: map[string]any{attrs.LogFileName: fileName}, offset: afterOffset})
+			assert.Equal(t, expected, records)
+			assert.Equal(t, int64(len(content)), r.Offset)
+
+			if tc.expectLog {
+				assert.Equal(t, 1, logs.FilterMessage("failed to decode token").Len())
+			} else {
+				assert.Equal(t, 0, logs.Len())
+			}
+		})
+	}
+}
+
+func TestSkippedBytes(t *testing.T) {
+	bad1, bad2 := "caf\xe9", "\xff\xfe\xfd"
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, bad1+"\nvalid\n"+bad2+"\nafter\n")
+
+	f, sink := testFactory(t)
+	f.Encoding = strictUTF8{}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("valid"), []byte("after"))
+	assert.Equal(t, int64(len(bad1)+len(bad2)+2), r.SkippedBytes())
+}
+
+func TestSkippedBytesHeldBatch(t *testing.T) {
+	bad := "caf\xe9"
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "valid\n"+bad+"\nafter\n")
+
+	f, sink := testFactory(t)
+	f.Encoding = strictUTF8{}
+	f.MinBatchSize = 10
+	f.MinBatchTimeout = 100 * time.Millisecond
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The bad token is read again with the rest of the held batch, but is only counted once
+	r.ReadToEnd(context.Background())
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, int64(0), r.SkippedBytes())
+
+	time.Sleep(150 * time.Millisecond)
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("valid"), []byte("after"))
+	assert.Equal(t, int64(len(bad)+1), r.SkippedBytes())
+}
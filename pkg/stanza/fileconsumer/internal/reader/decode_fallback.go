@@ -0,0 +1,46 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Fallbacks used for tokens which cannot be decoded. By default, such tokens are dropped.
+const (
+	// DecodeFallbackLatin1 interprets each byte of the token as an ISO-8859-1 character, which never fails.
+	DecodeFallbackLatin1 = "latin1"
+	// DecodeFallbackRaw emits the undecoded bytes of the token.
+	DecodeFallbackRaw = "raw"
+)
+
+// decode decodes a token with the configured encoding. If decoding fails and a fallback is configured,
+// the token is decoded with the fallback instead and flagged with the log.file.decode_fallback attribute.
+func (r *Reader) decode(token []byte) ([]byte, error) {
+	r.decodeFallbackUsed = false
+	decoded, err := r.decoder.Bytes(token)
+	if err == nil {
+		return decoded, nil
+	}
+
+	switch r.decodeFallback {
+	case DecodeFallbackLatin1:
+		latin1, latin1Err := charmap.ISO8859_1.NewDecoder().Bytes(token)
+		if latin1Err != nil {
+			return nil, err
+		}
+		decoded = latin1
+	case DecodeFallbackRaw:
+		// The token is only valid until the next scan
+		decoded = bytes.Clone(token)
+	default:
+		return nil, err
+	}
+	r.set.Logger.Debug("failed to decode token, using fallback", zap.Error(err), zap.String("fallback", r.decodeFallback))
+	r.decodeFallbackUsed = true
+	return decoded, nil
+}
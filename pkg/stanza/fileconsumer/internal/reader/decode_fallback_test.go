@@ -0,0 +1,81 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+// strictUTF8 fails to decode invalid UTF-8 rather than replacing it.
+type strictUTF8 struct{}
+
+func (strictUTF8) NewDecoder() *encoding.Decoder {
+	return &encoding.Decoder{Transformer: encoding.UTF8Validator}
+}
+
+func (strictUTF8) NewEncoder() *encoding.Encoder {
+	return unicode.UTF8.NewEncoder()
+}
+
+func TestDecodeFallback(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fallback string
+		expected string
+	}{
+		{name: "Latin1", fallback: DecodeFallbackLatin1, expected: "café ÿ"},
+		{name: "Raw", fallback: DecodeFallbackRaw, expected: "caf\xe9 \xff"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+			filetest.WriteString(t, temp, "valid\ncaf\xe9 \xff\nafter\n")
+
+			f, sink := testFactory(t)
+			f.Encoding = strictUTF8{}
+			f.DecodeFallback = tc.fallback
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+
+			r.ReadToEnd(context.Background())
+
+			fileAttrs := map[string]any{attrs.LogFileName: r.FileAttributes[attrs.LogFileName]}
+			sink.ExpectCall(t, []byte("valid"), fileAttrs)
+			sink.ExpectCall(t, []byte(tc.expected), map[string]any{
+				attrs.LogFileName:           r.FileAttributes[attrs.LogFileName],
+				attrs.LogFileDecodeFallback: tc.fallback,
+			})
+			sink.ExpectCall(t, []byte("after"), fileAttrs)
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
+
+func TestDecodeFailureDropsToken(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "valid\ncaf\xe9\nafter\n")
+
+	f, sink := testFactory(t)
+	f.Encoding = strictUTF8{}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("valid"), []byte("after"))
+	sink.ExpectNoCalls(t)
+}
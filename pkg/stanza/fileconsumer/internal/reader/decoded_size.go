@@ -0,0 +1,68 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// Policies applied to decoded tokens which are larger than the maximum decoded size.
+const (
+	// DecodedSizePolicyTruncate emits the beginning of the token, up to the maximum decoded size. This is the default.
+	DecodedSizePolicyTruncate = "truncate"
+	// DecodedSizePolicyDrop does not emit the token.
+	DecodedSizePolicyDrop = "drop"
+	// DecodedSizePolicySplit emits the token as several tokens, none of which exceed the maximum decoded size.
+	DecodedSizePolicySplit = "split"
+)
+
+// validSizePolicy returns true if the policy is one of the DecodedSizePolicy values, or is empty for the default.
+func validSizePolicy(policy string) bool {
+	switch policy {
+	case "", DecodedSizePolicyTruncate, DecodedSizePolicyDrop, DecodedSizePolicySplit:
+		return true
+	}
+	return false
+}
+
+// limitDecodedSize appends the tokens which should be emitted in place of the decoded token to dst.
+// Some encodings expand significantly when decoded, so a token which was within max_log_size
+// may still be too large once decoded.
+func (r *Reader) limitDecodedSize(dst [][]byte, token []byte) [][]byte {
+	if r.maxDecodedSize <= 0 || len(token) <= r.maxDecodedSize {
+		return append(dst, token)
+	}
+	return r.limitSize(dst, token, r.maxDecodedSize, r.decodedSizePolicy, "maximum decoded size")
+}
+
+// limitSize appends the tokens which should be emitted in place of a token which is larger than maxSize to dst,
+// according to the given oversized token policy. The limit names the size which was exceeded when the token is dropped.
+func (r *Reader) limitSize(dst [][]byte, token []byte, maxSize int, policy, limit string) [][]byte {
+	switch policy {
+	case DecodedSizePolicyDrop:
+		r.set.Logger.Debug("dropping token which exceeds the "+limit, zap.Int("size", len(token)))
+		return dst
+	case DecodedSizePolicySplit:
+		for len(token) > maxSize {
+			cut := runeBoundary(token, maxSize)
+			dst = append(dst, token[:cut])
+			token = token[cut:]
+		}
+		return append(dst, token)
+	default:
+		return append(dst, token[:runeBoundary(token, maxSize)])
+	}
+}
+
+// runeBoundary returns the largest index, not greater than n, at which the token can be cut without splitting a rune.
+func runeBoundary(token []byte, n int) int {
+	for cut := n; cut > 0 && n-cut < utf8.UTFMax; cut-- {
+		if utf8.RuneStart(token[cut]) {
+			return cut
+		}
+	}
+	return n
+}
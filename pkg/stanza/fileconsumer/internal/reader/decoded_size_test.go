@@ -0,0 +1,138 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestMaxDecodedSize(t *testing.T) {
+	// Each 'é' is a single byte in ISO-8859-1 but two bytes once decoded to UTF-8
+	raw := strings.Repeat("\xe9", 10) + "\nshort\n"
+	decoded := strings.Repeat("é", 10)
+
+	testCases := []struct {
+		name           string
+		policy         string
+		maxDecodedSize int
+		expected       []string
+	}{
+		{
+			name:           "unlimited",
+			policy:         DecodedSizePolicyTruncate,
+			maxDecodedSize: 0,
+			expected:       []string{decoded, "short"},
+		},
+		{
+			name:           "within_limit",
+			policy:         DecodedSizePolicyTruncate,
+			maxDecodedSize: 20,
+			expected:       []string{decoded, "short"},
+		},
+		{
+			name:           "truncate",
+			policy:         DecodedSizePolicyTruncate,
+			maxDecodedSize: 12,
+			expected:       []string{strings.Repeat("é", 6), "short"},
+		},
+		{
+			name:           "truncate_rune_boundary",
+			policy:         DecodedSizePolicyTruncate,
+			maxDecodedSize: 11,
+			expected:       []string{strings.Repeat("é", 5), "short"},
+		},
+		{
+			name:           "default_truncate",
+			maxDecodedSize: 12,
+			expected:       []string{strings.Repeat("é", 6), "short"},
+		},
+		{
+			name:           "drop",
+			policy:         DecodedSizePolicyDrop,
+			maxDecodedSize: 12,
+			expected:       []string{"short"},
+		},
+		{
+			name:           "split",
+			policy:         DecodedSizePolicySplit,
+			maxDecodedSize: 8,
+			expected:       []string{strings.Repeat("é", 4), strings.Repeat("é", 4), strings.Repeat("é", 2), "short"},
+		},
+		{
+			name:           "split_rune_boundary",
+			policy:         DecodedSizePolicySplit,
+			maxDecodedSize: 7,
+			expected:       []string{strings.Repeat("é", 3), strings.Repeat("é", 3), strings.Repeat("é", 3), "é", "short"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, raw)
+
+			var tokens []string
+			var offsets []int64
+			f := newTestFactory(t, func(_ context.Context, batch [][]byte, _ map[string]any, _ int64, batchOffsets []int64) error {
+				for i, token := range batch {
+					tokens = append(tokens, string(token))
+					offsets = append(offsets, batchOffsets[i])
+				}
+				return nil
+			})
+			f.Encoding = charmap.ISO8859_1
+			f.MaxDecodedSize = tc.maxDecodedSize
+			f.DecodedSizePolicy = tc.policy
+			f.BackfillProfile = &ThroughputProfile{MaxBatchSize: 2}
+
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+
+			r.ReadToEnd(context.Background())
+			require.Equal(t, tc.expected, tokens)
+			require.Equal(t, int64(len(raw)), r.Offset)
+			require.Equal(t, int64(len(tc.expected)), r.RecordNum)
+
+			// The pieces of a split token share the offset of the original token
+			for i, offset := range offsets[:len(offsets)-1] {
+				require.Zero(t, offset, "token %d", i)
+			}
+			require.Equal(t, int64(11), offsets[len(offsets)-1])
+		})
+	}
+}
+
+func TestUnknownSizePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		set    func(*Factory)
+		expect string
+	}{
+		{name: "DecodedSize", set: func(f *Factory) { f.DecodedSizePolicy = "skip" }, expect: `unknown decoded size policy "skip"`},
+		{name: "LineLength", set: func(f *Factory) { f.LineLengthPolicy = "skip" }, expect: `unknown line length policy "skip"`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, _ := testFactory(t)
+			tc.set(f)
+			temp := filetest.OpenTemp(t, t.TempDir())
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			file := filetest.OpenFile(t, temp.Name())
+			_, err = f.NewReader(file, fp)
+			require.EqualError(t, err, tc.expect)
+			_, err = file.Stat()
+			require.ErrorIs(t, err, os.ErrClosed)
+		})
+	}
+}
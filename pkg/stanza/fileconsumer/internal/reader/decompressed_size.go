@@ -0,0 +1,35 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"errors"
+	"io"
+)
+
+var errDecompressedSizeExceeded = errors.New("maximum decompressed size exceeded")
+
+// decompressedSizeLimiter stops reading from a decompressing reader once it has produced more than a
+// maximum number of bytes, protecting against files which decompress to an unreasonable size.
+type decompressedSizeLimiter struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (l *decompressedSizeLimiter) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// The limit has been reached, which is only a problem if there is more to read
+		var probe [1]byte
+		if n, err := l.reader.Read(probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, errDecompressedSizeExceeded
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.reader.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
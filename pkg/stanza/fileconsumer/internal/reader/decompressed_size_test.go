@@ -0,0 +1,108 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestDecompressedSizeLimiter(t *testing.T) {
+	l := &decompressedSizeLimiter{reader: strings.NewReader("0123456789"), remaining: 4}
+	content, err := io.ReadAll(l)
+	require.ErrorIs(t, err, errDecompressedSizeExceeded)
+	assert.Equal(t, []byte("0123"), content)
+
+	// Content which fits exactly within the limit is read to EOF
+	l = &decompressedSizeLimiter{reader: strings.NewReader("0123"), remaining: 4}
+	content, err = io.ReadAll(l)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("0123"), content)
+}
+
+func TestMaxDecompressedSize(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// 1 MiB of repetitive lines compresses to a few KiB
+	line := strings.Repeat("a", 99) + "\n"
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := gzipWriter.Write([]byte(strings.Repeat(line, 10*1024)))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	compressed := buf.Bytes()
+	require.Less(t, len(compressed), 10*1024)
+
+	name := filepath.Join(tempDir, "bomb.log.gz")
+	require.NoError(t, os.WriteFile(name, compressed, 0o600))
+
+	const maxDecompressedSize = 1000
+	numLines := 10 * 1024
+	f, sink := testFactory(t, withSinkChanSize(numLines))
+	f.Compression = "gzip"
+	f.MaxDecompressedSize = maxDecompressedSize
+	core, logs := observer.New(zapcore.WarnLevel)
+	f.TelemetrySettings.Logger = zap.New(core)
+
+	file := filetest.OpenFile(t, name)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	expected := func(n int) [][]byte {
+		tokens := make([][]byte, n)
+		for i := range tokens {
+			tokens[i] = []byte(line[:len(line)-1])
+		}
+		return tokens
+	}
+	perPoll := maxDecompressedSize / len(line)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, expected(perPoll)...)
+	sink.ExpectNoCalls(t)
+
+	assert.Equal(t, 1, logs.FilterMessage("stopped reading compressed file").Len())
+	assert.Equal(t, int64(0), r.Offset)
+	assert.Equal(t, int64(maxDecompressedSize), r.DecompressedOffset)
+
+	// The next poll continues from the consumed boundary, including after a restart
+	encoded, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, name), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, expected(perPoll)...)
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, int64(0), r.Offset)
+	assert.Equal(t, int64(2*maxDecompressedSize), r.DecompressedOffset)
+
+	// Once the rest of the file is read within the limit, the offset moves to the end of the compressed file
+	r.maxDecompressedSize = int64(len(line) * numLines)
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, expected(numLines-2*perPoll)...)
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, int64(len(compressed)), r.Offset)
+	assert.Equal(t, int64(0), r.DecompressedOffset)
+}
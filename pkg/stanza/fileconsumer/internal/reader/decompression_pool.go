@@ -0,0 +1,186 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+const defaultDecompressionChunkSize = 64 * 1024
+
+// DecompressionPool is a fixed set of goroutines which readers share to decompress files.
+// Decompression happens ahead of the goroutine scanning the file, and the number of cores
+// spent decompressing is bounded by the number of workers rather than the number of files.
+type DecompressionPool struct {
+	tasks     chan func()
+	done      chan struct{}
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+	chunkSize int
+}
+
+// NewDecompressionPool starts a pool with the given number of workers. The pool must be stopped
+// once no readers are using it.
+func NewDecompressionPool(workers int) *DecompressionPool {
+	p := &DecompressionPool{
+		tasks:     make(chan func()),
+		done:      make(chan struct{}),
+		chunkSize: defaultDecompressionChunkSize,
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for {
+				select {
+				case <-p.done:
+					return
+				case task := <-p.tasks:
+					task()
+				}
+			}
+		}()
+	}
+	return p
+}
+
+// Stop stops the workers. Readers which are still using the pool decompress on their own goroutine.
+func (p *DecompressionPool) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+	p.wg.Wait()
+}
+
+// submit waits for a worker to accept the task, so readers are held back while all workers are busy.
+// The task is dropped if the context is done first, since the reader stops waiting for it, in which
+// case it returns false.
+func (p *DecompressionPool) submit(ctx context.Context, task func()) bool {
+	select {
+	case p.tasks <- task:
+	case <-p.done:
+		task()
+	case <-ctx.Done():
+		return false
+	}
+	return true
+}
+
+// newReader returns a reader which decompresses src on the pool, one chunk ahead of the caller. If position
+// tracks the compressed input of src, it is read on the worker along with each chunk.
+func (p *DecompressionPool) newReader(ctx context.Context, src io.Reader, position *compressedPosition) *pooledReader {
+	ctx, cancel := context.WithCancel(ctx)
+	pr := &pooledReader{
+		ctx:      ctx,
+		cancel:   cancel,
+		pool:     p,
+		src:      src,
+		position: position,
+		buffers:  [2][]byte{make([]byte, p.chunkSize), make([]byte, p.chunkSize)},
+		pending:  make(chan decompressedChunk, 1),
+	}
+	pr.prefetch()
+	return pr
+}
+
+type decompressedChunk struct {
+	data []byte
+	err  error
+	// pos is the compressed position once the chunk was decompressed
+	pos int64
+}
+
+// pooledReader alternates between two buffers. One holds the chunk being read by the caller while a
+// worker fills the other, so at most two chunks per file are held in memory.
+type pooledReader struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pool    *DecompressionPool
+	src     io.Reader
+	buffers [2][]byte
+	next    int
+	pending chan decompressedChunk
+	current []byte
+	err     error
+	// prefetching is held while a chunk is being decompressed
+	prefetching sync.WaitGroup
+	// position is advanced by the worker, so the caller only reads the position sent with the current chunk
+	position      *compressedPosition
+	compressedPos int64
+}
+
+func (pr *pooledReader) prefetch() {
+	dst := pr.buffers[pr.next]
+	pr.next = 1 - pr.next
+	pr.prefetching.Add(1)
+	task := func() {
+		defer pr.prefetching.Done()
+		// The reader may have been closed while the task waited for a worker
+		if pr.ctx.Err() != nil {
+			return
+		}
+		n, err := readChunk(pr.src, dst)
+		chunk := decompressedChunk{data: dst[:n], err: err}
+		if pr.position != nil {
+			chunk.pos = pr.position.pos
+		}
+		pr.pending <- chunk
+	}
+	if !pr.pool.submit(pr.ctx, task) {
+		pr.prefetching.Done()
+	}
+}
+
+// Close stops decompressing ahead of the caller, and waits for a chunk which is being decompressed, so that
+// the source is not read once the caller stops reading it.
+func (pr *pooledReader) Close() {
+	pr.cancel()
+	pr.prefetching.Wait()
+}
+
+func (pr *pooledReader) Read(p []byte) (int, error) {
+	if len(pr.current) == 0 {
+		if pr.err != nil {
+			return 0, pr.err
+		}
+		select {
+		case <-pr.ctx.Done():
+			return 0, pr.ctx.Err()
+		case chunk := <-pr.pending:
+			pr.current, pr.err, pr.compressedPos = chunk.data, chunk.err, chunk.pos
+		}
+		if pr.err == nil {
+			pr.prefetch()
+		}
+		if len(pr.current) == 0 {
+			return 0, pr.err
+		}
+	}
+	n := copy(p, pr.current)
+	pr.current = pr.current[n:]
+	return n, nil
+}
+
+// readChunk reads until dst is full or src returns an error. Unlike io.ReadFull, the error from src is returned
+// unchanged, so that a truncated compressed file is still reported as io.ErrUnexpectedEOF.
+func readChunk(src io.Reader, dst []byte) (int, error) {
+	var n int
+	for n < len(dst) {
+		m, err := src.Read(dst[n:])
+		n += m
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// stopPrefetch stops the decompression of a compressed file ahead of the reader, once the reader stops reading it.
+func (r *Reader) stopPrefetch() {
+	if pr, ok := r.reader.(*pooledReader); ok {
+		pr.Close()
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func writeGzipFile(tb testing.TB, path string, content string) {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := gzipWriter.Write([]byte(content))
+	require.NoError(tb, err)
+	require.NoError(tb, gzipWriter.Close())
+	require.NoError(tb, os.WriteFile(path, buf.Bytes(), 0o600))
+}
+
+func TestDecompressionPool(t *testing.T) {
+	pool := NewDecompressionPool(2)
+	defer pool.Stop()
+	// Use small chunks so that each file is decompressed in several tasks
+	pool.chunkSize = 16
+
+	tempDir := t.TempDir()
+	var lines []string
+	for i := 0; i < 50; i++ {
+		lines = append(lines, fmt.Sprintf("compressed line %d", i))
+	}
+	path := filepath.Join(tempDir, "test.log.gz")
+	writeGzipFile(t, path, strings.Join(lines, "\n")+"\n")
+
+	f, sink := testFactory(t, withSinkChanSize(len(lines)))
+	f.Compression = "gzip"
+	f.DecompressionPool = pool
+
+	file := filetest.OpenFile(t, path)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	for _, line := range lines {
+		sink.ExpectCall(t, []byte(line), map[string]any{attrs.LogFileName: "test.log.gz"})
+	}
+	sink.ExpectNoCalls(t)
+}
+
+func TestPooledReaderTruncatedInput(t *testing.T) {
+	pool := NewDecompressionPool(1)
+	defer pool.Stop()
+	pool.chunkSize = 4
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := gzipWriter.Write([]byte("some content which is truncated"))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	truncated := buf.Bytes()[:buf.Len()-4]
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(truncated))
+	require.NoError(t, err)
+	data, err := io.ReadAll(pool.newReader(context.Background(), gzipReader, nil))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.Equal(t, "some content which is truncated", string(data))
+}
+
+func TestPooledReaderContextCanceled(t *testing.T) {
+	// No workers accept tasks, so reading waits until the context is canceled
+	pool := &DecompressionPool{tasks: make(chan func()), done: make(chan struct{}), chunkSize: 4}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pr := pool.newReader(ctx, strings.NewReader("content"), nil)
+	_, err := pr.Read(make([]byte, 4))
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDecompressionPoolStopped(t *testing.T) {
+	pool := NewDecompressionPool(1)
+	pool.chunkSize = 4
+	pool.Stop()
+
+	// Readers decompress on their own goroutine once the pool is stopped
+	data, err := io.ReadAll(pool.newReader(context.Background(), strings.NewReader("some content"), nil))
+	require.NoError(t, err)
+	require.Equal(t, "some content", string(data))
+}
+
+// countingReader counts the reads of its source.
+type countingReader struct {
+	src   io.Reader
+	reads atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads.Add(1)
+	return c.src.Read(p)
+}
+
+func TestPooledReaderClose(t *testing.T) {
+	pool := NewDecompressionPool(1)
+	defer pool.Stop()
+	pool.chunkSize = 4
+
+	src := &countingReader{src: strings.NewReader(strings.Repeat("content ", 100))}
+	pr := pool.newReader(context.Background(), src, nil)
+	_, err := pr.Read(make([]byte, 4))
+	require.NoError(t, err)
+
+	// The source is not read once the reader is closed, including by a chunk which was being prefetched
+	pr.Close()
+	reads := src.reads.Load()
+	time.Sleep(10 * time.Millisecond)
+	require.Equal(t, reads, src.reads.Load())
+	_, err = pr.Read(make([]byte, 8))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, reads, src.reads.Load())
+}
+
+func TestReadToEndStopsPrefetch(t *testing.T) {
+	pool := NewDecompressionPool(1)
+	defer pool.Stop()
+	pool.chunkSize = 16
+
+	path := filepath.Join(t.TempDir(), "test.log.gz")
+	writeGzipFile(t, path, strings.Repeat("compressed line\n", 100))
+
+	f, sink := testFactory(t)
+	f.Compression = "gzip"
+	f.DecompressionPool = pool
+	file := filetest.OpenFile(t, path)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.NextTokens(t, 100)
+
+	// The reader stops decompressing ahead once ReadToEnd returns
+	pr, ok := r.reader.(*pooledReader)
+	require.True(t, ok)
+	require.ErrorIs(t, pr.ctx.Err(), context.Canceled)
+}
+
+func BenchmarkConcurrentGzipRead(b *testing.B) {
+	const numFiles = 64
+	tempDir := b.TempDir()
+	var content strings.Builder
+	for i := 0; i < 2000; i++ {
+		content.Write(filetest.TokenWithLength(200))
+		content.WriteString("\n")
+	}
+	paths := make([]string, numFiles)
+	for i := range paths {
+		paths[i] = filepath.Join(tempDir, fmt.Sprintf("file%d.log.gz", i))
+		writeGzipFile(b, paths[i], content.String())
+	}
+
+	for _, workers := range []int{0, 2, 4, 8} {
+		name := "Synchronous"
+		if workers > 0 {
+			name = fmt.Sprintf("Pool%d", workers)
+		}
+		b.Run(name, func(b *testing.B) {
+			var pool *DecompressionPool
+			if workers > 0 {
+				pool = NewDecompressionPool(workers)
+				defer pool.Stop()
+			}
+			counter := atomic.Int64{}
+			f := newTestFactory(b, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+				counter.Add(int64(len(tokens)))
+				return nil
+			})
+			f.Compression = "gzip"
+			f.DecompressionPool = pool
+
+			// The aggregate throughput across the files is reported against the decompressed size
+			b.SetBytes(int64(numFiles * content.Len()))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var wg sync.WaitGroup
+				for _, path := range paths {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						file, err := os.Open(path)
+						if err != nil {
+							b.Error(err)
+							return
+						}
+						fp, err := f.NewFingerprint(file)
+						if err != nil {
+							b.Error(err)
+							return
+						}
+						r, err := f.NewReader(file, fp)
+						if err != nil {
+							b.Error(err)
+							return
+						}
+						r.ReadToEnd(context.Background())
+						r.Close()
+					}()
+				}
+				wg.Wait()
+			}
+			b.StopTimer()
+			require.EqualValues(b, int64(b.N)*numFiles*2000, counter.Load())
+		})
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const defaultDedupFalsePositiveRate = 0.01
+
+// DedupFilter is a bloom filter of the tokens which were recently emitted from a file. It is persisted with the
+// rest of the metadata, so that tokens which are read again after a restart from an earlier offset are not
+// emitted twice. A token is identified by its content and its offset, so identical lines elsewhere in the file
+// are not affected. To remember only recent tokens, the filter is made of two generations. Once the current
+// generation holds Capacity tokens, it replaces the previous one and a new generation is started.
+type DedupFilter struct {
+	Current  []byte
+	Previous []byte
+	Count    int
+	Capacity int
+	Hashes   int
+}
+
+// newDedupFilter creates a filter for capacity tokens per generation with the given false positive rate.
+func newDedupFilter(capacity int, falsePositiveRate float64) *DedupFilter {
+	size, hashes := dedupFilterSize(capacity, falsePositiveRate)
+	return &DedupFilter{
+		Current:  make([]byte, size),
+		Previous: make([]byte, size),
+		Capacity: capacity,
+		Hashes:   hashes,
+	}
+}
+
+// dedupFilterSize returns the number of bytes in each generation of a filter, and the number of hashes.
+func dedupFilterSize(capacity int, falsePositiveRate float64) (size, hashes int) {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = defaultDedupFalsePositiveRate
+	}
+	bits := math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	return int(math.Ceil(bits / 8)), max(1, int(math.Round(bits/float64(capacity)*math.Ln2)))
+}
+
+// matches returns false if the filter was sized for a different configuration, which may be the case
+// when it was restored from metadata.
+func (d *DedupFilter) matches(capacity int, falsePositiveRate float64) bool {
+	size, hashes := dedupFilterSize(capacity, falsePositiveRate)
+	return d.Capacity == capacity && d.Hashes == hashes && len(d.Current) == size && len(d.Previous) == size
+}
+
+// dedupHash is the pair of hashes from which the bits of a token in the filter are derived.
+type dedupHash struct {
+	h1, h2 uint64
+}
+
+// dedupHashOf returns the hashes of the token which starts at the given offset.
+func dedupHashOf(token []byte, offset int64, index int) dedupHash {
+	var key [16]byte
+	binary.BigEndian.PutUint64(key[:8], uint64(offset))
+	binary.BigEndian.PutUint64(key[8:], uint64(index))
+	digest := xxhash.New()
+	_, _ = digest.Write(key[:])
+	_, _ = digest.Write(token)
+	h1 := digest.Sum64()
+	// The second hash is derived from the first, as two independent hashes are not needed for a bloom filter
+	return dedupHash{h1: h1, h2: h1>>33 | h1<<31 | 1}
+}
+
+// seen returns true if the token was already recorded.
+func (d *DedupFilter) seen(h dedupHash) bool {
+	bits := uint64(len(d.Current)) * 8
+	return d.contains(d.Current, h.h1, h.h2, bits) || d.contains(d.Previous, h.h1, h.h2, bits)
+}
+
+// add records the token which starts at the given offset, and returns false if it was already recorded.
+// The index distinguishes the tokens which a single decoded token is emitted as.
+func (d *DedupFilter) add(token []byte, offset int64, index int) bool {
+	return d.addHash(dedupHashOf(token, offset, index))
+}
+
+func (d *DedupFilter) addHash(h dedupHash) bool {
+	if d.seen(h) {
+		return false
+	}
+	if d.Count >= d.Capacity {
+		d.Current, d.Previous = d.Previous, d.Current
+		clear(d.Current)
+		d.Count = 0
+	}
+	bits := uint64(len(d.Current)) * 8
+	for i := range uint64(d.Hashes) {
+		bit := (h.h1 + i*h.h2) % bits
+		d.Current[bit/8] |= 1 << (bit % 8)
+	}
+	d.Count++
+	return true
+}
+
+func (d *DedupFilter) contains(filter []byte, h1, h2, bits uint64) bool {
+	for i := range uint64(d.Hashes) {
+		bit := (h1 + i*h2) % bits
+		if filter[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// dropDuplicates removes the tokens which were already emitted from the tokens decoded from the token
+// which starts at the given offset. The tokens which are kept are only recorded once they have been
+// emitted, since a batch which is not emitted is read again.
+func (r *Reader) dropDuplicates(tokens [][]byte, offset int64) [][]byte {
+	if r.Dedup == nil {
+		return tokens
+	}
+	kept := tokens[:0]
+	for i, token := range tokens {
+		h := dedupHashOf(token, offset, i)
+		if !r.Dedup.seen(h) {
+			r.dedupPending = append(r.dedupPending, h)
+			kept = append(kept, token)
+		}
+	}
+	return kept
+}
+
+// recordDuplicates records the tokens which were kept by dropDuplicates, once the batches they were read in
+// have been emitted or skipped.
+func (r *Reader) recordDuplicates() {
+	if r.Dedup != nil {
+		for _, h := range r.dedupPending {
+			r.Dedup.addHash(h)
+		}
+	}
+	r.dedupPending = r.dedupPending[:0]
+}
@@ -0,0 +1,138 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestDedupAfterRestart(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "a\nb\nc\n")
+
+	f, sink := testFactory(t)
+	f.DedupCapacity = 100
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("a"), []byte("b"), []byte("c"))
+
+	// The checkpoint is older than the records which were emitted, so they are read again
+	encoded, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	m.Offset = 2
+	filetest.WriteString(t, temp, "d\n")
+
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("d"))
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, int64(8), r.Offset)
+}
+
+func TestDedupHeldBatch(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "a\nb\n")
+
+	f, sink := testFactory(t)
+	f.DedupCapacity = 100
+	f.MinBatchSize = 3
+	f.MinBatchTimeout = 100 * time.Millisecond
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The held tokens were not emitted, so they are not duplicates when they are read again
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	time.Sleep(f.MinBatchTimeout)
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("a"), []byte("b"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestDedupRepeatedLines(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "same\nsame\nsame\n")
+
+	f, sink := testFactory(t)
+	f.DedupCapacity = 100
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// Identical records at different offsets are not duplicates
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("same"), []byte("same"), []byte("same"))
+}
+
+func TestDedupDisabled(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "a\n")
+
+	f, sink := testFactory(t)
+	f.DedupCapacity = 10
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("a"))
+
+	m := r.Close()
+	require.NotNil(t, m.Dedup)
+	m.Offset = 0
+
+	// The filter is discarded once deduplication is disabled
+	f.DedupCapacity = 0
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Nil(t, r.Dedup)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("a"))
+}
+
+func TestDedupFilterGenerations(t *testing.T) {
+	d := newDedupFilter(10, 0.001)
+	require.True(t, d.matches(10, 0.001))
+	require.False(t, d.matches(20, 0.001))
+
+	token := func(i int) []byte { return []byte(fmt.Sprintf("token%d", i)) }
+	for i := range 10 {
+		require.True(t, d.add(token(i), int64(i), 0))
+	}
+	require.False(t, d.add(token(0), 0, 0))
+
+	// The first generation is kept while the second is filled
+	for i := 10; i < 20; i++ {
+		require.True(t, d.add(token(i), int64(i), 0))
+	}
+	require.False(t, d.add(token(0), 0, 0))
+
+	// Then it is forgotten, while the tokens of the second generation are still recognized
+	require.True(t, d.add(token(20), 20, 0))
+	require.False(t, d.add(token(15), 15, 0))
+	require.True(t, d.add(token(0), 0, 0))
+}
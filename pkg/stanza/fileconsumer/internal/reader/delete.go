@@ -0,0 +1,169 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const defaultDeleteRetryBackoff = 100 * time.Millisecond
+
+// maxDeleteRetryWait bounds the total time spent waiting to retry the deletion of a file, since the poll waits.
+const maxDeleteRetryWait = time.Second
+
+// removeFile and renameFile are replaced in tests to simulate files which cannot be deleted or moved
+var (
+	removeFile = os.Remove
+	renameFile = os.Rename
+)
+
+// delete closes and deletes the file, or moves it to the archive directory if one is configured. A failed
+// deletion is retried up to the configured number of times, doubling the backoff after each attempt, until the
+// total backoff reaches maxDeleteRetryWait. If the file still cannot be deleted, or the context is done before
+// it is, it is flagged as pending and deleted the next time it is read to the end. Only the first failure for
+// a file is logged as a warning.
+func (r *Reader) delete(ctx context.Context) {
+	r.close()
+
+	backoff, remaining := r.deleteRetryBackoff, maxDeleteRetryWait
+	var err error
+	attempts := 0
+	for {
+		err = r.deleteFile()
+		attempts++
+		if err == nil || errors.Is(err, os.ErrNotExist) || attempts > r.deleteRetries || remaining <= 0 {
+			break
+		}
+		wait := min(backoff, remaining)
+		if !r.waitToRetryDelete(ctx, err, wait) {
+			break
+		}
+		backoff, remaining = backoff*2, remaining-wait
+	}
+
+	switch {
+	case err == nil || errors.Is(err, os.ErrNotExist):
+		if r.pendingDelete {
+			r.set.Logger.Info("deleted file which previously could not be deleted")
+		}
+		r.pendingDelete = false
+	case r.pendingDelete:
+		r.set.Logger.Debug("could not delete", zap.Error(err))
+	default:
+		r.set.Logger.Warn("could not delete, the file will be deleted when it is next read", zap.Error(err), zap.Int("attempts", attempts))
+		r.pendingDelete = true
+	}
+}
+
+// waitToRetryDelete waits for the backoff before the next attempt to delete the file. It returns false if the
+// context is done first.
+func (r *Reader) waitToRetryDelete(ctx context.Context, err error, backoff time.Duration) bool {
+	r.set.Logger.Debug("could not delete, retrying", zap.Error(err), zap.Duration("backoff", backoff))
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (r *Reader) deleteFile() error {
+	if r.deleteArchiveDir == "" {
+		return removeFile(r.fileName)
+	}
+	// The file was already copied into the archive directory, so only its removal is retried
+	if r.archivedCopy != "" {
+		return removeFile(r.fileName)
+	}
+	copied, err := archiveFile(r.fileName, r.deleteArchiveDir)
+	if err != nil && copied != "" {
+		r.archivedCopy = copied
+	}
+	return err
+}
+
+// archiveFile moves a file into dir. If a file of the same name is already there, a numeric suffix is added
+// to the name. A file which cannot be renamed into dir because it is on another filesystem is copied and removed.
+// If the copy was made but the file could not be removed, the path of the copy is returned with the error.
+func archiveFile(name, dir string) (copied string, err error) {
+	if _, err = os.Lstat(name); err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("create archive directory: %w", err)
+	}
+	dst, err := reserveArchivePath(dir, filepath.Base(name))
+	if err != nil {
+		return "", err
+	}
+
+	// The reserved file is replaced by the rename, so no other file can be overwritten
+	err = renameFile(name, dst)
+	if err == nil {
+		return "", nil
+	}
+	if !crossDevice(err) {
+		_ = os.Remove(dst)
+		return "", err
+	}
+	if err = copyFile(name, dst); err != nil {
+		_ = os.Remove(dst)
+		return "", err
+	}
+	if err = removeFile(name); err != nil {
+		return dst, err
+	}
+	return "", nil
+}
+
+// reserveArchivePath creates an empty file in dir under the first name, starting with base, which is not taken.
+// Creating the file claims the name, so files which are archived at the same time cannot be given the same one.
+func reserveArchivePath(dir, base string) (string, error) {
+	dst := filepath.Join(dir, base)
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			return dst, f.Close()
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", fmt.Errorf("reserve archive path: %w", err)
+		}
+		dst = filepath.Join(dir, fmt.Sprintf("%s.%d", base, i))
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if err = out.Chmod(info.Mode().Perm()); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if _, err = io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("copy to archive directory: %w", err)
+	}
+	return out.Close()
+}
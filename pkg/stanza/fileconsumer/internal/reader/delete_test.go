@@ -0,0 +1,216 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+var errFileInUse = errors.New("file in use")
+
+// failRemove makes the next n deletions fail, counting every attempt.
+func failRemove(t *testing.T, n int) *int {
+	var attempts int
+	removeFile = func(name string) error {
+		attempts++
+		if attempts <= n {
+			return errFileInUse
+		}
+		return os.Remove(name)
+	}
+	t.Cleanup(func() { removeFile = os.Remove })
+	return &attempts
+}
+
+func TestDeleteRetry(t *testing.T) {
+	attempts := failRemove(t, 1)
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog1\n")
+
+	f, sink := testFactory(t)
+	f.DeleteAtEOF = true
+	f.DeleteRetries = 2
+	f.DeleteRetryBackoff = time.Millisecond
+	core, logs := observer.New(zapcore.WarnLevel)
+	f.TelemetrySettings.Logger = zap.New(core)
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog1"))
+
+	assert.Equal(t, 2, *attempts)
+	assert.NoFileExists(t, temp.Name())
+	assert.False(t, r.pendingDelete)
+	assert.Equal(t, 0, logs.Len())
+}
+
+func TestDeletePending(t *testing.T) {
+	attempts := failRemove(t, 2)
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog1\n")
+
+	f, sink := testFactory(t)
+	f.DeleteAtEOF = true
+	f.DeleteRetries = 1
+	f.DeleteRetryBackoff = time.Millisecond
+	core, logs := observer.New(zapcore.WarnLevel)
+	f.TelemetrySettings.Logger = zap.New(core)
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog1"))
+
+	// Deletion failed on every attempt, so the file is left behind and flagged
+	assert.Equal(t, 2, *attempts)
+	assert.FileExists(t, temp.Name())
+	assert.Equal(t, 1, logs.FilterMessage("could not delete, the file will be deleted when it is next read").Len())
+
+	// The file is deleted when it is next read to the end
+	file := filetest.OpenFile(t, temp.Name())
+	r, err = f.NewReaderFromMetadata(file, r.Close())
+	require.NoError(t, err)
+	require.True(t, r.pendingDelete)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, 3, *attempts)
+	assert.NoFileExists(t, temp.Name())
+	assert.False(t, r.pendingDelete)
+}
+
+func TestDeleteRetryWaitIsBounded(t *testing.T) {
+	attempts := failRemove(t, 100)
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\n")
+
+	f, sink := testFactory(t)
+	f.DeleteAtEOF = true
+	f.DeleteRetries = 10
+	f.DeleteRetryBackoff = 300 * time.Millisecond
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	// The retries stop once the backoff adds up to the maximum wait, after waiting 300ms, 600ms and then 100ms
+	start := time.Now()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog1"))
+	assert.Less(t, time.Since(start), maxDeleteRetryWait+time.Second)
+	assert.Equal(t, 4, *attempts)
+	assert.FileExists(t, temp.Name())
+	assert.True(t, r.pendingDelete)
+}
+
+func TestDeleteRetryCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var attempts int
+	removeFile = func(string) error {
+		attempts++
+		cancel()
+		return errFileInUse
+	}
+	t.Cleanup(func() { removeFile = os.Remove })
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\n")
+
+	f, _ := testFactory(t)
+	f.DeleteAtEOF = true
+	f.DeleteRetries = 3
+	f.DeleteRetryBackoff = time.Hour
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	// The backoff is cut short once the context is done, and the file is deleted when it is next read
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ReadToEnd(ctx)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "delete did not stop retrying once the context was done")
+	}
+	assert.Equal(t, 1, attempts)
+	assert.FileExists(t, temp.Name())
+	assert.True(t, r.pendingDelete)
+}
+
+func TestDeleteToArchive(t *testing.T) {
+	tempDir := t.TempDir()
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+	f, sink := testFactory(t)
+	f.DeleteAtEOF = true
+	f.DeleteArchiveDir = archiveDir
+
+	// The second file of the same name is archived alongside the first
+	for i, content := range []string{"testlog1\n", "testlog2\n"} {
+		temp, err := os.Create(filepath.Join(tempDir, "app.log"))
+		require.NoError(t, err)
+		filetest.WriteString(t, temp, content)
+
+		fp, err := f.NewFingerprint(temp)
+		require.NoError(t, err)
+		r, err := f.NewReader(temp, fp)
+		require.NoError(t, err)
+		r.ReadToEnd(context.Background())
+		sink.ExpectToken(t, []byte(content[:len(content)-1]))
+		assert.NoFileExists(t, temp.Name(), "file %d", i)
+		assert.False(t, r.pendingDelete)
+	}
+
+	archived, err := os.ReadFile(filepath.Join(archiveDir, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "testlog1\n", string(archived))
+	archived, err = os.ReadFile(filepath.Join(archiveDir, "app.log.1"))
+	require.NoError(t, err)
+	assert.Equal(t, "testlog2\n", string(archived))
+}
+
+func TestReserveArchivePath(t *testing.T) {
+	archiveDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(archiveDir, "app.log"), []byte("testlog1\n"), 0o600))
+
+	// Each reservation claims its name, so a name is not handed out twice before the file is moved there
+	first, err := reserveArchivePath(archiveDir, "app.log")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(archiveDir, "app.log.1"), first)
+	second, err := reserveArchivePath(archiveDir, "app.log")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(archiveDir, "app.log.2"), second)
+
+	archived, err := os.ReadFile(filepath.Join(archiveDir, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "testlog1\n", string(archived))
+}
@@ -0,0 +1,16 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"errors"
+	"syscall"
+)
+
+// crossDevice returns true if a rename failed because the destination is on another filesystem.
+func crossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,94 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package reader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestArchiveFileCrossDevice(t *testing.T) {
+	renameFile = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	src := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(src, []byte("testlog1\n"), 0o600))
+	archiveDir := t.TempDir()
+
+	copied, err := archiveFile(src, archiveDir)
+	require.NoError(t, err)
+	assert.Empty(t, copied)
+	assert.NoFileExists(t, src)
+	archived, err := os.ReadFile(filepath.Join(archiveDir, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "testlog1\n", string(archived))
+	info, err := os.Stat(filepath.Join(archiveDir, "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestArchiveFileRenameError(t *testing.T) {
+	renameFile = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EACCES}
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	src := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(src, []byte("testlog1\n"), 0o600))
+	archiveDir := t.TempDir()
+
+	// Only a rename across filesystems falls back to copying
+	copied, err := archiveFile(src, archiveDir)
+	require.ErrorIs(t, err, syscall.EACCES)
+	assert.Empty(t, copied)
+	assert.FileExists(t, src)
+	assert.NoFileExists(t, filepath.Join(archiveDir, "app.log"))
+}
+
+func TestDeleteToArchiveRemoveFailsAfterCopy(t *testing.T) {
+	renameFile = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { renameFile = os.Rename })
+	attempts := failRemove(t, 1)
+
+	archiveDir := filepath.Join(t.TempDir(), "archive")
+	f, sink := testFactory(t)
+	f.DeleteAtEOF = true
+	f.DeleteArchiveDir = archiveDir
+	f.DeleteRetries = 1
+	f.DeleteRetryBackoff = time.Millisecond
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\n")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog1"))
+
+	// The removal is retried without copying the file into the archive again
+	assert.Equal(t, 2, *attempts)
+	assert.NoFileExists(t, temp.Name())
+	entries, err := os.ReadDir(archiveDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	archived, err := os.ReadFile(filepath.Join(archiveDir, entries[0].Name()))
+	require.NoError(t, err)
+	assert.Equal(t, "testlog1\n", string(archived))
+}
@@ -0,0 +1,39 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "bytes"
+
+// trimTrailingDelimiter removes a trailing line ending from a decoded token if configured to do so,
+// followed by the configured trailing delimiter. Tokens which do not end with them are unchanged.
+func (r *Reader) trimTrailingDelimiter(token []byte) []byte {
+	if r.trimLineEnding {
+		if trimmed, ok := bytes.CutSuffix(token, []byte("\n")); ok {
+			token = bytes.TrimSuffix(trimmed, []byte("\r"))
+		} else {
+			token = bytes.TrimSuffix(token, []byte("\r"))
+		}
+	}
+	if len(r.trailingDelimiter) > 0 {
+		token = bytes.TrimSuffix(token, r.trailingDelimiter)
+	}
+	return token
+}
+
+// normalizeNewlines replaces the CRLF line breaks within a decoded multiline token with LF, if configured to do so.
+// A line ending at the end of the token is kept as it is, so single line tokens are unchanged. Offsets are taken
+// from the raw bytes, so they are not affected either.
+func (r *Reader) normalizeNewlines(token []byte) []byte {
+	if !r.normalizeCRLF {
+		return token
+	}
+	body, lineEnding := token, []byte(nil)
+	if trimmed, ok := bytes.CutSuffix(token, []byte("\r\n")); ok {
+		body, lineEnding = trimmed, []byte("\r\n")
+	}
+	if !bytes.Contains(body, []byte("\r\n")) {
+		return token
+	}
+	return append(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n")), lineEnding...)
+}
@@ -0,0 +1,121 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/split"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/trim"
+)
+
+func TestTrimTrailingDelimiter(t *testing.T) {
+	testCases := []struct {
+		name           string
+		delimiter      string
+		trimLineEnding bool
+		token          string
+		expected       string
+	}{
+		{"NotConfigured", "", false, "record;\n", "record;\n"},
+		{"Present", ";", false, "record;", "record"},
+		{"Absent", ";", false, "record", "record"},
+		{"OnlyOnce", ";", false, "record;;", "record;"},
+		{"NotTrailing", ";", false, "rec;ord", "rec;ord"},
+		{"MultiByte", "<EOR>", false, "record<EOR>", "record"},
+		{"MultiBytePartial", "<EOR>", false, "record<EO", "record<EO"},
+		{"MultiByteRune", "§§", false, "record§§", "record"},
+		{"Newline", "", true, "record\n", "record"},
+		{"CarriageReturnNewline", "", true, "record\r\n", "record"},
+		{"CarriageReturn", "", true, "record\r", "record"},
+		{"NoLineEnding", "", true, "record", "record"},
+		{"LineEndingThenDelimiter", ";", true, "record;\r\n", "record"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Reader{trailingDelimiter: []byte(tc.delimiter), trimLineEnding: tc.trimLineEnding}
+			assert.Equal(t, []byte(tc.expected), r.trimTrailingDelimiter([]byte(tc.token)))
+		})
+	}
+}
+
+func TestTrailingDelimiterIsTrimmedBeforeEmit(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "first<EOR>\nsecond\n<EOR>third<EOR>\n<EOR>")
+
+	f, sink := testFactory(t)
+	f.SplitFunc = split.LineEndSplitFunc(regexp.MustCompile(`<EOR>`), false, false)
+	f.TrimFunc = trim.Nop
+	f.TrailingDelimiter = []byte("<EOR>")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("first"), []byte("\nsecond\n"), []byte("third"), []byte("\n"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		enabled  bool
+		token    string
+		expected string
+	}{
+		{"NotConfigured", false, "first\r\nsecond", "first\r\nsecond"},
+		{"Multiline", true, "first\r\nsecond\r\nthird", "first\nsecond\nthird"},
+		{"TrailingLineEnding", true, "first\r\nsecond\r\n", "first\nsecond\r\n"},
+		{"SingleLine", true, "record\r\n", "record\r\n"},
+		{"LineFeeds", true, "first\nsecond", "first\nsecond"},
+		{"CarriageReturn", true, "first\rsecond", "first\rsecond"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &Reader{normalizeCRLF: tc.enabled}
+			assert.Equal(t, []byte(tc.expected), r.normalizeNewlines([]byte(tc.token)))
+		})
+	}
+}
+
+func TestNormalizeNewlinesBeforeEmit(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	first := "START panic\r\n  at foo\r\n  at bar\r\n"
+	second := "START single\r\n"
+	filetest.WriteString(t, temp, first+second+"START next")
+
+	var tokens []string
+	var offsets []int64
+	f := newTestFactory(t, func(_ context.Context, batch [][]byte, _ map[string]any, _ int64, batchOffsets []int64) error {
+		for i, token := range batch {
+			tokens = append(tokens, string(token))
+			offsets = append(offsets, batchOffsets[i])
+		}
+		return nil
+	})
+	f.SplitFunc = split.LineStartSplitFunc(regexp.MustCompile(`START`), false, false)
+	f.NormalizeNewlines = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, []string{"START panic\n  at foo\n  at bar", "START single"}, tokens)
+
+	// The offsets are those of the raw bytes
+	assert.Equal(t, []int64{0, int64(len(first))}, offsets)
+	assert.Equal(t, int64(len(first+second)), r.Offset)
+}
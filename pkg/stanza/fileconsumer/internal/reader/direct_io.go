@@ -0,0 +1,107 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"errors"
+	"io"
+	"os"
+	"unsafe"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// directIOAlignment is the alignment of offsets, lengths, and buffers which works for direct I/O on common block devices
+	directIOAlignment  = 4096
+	directIOBufferSize = 256 * 1024
+)
+
+var errDirectIOUnsupported = errors.New("direct I/O is not supported on this platform")
+
+// startDirectIO switches reading to a second handle, opened for direct I/O, so that reading the file does not fill
+// the page cache. It falls back to reading through the page cache if the file cannot be opened for direct I/O,
+// as is the case for some file systems.
+func (r *Reader) startDirectIO() {
+	if !r.directIO || r.reader != io.Reader(r.file) {
+		return
+	}
+	file, err := openDirect(r.file.Name())
+	if err != nil {
+		r.set.Logger.Debug("failed to open file for direct I/O, reading through the page cache", zap.Error(err))
+		return
+	}
+	// The path may have been replaced since the file was opened
+	if info, statErr := file.Stat(); statErr != nil || !r.SameFile(info) {
+		_ = file.Close()
+		return
+	}
+	r.reader = newDirectReader(file, r.Offset)
+}
+
+// stopDirectIO closes the handle opened for direct I/O, if any.
+func (r *Reader) stopDirectIO() {
+	direct, ok := r.reader.(*directReader)
+	if !ok {
+		return
+	}
+	if err := direct.file.Close(); err != nil {
+		r.set.Logger.Debug("problem closing direct I/O handle", zap.Error(err))
+	}
+	r.reader = r.file
+}
+
+// directReader reads a file opened for direct I/O from an arbitrary offset. Reads are made into an aligned buffer
+// from aligned offsets, and the bytes before the requested offset are discarded.
+type directReader struct {
+	file       *os.File
+	buf        []byte
+	start, end int
+	pos        int64
+	skip       int
+	err        error
+}
+
+func newDirectReader(file *os.File, offset int64) *directReader {
+	d := &directReader{file: file, buf: alignedBuffer(directIOBufferSize)}
+	d.reset(offset)
+	return d
+}
+
+// reset discards anything which was buffered, so that reading continues from the offset.
+func (d *directReader) reset(offset int64) {
+	d.pos = offset &^ (directIOAlignment - 1)
+	d.skip = int(offset - d.pos)
+	d.start, d.end = 0, 0
+	d.err = nil
+}
+
+func (d *directReader) Read(p []byte) (int, error) {
+	for d.start == d.end {
+		if d.err != nil {
+			return 0, d.err
+		}
+		// A short read is the end of the file, after which the position is no longer aligned,
+		// so nothing more is read until the reader is reset.
+		n, err := d.file.ReadAt(d.buf, d.pos)
+		d.pos += int64(n)
+		d.err = err
+		skipped := min(d.skip, n)
+		d.skip -= skipped
+		d.start, d.end = skipped, n
+	}
+	n := copy(p, d.buf[d.start:d.end])
+	d.start += n
+	return n, nil
+}
+
+// alignedBuffer returns a buffer of the given size which starts at an address aligned for direct I/O.
+func alignedBuffer(size int) []byte {
+	buf := make([]byte, size+directIOAlignment)
+	offset := 0
+	if rem := int(uintptr(unsafe.Pointer(&buf[0])) & (directIOAlignment - 1)); rem != 0 {
+		offset = directIOAlignment - rem
+	}
+	return buf[offset : offset+size : offset+size]
+}
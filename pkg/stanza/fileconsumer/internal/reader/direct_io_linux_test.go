@@ -0,0 +1,93 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestDirectIO(t *testing.T) {
+	for _, directIO := range []bool{false, true} {
+		t.Run(fmt.Sprintf("DirectIO=%t", directIO), func(t *testing.T) {
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+			var content strings.Builder
+			numLines := 0
+			for ; content.Len() < 4*1024*1024; numLines++ {
+				fmt.Fprintf(&content, "this is line number %d\n", numLines)
+			}
+			filetest.WriteString(t, temp, content.String())
+			evictPageCache(t, temp)
+
+			var tokens int
+			f := newTestFactory(t, func(_ context.Context, batch [][]byte, _ map[string]any, _ int64, _ []int64) error {
+				tokens += len(batch)
+				return nil
+			})
+			f.DirectIO = directIO
+			file := filetest.OpenFile(t, temp.Name())
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err := f.NewReader(file, fp)
+			require.NoError(t, err)
+
+			r.ReadToEnd(context.Background())
+			require.Equal(t, numLines, tokens)
+			require.Equal(t, int64(content.Len()), r.Offset)
+
+			resident := residentFraction(t, temp)
+			if directIO {
+				// Only the pages read for the fingerprint are cached
+				assert.Less(t, resident, 0.01)
+			} else {
+				assert.Greater(t, resident, 0.5)
+			}
+		})
+	}
+}
+
+func evictPageCache(t *testing.T, file *os.File) {
+	require.NoError(t, file.Sync())
+	require.NoError(t, unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_DONTNEED))
+	if resident := residentFraction(t, file); resident > 0.01 {
+		t.Skipf("page cache could not be evicted, %.2f of the file is resident", resident)
+	}
+	// Direct I/O is not supported by all file systems
+	direct, err := openDirect(file.Name())
+	if err != nil {
+		t.Skipf("direct I/O is not supported: %v", err)
+	}
+	require.NoError(t, direct.Close())
+}
+
+func residentFraction(t *testing.T, file *os.File) float64 {
+	info, err := file.Stat()
+	require.NoError(t, err)
+	data, err := unix.Mmap(int(file.Fd()), 0, int(info.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, unix.Munmap(data)) }()
+
+	pageSize := os.Getpagesize()
+	vec := make([]byte, (len(data)+pageSize-1)/pageSize)
+	_, _, errno := unix.Syscall(unix.SYS_MINCORE, uintptr(unsafe.Pointer(&data[0])), uintptr(len(data)), uintptr(unsafe.Pointer(&vec[0])))
+	require.Zero(t, errno)
+	var resident int
+	for _, v := range vec {
+		resident += int(v & 1)
+	}
+	return float64(resident) / float64(len(vec))
+}
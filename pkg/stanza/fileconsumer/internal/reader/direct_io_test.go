@@ -0,0 +1,55 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestDirectReader(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	var content strings.Builder
+	for i := 0; content.Len() < 2*directIOBufferSize+directIOAlignment/2; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	filetest.WriteString(t, temp, content.String())
+
+	offsets := []int64{0, 1, directIOAlignment - 1, directIOAlignment, directIOAlignment + 7, directIOBufferSize + 3, int64(content.Len()) - 1, int64(content.Len())}
+	for _, offset := range offsets {
+		t.Run(fmt.Sprintf("%d", offset), func(t *testing.T) {
+			// The reader is exercised without direct I/O, which not all file systems support
+			d := newDirectReader(temp, offset)
+			data, err := io.ReadAll(d)
+			require.NoError(t, err)
+			assert.Equal(t, content.String()[offset:], string(data))
+		})
+	}
+
+	d := newDirectReader(temp, 5)
+	buf := make([]byte, 10)
+	_, err := io.ReadFull(d, buf)
+	require.NoError(t, err)
+	d.reset(directIOAlignment + 1)
+	_, err = io.ReadFull(d, buf)
+	require.NoError(t, err)
+	assert.Equal(t, content.String()[directIOAlignment+1:directIOAlignment+11], string(buf))
+}
+
+func TestAlignedBuffer(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		buf := alignedBuffer(directIOBufferSize)
+		assert.Len(t, buf, directIOBufferSize)
+		assert.Zero(t, uintptr(unsafe.Pointer(&buf[0]))%directIOAlignment)
+	}
+}
@@ -0,0 +1,17 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+// DiskPressureFunc reports whether the disk which holds the checkpoints is under pressure. Reading
+// is paused while it is, since checkpoints of any further progress could fail to be written.
+type DiskPressureFunc func() bool
+
+// underDiskPressure returns true if the poll should be skipped because of disk pressure.
+func (r *Reader) underDiskPressure() bool {
+	if r.diskPressure == nil || !r.diskPressure() {
+		return false
+	}
+	r.set.Logger.Debug("skipping read, disk is under pressure")
+	return true
+}
@@ -0,0 +1,39 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestDiskPressure(t *testing.T) {
+	f, sink := testFactory(t)
+	var pressure bool
+	f.DiskPressure = func() bool { return pressure }
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "a\nb\n")
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	pressure = true
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(0), r.Offset)
+
+	// Reading resumes where it was paused once the pressure is relieved
+	pressure = false
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("a"), []byte("b"))
+	require.Equal(t, int64(4), r.Offset)
+}
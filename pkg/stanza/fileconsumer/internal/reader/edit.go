@@ -0,0 +1,63 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
+)
+
+const (
+	// InPlaceEditIgnore keeps reading a file which was edited in place from the current offset
+	InPlaceEditIgnore = "ignore"
+	// InPlaceEditRestart reads a file which was edited in place again from the start
+	InPlaceEditRestart = "restart"
+	// InPlaceEditStop stops reading a file which was edited in place
+	InPlaceEditStop = "stop"
+)
+
+// checkInPlaceEdit checks that the file still starts with its fingerprint before it is read, in which case the
+// offset still applies to it. It returns false if the file should not be read.
+func (r *Reader) checkInPlaceEdit() bool {
+	if r.editStopped {
+		return false
+	}
+	if r.inPlaceEditPolicy == "" || r.inPlaceEditPolicy == InPlaceEditIgnore || r.Fingerprint.Len() == 0 {
+		return true
+	}
+	current, err := r.newFingerprint(r.file)
+	if err != nil {
+		r.set.Logger.Debug("failed to check for in place edit", zap.Error(err))
+		return true
+	}
+	if current.StartsWith(r.Fingerprint) {
+		return true
+	}
+	r.inPlaceEdited(current)
+	return !r.editStopped
+}
+
+// inPlaceEdited applies the configured policy to a file which no longer starts with its fingerprint. Unless edits
+// are ignored, the reader takes the current fingerprint, so that the file is still recognized by it.
+func (r *Reader) inPlaceEdited(current *fingerprint.Fingerprint) {
+	if r.inPlaceEditPolicy != InPlaceEditRestart && r.inPlaceEditPolicy != InPlaceEditStop {
+		return
+	}
+	if r.hashFingerprint {
+		current = current.Hashed()
+	}
+	switch r.inPlaceEditPolicy {
+	case InPlaceEditRestart:
+		r.set.Logger.Warn("file was edited in place, reading it again from the start", zap.Int64("offset", r.Offset))
+		r.resetToStart(current)
+		if r.headerConfig != nil && r.headerReader == nil {
+			r.rearmHeader()
+		}
+	case InPlaceEditStop:
+		r.set.Logger.Warn("file was edited in place, it will not be read any further", zap.Int64("offset", r.Offset))
+		r.Fingerprint = current
+		r.editStopped = true
+	}
+}
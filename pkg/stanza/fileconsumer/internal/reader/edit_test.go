@@ -0,0 +1,90 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestInPlaceEdit(t *testing.T) {
+	testCases := []struct {
+		name         string
+		policy       string
+		expectTokens [][]byte
+		expectOffset int64
+	}{
+		{
+			name:         "Default",
+			expectTokens: [][]byte{[]byte("testlog3")},
+			expectOffset: int64(len("TESTLOG1\ntestlog2\ntestlog3\n")),
+		},
+		{
+			name:         "Ignore",
+			policy:       InPlaceEditIgnore,
+			expectTokens: [][]byte{[]byte("testlog3")},
+			expectOffset: int64(len("TESTLOG1\ntestlog2\ntestlog3\n")),
+		},
+		{
+			name:         "Restart",
+			policy:       InPlaceEditRestart,
+			expectTokens: [][]byte{[]byte("TESTLOG1"), []byte("testlog2"), []byte("testlog3")},
+			expectOffset: int64(len("TESTLOG1\ntestlog2\ntestlog3\n")),
+		},
+		{
+			name:         "Stop",
+			policy:       InPlaceEditStop,
+			expectOffset: int64(len("testlog1\ntestlog2\n")),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+			filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+			f, sink := testFactory(t)
+			f.InPlaceEditPolicy = tc.policy
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+			require.NoError(t, err)
+
+			r.ReadToEnd(context.Background())
+			sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+
+			// The first line is edited in place between polls, and another line is appended
+			_, err = temp.WriteAt([]byte("TESTLOG1"), 0)
+			require.NoError(t, err)
+			filetest.WriteString(t, temp, "testlog3\n")
+
+			r.ReadToEnd(context.Background())
+			if len(tc.expectTokens) > 0 {
+				sink.ExpectTokens(t, tc.expectTokens...)
+			}
+			sink.ExpectNoCalls(t)
+			assert.Equal(t, tc.expectOffset, r.Offset)
+
+			if tc.policy == InPlaceEditRestart || tc.policy == InPlaceEditStop {
+				// The reader takes the fingerprint of the edited content
+				assert.Equal(t, fingerprint.New([]byte("TESTLOG1\ntestlog2\ntestlog3\n")), r.Fingerprint)
+			}
+
+			filetest.WriteString(t, temp, "testlog4\n")
+			r.ReadToEnd(context.Background())
+			if tc.policy == InPlaceEditStop {
+				// The file is not read any further
+				sink.ExpectNoCalls(t)
+			} else {
+				sink.ExpectToken(t, []byte("testlog4"))
+			}
+		})
+	}
+}
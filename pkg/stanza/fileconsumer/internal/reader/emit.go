@@ -0,0 +1,233 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/multierr"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/emit"
+)
+
+// tokenAttributes returns the attributes that apply only to the given piece of the token read at the given offset,
+// or nil if there are none.
+func (r *Reader) tokenAttributes(token []byte, offset int64, piece int) map[string]any {
+	var attributes map[string]any
+	// RecordNum is persisted with the rest of the metadata, so the first record is not flagged again when
+	// reading resumes after a restart. A rotated or truncated file is tracked as a new file and is flagged again.
+	if r.includeFirstRecord && r.RecordNum == 0 {
+		attributes = withAttribute(attributes, attrs.LogFileFirstRecord, true)
+	}
+	if r.partialToken {
+		attributes = withAttribute(attributes, attrs.LogFilePartial, true)
+	}
+	if r.decodeFallbackUsed {
+		attributes = withAttribute(attributes, attrs.LogFileDecodeFallback, r.decodeFallback)
+	}
+	if r.truncatedToken {
+		attributes = withAttribute(attributes, attrs.LogFileTruncated, true)
+	}
+	if r.isTooLongPiece(token) {
+		attributes = withAttribute(attributes, attrs.LogFileLineTooLong, true)
+	}
+	// The reader of a compressed file is only created for the duration of a read
+	if r.gzipPosition != nil {
+		attributes = withAttribute(attributes, attrs.LogFileGzipCompressedOffset, r.compressedOffset())
+	}
+	if r.includeScanTime {
+		attributes = withAttribute(attributes, attrs.LogFileScanTimeUnixNano, r.scanTime.UnixNano())
+	}
+	if r.includePollCycle {
+		attributes = withAttribute(attributes, attrs.LogFilePollCycle, r.pollCycle)
+	}
+	// The raw bytes before the token are only available from the scanner which read the token,
+	// so the context of the first token read on each poll is empty and is omitted.
+	if len(r.contextBefore) > 0 {
+		attributes = withAttribute(attributes, attrs.LogFileContextBefore, bytes.Clone(r.contextBefore))
+	}
+	if r.severityExtractor != nil {
+		attributes = withAttribute(attributes, attrs.LogRecordSeverityNumber, r.severityExtractor.Extract(token))
+	}
+	if r.checksumVerifier != nil {
+		if valid, ok := r.checksumVerifier.Verify(token); ok {
+			attributes = withAttribute(attributes, attrs.LogChecksumValid, valid)
+		}
+	}
+	if r.uuidNamespace != nil {
+		attributes = withAttribute(attributes, attrs.LogFileUUID, uuid.NewSHA1(*r.uuidNamespace, r.recordID(offset, piece)).String())
+	}
+	if r.timestampNormalizer != nil {
+		if ts, ok := r.normalizeTimestamp(token); ok {
+			attributes = withAttribute(attributes, attrs.LogFileTimestamp, ts)
+		}
+	}
+	if r.timestampParser != nil {
+		if ts, ok := r.timestampParser(token); ok {
+			if !r.LastTimestamp.IsZero() {
+				attributes = withAttribute(attributes, attrs.LogFileDeltaNs, ts.Sub(r.LastTimestamp).Nanoseconds())
+			}
+			r.LastTimestamp = ts
+		}
+	}
+	return attributes
+}
+
+// withAttribute sets an attribute, allocating the map on first use.
+func withAttribute(attributes map[string]any, k string, v any) map[string]any {
+	if attributes == nil {
+		attributes = make(map[string]any, 1)
+	}
+	attributes[k] = v
+	return attributes
+}
+
+// emitBatch passes a batch of tokens to the emit callback. The emit callback accepts a single set of
+// attributes per call, so consecutive tokens with the same token attributes are emitted together,
+// with their token attributes merged over the file attributes. Static labels are merged under the file
+// attributes, which include any header attributes, unless they are configured to override them.
+// Tokens are transformed before they are emitted, after their token attributes are extracted.
+// The offsets hold the start of each token
+// followed by the end of the last one. If readers share a token rate limiter, the batch waits for it first.
+func (r *Reader) emitBatch(ctx context.Context, tokens [][]byte, tokenAttributes []map[string]any, offsets []int64) error {
+	if r.tokenRateLimiter != nil && !r.tokenRateLimiter.wait(ctx, len(tokens)) {
+		return ctx.Err()
+	}
+	r.transformTokens(tokens)
+
+	var groups []emitGroup
+	for start := 0; start < len(tokens); {
+		end := start + 1
+		// Each token has its own host sequence number, so it is emitted in its own call
+		for end < len(tokens) && r.hostSequence == nil && equalAttributes(tokenAttributes[start], tokenAttributes[end]) &&
+			r.batchRecordNum(end, len(tokens)) == r.batchRecordNum(end-1, len(tokens))+1 {
+			end++
+		}
+
+		lastRecordNum := r.batchRecordNum(end-1, len(tokens))
+		// The cumulative bytes count only the tokens which are emitted, while the bytes read include the bytes
+		// of the tokens which were dropped between them
+		r.BytesRead += offsets[end] - offsets[start]
+		for _, token := range tokens[start:end] {
+			r.CumulativeBytes += int64(len(token))
+		}
+
+		// The file attributes are updated as the file is read again, while the emit callback may still hold the
+		// attributes of an earlier batch, so each batch is given its own copy
+		attributes := make(map[string]any, len(r.FileAttributes)+len(r.staticLabels)+len(tokenAttributes[start])+2)
+		if r.staticLabelsOverride {
+			maps.Copy(attributes, r.FileAttributes)
+			maps.Copy(attributes, r.staticLabels)
+		} else {
+			maps.Copy(attributes, r.staticLabels)
+			maps.Copy(attributes, r.FileAttributes)
+		}
+		maps.Copy(attributes, tokenAttributes[start])
+		if r.includeCumulativeCounters {
+			attributes[attrs.LogFileCumulativeRecords] = lastRecordNum
+			attributes[attrs.LogFileCumulativeBytes] = r.CumulativeBytes
+		}
+		groups = append(groups, emitGroup{tokens: tokens[start:end], attributes: attributes, lastRecordNum: lastRecordNum, offsets: offsets[start:]})
+		start = end
+	}
+	return r.emitGroups(ctx, groups)
+}
+
+// emitGroup is a single call to each emit callback for the tokens of a batch which share their attributes.
+type emitGroup struct {
+	tokens        [][]byte
+	attributes    map[string]any
+	lastRecordNum int64
+	offsets       []int64
+	// next is the index of the first emit callback which has not yet been passed the group
+	next int
+}
+
+// emitGroups calls the emit callbacks for each of the groups in turn. If a call is interrupted, the groups which
+// were not passed to every emit callback are held in flight, and the rest of the groups are not emitted. When
+// the groups in flight are emitted, each is only passed to the emit callbacks which it was not yet passed to.
+func (r *Reader) emitGroups(ctx context.Context, groups []emitGroup) error {
+	var errs error
+	for i := range groups {
+		g := &groups[i]
+		// The allocator is shared by the readers, so the sequence orders the tokens of every file on the host
+		// in the order in which they are emitted
+		if r.hostSequence != nil && g.next == 0 {
+			g.attributes[attrs.LogFileHostSeq] = r.hostSequence()
+		}
+		err := r.emit(ctx, g)
+		if r.emitInterrupted(ctx, err) {
+			rest := groups[i:]
+			if g.next == r.numEmitFuncs() {
+				rest = groups[i+1:]
+			}
+			r.holdInFlight(rest, i > 0 || g.next > 0)
+			return err
+		}
+		errs = multierr.Append(errs, err)
+	}
+	return errs
+}
+
+// numEmitFuncs returns the number of emit callbacks which each batch is passed to.
+func (r *Reader) numEmitFuncs() int {
+	return 1 + len(r.fanOut)
+}
+
+// emitFuncAt returns the i-th emit callback which each batch is passed to.
+func (r *Reader) emitFuncAt(i int) emit.Callback {
+	if i == 0 {
+		return r.emitFunc
+	}
+	return r.fanOut[i-1]
+}
+
+// emit passes the group to each of the emit callbacks which it was not yet passed to, in order. An error from
+// one of the callbacks is handled as any emit error is, once the group has been passed to the rest of them. If
+// a call is interrupted, the group is not passed to the rest of the callbacks. Once the group has been passed to
+// every callback, this reports how long it took to the batch emitted hook if one is set.
+func (r *Reader) emit(ctx context.Context, g *emitGroup) error {
+	start := time.Now()
+	var errs error
+	for ; g.next < r.numEmitFuncs(); g.next++ {
+		err := r.callEmitFunc(ctx, r.emitFuncAt(g.next), g.tokens, g.attributes, g.lastRecordNum, g.offsets)
+		// A call which did not return is accounted for once it does
+		if r.emitInterrupted(ctx, err) {
+			if errors.Is(err, errEmitTimeout) {
+				// The stuck call has its own copy of the tokens
+				g.next++
+			}
+			return err
+		}
+		errs = multierr.Append(errs, err)
+	}
+	if r.recentTokens != nil {
+		r.recentTokens.add(g.tokens)
+	}
+	if r.onBatchEmitted == nil {
+		return errs
+	}
+	duration := time.Since(start)
+
+	var numBytes int
+	for _, token := range g.tokens {
+		numBytes += len(token)
+	}
+	r.onBatchEmitted(len(g.tokens), numBytes, duration)
+	return errs
+}
+
+func equalAttributes(a, b map[string]any) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
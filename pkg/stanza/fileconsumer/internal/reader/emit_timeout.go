@@ -0,0 +1,204 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/emit"
+)
+
+var (
+	errEmitTimeout  = errors.New("emit timed out")
+	errEmitDeadline = errors.New("emit callback gave up at the emit timeout")
+)
+
+// stuckEmit is a call to the emit callback which did not return before the emit timeout.
+type stuckEmit struct {
+	done   chan struct{}
+	err    error
+	tokens [][]byte
+}
+
+// inFlightEmit holds a batch which was only partly emitted, because the emit callback did not return in time or
+// the context was cancelled. It is kept with the metadata, so that it outlives the reader which read the batch.
+// Until a stuck call returns, the file is not read and nothing more is emitted from it. Once it returns, the rest of
+// the batch is emitted and the offset is advanced past the batch, since the stuck call delivered its tokens, whether
+// or not it failed to.
+type inFlightEmit struct {
+	stuck *stuckEmit
+	// groups are the calls to the emit callbacks for the rest of the batch
+	groups []emitGroup
+	// delivered is set if some of the batch was passed to the emit callback before it was interrupted
+	delivered bool
+	// after is the state of the reader once the whole batch is emitted, if the batch was read from the file
+	after *batchEnd
+}
+
+// batchEnd is the state of a reader after all of the tokens of a batch have been read.
+type batchEnd struct {
+	offset             int64
+	decompressedOffset int64
+	state              batchState
+	dedup              []dedupHash
+	overlap            []uint64
+}
+
+// callEmitFunc calls an emit callback. If an emit timeout is set, the callback is given a context with that
+// deadline, and the reader stops waiting for a callback which ignores it once the deadline has passed. The
+// callback keeps the tokens and attributes after the reader stops waiting for it, so they are copied first.
+func (r *Reader) callEmitFunc(ctx context.Context, emitFunc emit.Callback, tokens [][]byte, attributes map[string]any, lastRecordNum int64, offsets []int64) error {
+	if r.emitTimeout <= 0 {
+		return emitFunc(ctx, tokens, attributes, lastRecordNum, offsets)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.emitTimeout)
+	call := &stuckEmit{done: make(chan struct{}), tokens: make([][]byte, len(tokens))}
+	for i, token := range tokens {
+		call.tokens[i] = bytes.Clone(token)
+	}
+	attributes = maps.Clone(attributes)
+	offsets = slices.Clone(offsets[:len(tokens)+1])
+	go func() {
+		defer close(call.done)
+		defer cancel()
+		call.err = emitFunc(ctx, call.tokens, attributes, lastRecordNum, offsets)
+	}()
+
+	timer := time.NewTimer(r.emitTimeout)
+	defer timer.Stop()
+	select {
+	case <-call.done:
+		// A callback which returns the error of its own context did not deliver the tokens, so they are emitted again
+		if call.err != nil && ctx.Err() != nil && errors.Is(call.err, ctx.Err()) {
+			return fmt.Errorf("%w: %w", errEmitDeadline, call.err)
+		}
+		return call.err
+	case <-timer.C:
+		if r.inFlight == nil {
+			r.inFlight = &inFlightEmit{}
+		}
+		r.inFlight.stuck = call
+		return errEmitTimeout
+	}
+}
+
+// emitInterrupted reports whether emitting failed because the emit callback did not return in time, or because
+// the context of the read or of the call was done before the batch was emitted, in which case reading stops without
+// the offset being advanced past the batch. A context error which the callback returns for any other reason, such
+// as from a context of its own, is handled as any other emit error is.
+func (*Reader) emitInterrupted(ctx context.Context, err error) bool {
+	if errors.Is(err, errEmitTimeout) || errors.Is(err, errEmitDeadline) {
+		return true
+	}
+	return err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err())
+}
+
+// holdInFlight keeps the groups of a batch which were not passed to the emit callback because it was interrupted.
+func (r *Reader) holdInFlight(groups []emitGroup, delivered bool) {
+	if r.inFlight == nil {
+		r.inFlight = &inFlightEmit{}
+	}
+	r.inFlight.groups = cloneGroups(groups)
+	r.inFlight.delivered = delivered
+}
+
+// interruptBatch undoes the effects of reading a batch which was not fully emitted, so that the offset is not
+// advanced past it. Once the in flight part of the batch is emitted, the reader resumes from the end of the batch.
+// If none of the batch was emitted, it is read again instead, unless it was read from a compressed window.
+func (r *Reader) interruptBatch(err error, start batchState, end int64) {
+	if errors.Is(err, errEmitTimeout) {
+		r.set.Logger.Warn("emit callback did not return in time, stopping read until next poll",
+			zap.Duration("emit_timeout", r.emitTimeout), zap.Int64("offset", r.Offset))
+	} else {
+		r.set.Logger.Debug("emit was interrupted, stopping read", zap.Error(err), zap.Int64("offset", r.Offset))
+	}
+	if f := r.inFlight; f != nil {
+		if f.stuck == nil && !f.delivered && !r.readingWindow {
+			r.inFlight = nil
+		} else {
+			f.after = &batchEnd{offset: end, state: r.saveBatchState(), dedup: slices.Clone(r.dedupPending[start.dedupPending:])}
+			if r.rotationOverlap != nil {
+				f.after.overlap = slices.Clone(r.rotationOverlap.pending[start.overlapPending:])
+			}
+		}
+	}
+	r.restoreBatchState(start)
+}
+
+// settleInFlight emits the rest of a batch which is in flight once the stuck call to the emit callback returns,
+// and moves the reader to the end of the batch. It returns false if the file should not be read yet.
+func (r *Reader) settleInFlight(ctx context.Context) bool {
+	f := r.inFlight
+	if f == nil {
+		return true
+	}
+	if f.stuck != nil {
+		select {
+		case <-f.stuck.done:
+		default:
+			r.set.Logger.Debug("emit callback has not returned, skipping read")
+			return false
+		}
+		if f.stuck.err != nil {
+			r.set.Logger.Error("failed to emit token", zap.Error(f.stuck.err))
+		}
+		// A group which the stuck call was passed is recorded once it is passed to the rest of the emit callbacks
+		if r.recentTokens != nil && (len(f.groups) == 0 || f.groups[0].next == 0) {
+			r.recentTokens.add(f.stuck.tokens)
+		}
+		f.stuck = nil
+	}
+
+	r.inFlight = nil
+	if err := r.emitGroups(ctx, f.groups); r.emitInterrupted(ctx, err) {
+		r.inFlight.delivered = r.inFlight.delivered || f.delivered
+		r.inFlight.after = f.after
+		return false
+	} else if err != nil {
+		r.set.Logger.Error("failed to emit token", zap.Error(err))
+	}
+	if f.after != nil {
+		f.after.state.dedupPending = len(r.dedupPending)
+		if r.rotationOverlap != nil {
+			f.after.state.overlapPending = len(r.rotationOverlap.pending)
+		}
+		r.restoreBatchState(f.after.state)
+		r.recordSession()
+		r.dedupPending = append(r.dedupPending, f.after.dedup...)
+		r.recordDuplicates()
+		if r.rotationOverlap != nil {
+			r.rotationOverlap.pending = append(r.rotationOverlap.pending, f.after.overlap...)
+			r.recordRotationOverlap()
+		}
+		r.Offset, r.DecompressedOffset = f.after.offset, f.after.decompressedOffset
+	}
+	return true
+}
+
+func cloneGroups(groups []emitGroup) []emitGroup {
+	cloned := make([]emitGroup, len(groups))
+	for i, g := range groups {
+		tokens := make([][]byte, len(g.tokens))
+		for j, token := range g.tokens {
+			tokens[j] = bytes.Clone(token)
+		}
+		cloned[i] = emitGroup{
+			tokens:        tokens,
+			attributes:    maps.Clone(g.attributes),
+			lastRecordNum: g.lastRecordNum,
+			offsets:       slices.Clone(g.offsets[:len(g.tokens)+1]),
+			next:          g.next,
+		}
+	}
+	return cloned
+}
@@ -0,0 +1,144 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestEmitTimeout(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	content := "testlog1\ntestlog2\ntestlog3\n"
+	filetest.WriteString(t, temp, content)
+
+	unblock := make(chan struct{})
+	emitted := make(chan string, 10)
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		for _, token := range tokens {
+			emitted <- string(token)
+		}
+		// The callback ignores the deadline of its context when emitting the second token
+		if string(tokens[0]) == "testlog2" {
+			<-unblock
+		}
+		return nil
+	})
+	f.EmitTimeout = 50 * time.Millisecond
+	// Each token has its own attributes, so it is emitted in its own call
+	f.IncludeByteRange = true
+	core, logs := observer.New(zap.WarnLevel)
+	f.TelemetrySettings.Logger = zap.New(core)
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.ReadToEnd(context.Background())
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "read did not return after the emit timeout")
+	}
+	assert.Equal(t, "testlog1", <-emitted)
+	assert.Equal(t, "testlog2", <-emitted)
+	assert.Equal(t, 1, logs.FilterMessageSnippet("emit callback did not return in time").Len())
+	assert.Zero(t, r.Offset)
+	assert.Zero(t, r.RecordNum)
+
+	// Nothing is emitted while the callback is still blocked, including by the readers of later polls
+	for range 3 {
+		r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+		require.NoError(t, err)
+		r.ReadToEnd(context.Background())
+		assert.Empty(t, emitted)
+		assert.Zero(t, r.Offset)
+	}
+
+	// Once it returns, only the rest of the batch is emitted
+	close(unblock)
+	require.Eventually(t, func() bool {
+		r.ReadToEnd(context.Background())
+		return r.Offset == int64(len(content))
+	}, 5*time.Second, 10*time.Millisecond)
+	defer r.Close()
+	assert.Equal(t, "testlog3", <-emitted)
+	assert.Empty(t, emitted)
+	assert.Equal(t, int64(3), r.RecordNum)
+	assert.Nil(t, r.inFlight)
+}
+
+func TestEmitTimeoutFirstCall(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	content := "testlog1\ntestlog2\n"
+	filetest.WriteString(t, temp, content)
+
+	unblock := make(chan struct{})
+	emitted := make(chan [][]byte, 10)
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		emitted <- tokens
+		<-unblock
+		return nil
+	})
+	f.EmitTimeout = 50 * time.Millisecond
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, [][]byte{[]byte("testlog1"), []byte("testlog2")}, <-emitted)
+	assert.Zero(t, r.Offset)
+
+	// The stuck call delivered the batch, so it is not emitted again
+	close(unblock)
+	require.Eventually(t, func() bool {
+		r.ReadToEnd(context.Background())
+		return r.Offset == int64(len(content))
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, emitted)
+	assert.Equal(t, int64(2), r.RecordNum)
+}
+
+func TestEmitContextErrorNotInterrupted(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	content := "testlog1\ntestlog2\n"
+	filetest.WriteString(t, temp, content)
+
+	var calls int
+	f := newTestFactory(t, func(_ context.Context, _ [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		calls++
+		// The error comes from a context of the callback, not of the read
+		return fmt.Errorf("failed to send: %w", context.DeadlineExceeded)
+	})
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The error is handled as any other emit error is, so the batch is not read again
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, int64(len(content)), r.Offset)
+	assert.Nil(t, r.inFlight)
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, 1, calls)
+}
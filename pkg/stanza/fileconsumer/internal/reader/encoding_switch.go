@@ -0,0 +1,59 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bufio"
+	"bytes"
+
+	"go.uber.org/zap"
+	"golang.org/x/text/encoding"
+)
+
+// EncodingSwitch configures a change of encoding part way through a file.
+type EncodingSwitch struct {
+	// Marker is the token, before it is decoded, after which the rest of the file is in the new encoding.
+	// The marker is not emitted.
+	Marker []byte
+	// Encoding is the encoding of the rest of the file.
+	Encoding encoding.Encoding
+	// SplitFunc splits the rest of the file, and must be suitable for the new encoding.
+	SplitFunc bufio.SplitFunc
+}
+
+// isEncodingSwitch returns true if the token, before it is decoded, is the marker of an encoding switch
+// which has not happened yet.
+func (r *Reader) isEncodingSwitch(token []byte) bool {
+	if r.encodingSwitch == nil || r.EncodingSwitched {
+		return false
+	}
+	// The rest of the file can only be read with the new split func if we are able to seek back to it
+	if !r.readingFile() {
+		return false
+	}
+	return bytes.Equal(token, r.encodingSwitch.Marker)
+}
+
+// switchEncoding rebinds the decoder and split func for the rest of the file, which is read from the current offset.
+// It returns true if reading should continue.
+func (r *Reader) switchEncoding() bool {
+	r.EncodingSwitched = true
+	r.SwitchBOMPending = true
+	r.bindEncoding(r.encodingSwitch.Encoding, r.encodingSwitch.SplitFunc)
+	if err := r.seekToOffset(); err != nil {
+		r.set.Logger.Error("failed to seek after encoding switch", zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// stripSwitchBOM removes a byte order mark from the start of the first token decoded after an encoding switch,
+// since the new segment of the file may begin with its own BOM.
+func (r *Reader) stripSwitchBOM(decoded []byte) []byte {
+	if !r.SwitchBOMPending {
+		return decoded
+	}
+	r.SwitchBOMPending = false
+	return bytes.TrimPrefix(decoded, utf8BOM)
+}
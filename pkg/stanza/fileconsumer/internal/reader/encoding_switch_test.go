@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/split"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/trim"
+)
+
+func TestEncodingSwitch(t *testing.T) {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	encodeUTF16 := func(s string) string {
+		encoded, err := utf16.NewEncoder().String(s)
+		require.NoError(t, err)
+		return encoded
+	}
+	utf16SplitFunc, err := split.NewlineSplitFunc(utf16, false)
+	require.NoError(t, err)
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	before := "first ü\nsecond\n"
+	marker := "SWITCH TO UTF-16\n"
+	after := encodeUTF16("third ü\nfourth\n")
+	filetest.WriteString(t, temp, before+marker+after)
+
+	f, sink := testFactory(t)
+	f.TrimFunc = trim.Nop
+	f.EncodingSwitch = &EncodingSwitch{
+		Marker:    []byte("SWITCH TO UTF-16"),
+		Encoding:  utf16,
+		SplitFunc: utf16SplitFunc,
+	}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("first ü"), []byte("second"), []byte("third ü"), []byte("fourth"))
+	sink.ExpectNoCalls(t)
+	assert.True(t, r.EncodingSwitched)
+	assert.Equal(t, int64(len(before+marker+after)), r.Offset)
+
+	// The new encoding is used for the rest of the file after the reader is recreated
+	more := encodeUTF16("fifth\nSWITCH TO UTF-16\n")
+	filetest.WriteString(t, temp, more)
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("fifth"), []byte("SWITCH TO UTF-16"))
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, int64(len(before+marker+after+more)), r.Offset)
+}
+
+func TestEncodingSwitchAcrossPolls(t *testing.T) {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	utf16SplitFunc, err := split.NewlineSplitFunc(utf16, false)
+	require.NoError(t, err)
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "first\nSWITCH\n")
+
+	f, sink := testFactory(t)
+	f.TrimFunc = trim.Nop
+	f.EncodingSwitch = &EncodingSwitch{Marker: []byte("SWITCH"), Encoding: utf16, SplitFunc: utf16SplitFunc}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("first"))
+	sink.ExpectNoCalls(t)
+
+	encoded, err := utf16.NewEncoder().String("second\n")
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, encoded)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("second"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestEncodingSwitchStripsBOM(t *testing.T) {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	utf16SplitFunc, err := split.NewlineSplitFunc(utf16, false)
+	require.NoError(t, err)
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	after, err := utf16.NewEncoder().String("\ufeffthird\n\ufefffourth\n")
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "first\nSWITCH\n"+after)
+
+	f, sink := testFactory(t)
+	f.TrimFunc = trim.Nop
+	f.EncodingSwitch = &EncodingSwitch{Marker: []byte("SWITCH"), Encoding: utf16, SplitFunc: utf16SplitFunc}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	// Only the BOM at the start of the new segment is removed
+	sink.ExpectTokens(t, []byte("first"), []byte("third"), []byte("\ufefffourth"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestEncodingSwitchStripsBOMAcrossPolls(t *testing.T) {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	utf16SplitFunc, err := split.NewlineSplitFunc(utf16, false)
+	require.NoError(t, err)
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "first\nSWITCH\n")
+
+	f, sink := testFactory(t)
+	f.TrimFunc = trim.Nop
+	f.EncodingSwitch = &EncodingSwitch{Marker: []byte("SWITCH"), Encoding: utf16, SplitFunc: utf16SplitFunc}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("first"))
+	sink.ExpectNoCalls(t)
+
+	// The segment is written after the reader is restored from a checkpoint
+	encoded, err := utf16.NewEncoder().String("\ufeffsecond\n")
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, encoded)
+	checkpoint, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(checkpoint, m))
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("second"))
+	sink.ExpectNoCalls(t)
+}
@@ -0,0 +1,37 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+)
+
+const defaultErrorTokenInterval = time.Minute
+
+// emitErrorToken emits an empty token flagged with a scan error and the offset at which it occurred, so that
+// read failures can be observed downstream. A persistent error is hit again on every poll, so at most one
+// error token is emitted per interval for each file.
+func (r *Reader) emitErrorToken(ctx context.Context, scanErr error) {
+	if !r.emitScanErrors {
+		return
+	}
+	if !r.lastErrorToken.IsZero() && time.Since(r.lastErrorToken) < r.errorTokenInterval {
+		return
+	}
+	r.lastErrorToken = time.Now()
+
+	attributes := make(map[string]any, len(r.FileAttributes)+2)
+	maps.Copy(attributes, r.FileAttributes)
+	attributes[attrs.LogFileError] = scanErr.Error()
+	attributes[attrs.LogFileRecordOffset] = r.Offset
+	if err := r.emit(ctx, &emitGroup{tokens: [][]byte{{}}, attributes: attributes, lastRecordNum: r.RecordNum, offsets: []int64{r.Offset, r.Offset}}); err != nil {
+		r.set.Logger.Error("failed to emit error token", zap.Error(err))
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestEmitScanErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "good\nbad\nunreachable\n")
+
+	f, sink := testFactory(t)
+	f.SplitFunc = func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := bufio.ScanLines(data, atEOF)
+		if bytes.Equal(token, []byte("bad")) {
+			return 0, nil, errors.New("simulated scan failure")
+		}
+		return advance, token, err
+	}
+	f.EmitScanErrors = true
+	f.ErrorTokenInterval = time.Hour
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	fileName := r.FileAttributes[attrs.LogFileName]
+	errorAttrs := map[string]any{
+		attrs.LogFileName:         fileName,
+		attrs.LogFileError:        "scanner error: simulated scan failure",
+		attrs.LogFileRecordOffset: int64(len("good\n")),
+	}
+	sink.ExpectCall(t, []byte("good"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectCall(t, []byte{}, errorAttrs)
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(len("good\n")), r.Offset)
+
+	// The error persists, but is not emitted again until the interval has passed
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+
+	r.lastErrorToken = time.Now().Add(-2 * time.Hour)
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte{}, errorAttrs)
+	sink.ExpectNoCalls(t)
+	r.Close()
+}
+
+func TestScanErrorsNotEmitted(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "bad\n")
+
+	f, sink := testFactory(t)
+	f.SplitFunc = func([]byte, bool) (int, []byte, error) {
+		return 0, nil, errors.New("simulated scan failure")
+	}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+}
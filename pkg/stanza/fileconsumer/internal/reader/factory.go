@@ -9,9 +9,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"go.opentelemetry.io/collector/component"
 	"go.uber.org/zap"
 	"golang.org/x/text/encoding"
@@ -20,6 +22,8 @@ import (
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/emit"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/header"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/scanner"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/flush"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/tokenlen"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/trim"
@@ -33,38 +37,155 @@ const (
 
 type Factory struct {
 	component.TelemetrySettings
-	HeaderConfig            *header.Config
-	FromBeginning           bool
-	FingerprintSize         int
-	BufPool                 sync.Pool
-	InitialBufferSize       int
-	MaxLogSize              int
-	Encoding                encoding.Encoding
-	SplitFunc               bufio.SplitFunc
-	TrimFunc                trim.Func
-	FlushTimeout            time.Duration
-	EmitFunc                emit.Callback
-	Attributes              attrs.Resolver
-	DeleteAtEOF             bool
-	IncludeFileRecordNumber bool
-	IncludeFileRecordOffset bool
-	Compression             string
-	AcquireFSLock           bool
+	HeaderConfig                   *header.Config
+	HeaderResourceAttributes       map[string]string
+	HeaderEncodingAttribute        string
+	HeaderEncodingSplitFunc        func(encoding.Encoding) (bufio.SplitFunc, error)
+	RepeatedHeaderStart            *regexp.Regexp
+	EmitHeader                     bool
+	FromBeginning                  bool
+	StartAtEndLookback             time.Duration
+	ResumeAtEndOnRestart           bool
+	ResumeByContent                bool
+	MaxResumeSearchSize            int
+	ResumeLineTerminator           []byte
+	FingerprintSize                int
+	MaxFingerprintSize             int
+	FingerprintAlgorithm           string
+	BufPool                        sync.Pool
+	ZeroPooledBuffers              bool
+	InitialBufferSize              int
+	ReadChunkSize                  int
+	MaxLogSize                     int
+	BufferGrowth                   scanner.Growth
+	PartialChunkSize               int
+	Encoding                       encoding.Encoding
+	EncodingSwitch                 *EncodingSwitch
+	DecodeFallback                 string
+	DecodeErrorAction              string
+	LineGzip                       bool
+	SplitFunc                      bufio.SplitFunc
+	TrimFunc                       trim.Func
+	TrailingDelimiter              []byte
+	TrimLineEnding                 bool
+	NormalizeNewlines              bool
+	StripRecordBOM                 bool
+	FlushTimeout                   time.Duration
+	EmitFunc                       emit.Callback
+	FanOut                         []emit.Callback
+	OnBatchEmitted                 BatchEmittedFunc
+	DiskPressure                   DiskPressureFunc
+	TokenBatchBuffer               int
+	MaxBatchMemory                 int
+	RecentTokensSize               int
+	RotationOverlapSize            int
+	SampleRate                     float64
+	CoalesceRepeats                bool
+	MaxRepeatCount                 int64
+	RepeatFlushTimeout             time.Duration
+	DedupCapacity                  int
+	DedupFalsePositiveRate         float64
+	TimestampParser                func([]byte) (time.Time, bool)
+	TokenTransform                 TokenTransform
+	TransformWorkers               int
+	Attributes                     attrs.Resolver
+	StaticLabels                   map[string]any
+	StaticLabelsOverride           bool
+	DeleteAtEOF                    bool
+	InPlaceEditPolicy              string
+	EmitTruncationEvents           bool
+	MaxStalledScans                int
+	DeleteRetries                  int
+	DeleteArchiveDir               string
+	DeleteRetryBackoff             time.Duration
+	IncludeFileRecordNumber        bool
+	IncludeFileFirstRecord         bool
+	IncludeFileTruncated           bool
+	IncludeScanTime                bool
+	IncludePollCycle               bool
+	ContextBeforeSize              int
+	EmitScanErrors                 bool
+	ErrorTokenInterval             time.Duration
+	IncludeCumulativeCounters      bool
+	IncludeFileRecordOffset        bool
+	Compression                    string
+	SniffCompression               bool
+	IncludeGzipHeader              bool
+	IncludeGzipOffset              bool
+	MixedCompression               bool
+	IncrementalGzip                bool
+	GzipReaderLimiter              *GzipReaderLimiter
+	TokenRateLimiter               *TokenRateLimiter
+	IncludeAutoDetectedCompression bool
+	IncludeSourceCodec             bool
+	MaxDecompressedSize            int64
+	DecompressionPool              *DecompressionPool
+	TelemetryBuilder               *metadata.TelemetryBuilder
+	ContentStartMarker             []byte
+	MaxPreambleSize                int
+	AcquireFSLock                  bool
+	OpenFlags                      int
+	DirectIO                       bool
+	ReadaheadMinSize               int64
+	PrefixCache                    *PrefixCache
+	SymlinkMode                    string
+	MaxFSLockHold                  time.Duration
+	EmitTimeout                    time.Duration
+	BackfillProfile                *ThroughputProfile
+	FollowProfile                  *ThroughputProfile
+	MinBatchSize                   int
+	MinBatchTimeout                time.Duration
+	SeverityExtractor              *SeverityExtractor
+	ChecksumVerifier               *ChecksumVerifier
+	TimestampNormalizer            *TimestampNormalizer
+	Sessionizer                    *Sessionizer
+	FingerprintMatch               FingerprintMatchFunc
+	MultipartGzip                  bool
+	UUIDNamespace                  *uuid.UUID
+	HostSequence                   func() uint64
+	IncludeFileID                  bool
+	FileIDIncludeInode             bool
+	IncludeFileIdentity            bool
+	FileIdentityIncludeInode       bool
+	IncludeByteRange               bool
+	DecompressFingerprint          bool
+	MaxDecodedSize                 int
+	DecodedSizePolicy              string
+	MaxLineLength                  int
+	LineLengthPolicy               string
+}
+
+// validatePolicies returns an error if a policy is not one of the values which it may take.
+func (f *Factory) validatePolicies() error {
+	if !validSizePolicy(f.DecodedSizePolicy) {
+		return fmt.Errorf("unknown decoded size policy %q", f.DecodedSizePolicy)
+	}
+	if !validSizePolicy(f.LineLengthPolicy) {
+		return fmt.Errorf("unknown line length policy %q", f.LineLengthPolicy)
+	}
+	return nil
 }
 
 func (f *Factory) NewFingerprint(file *os.File) (*fingerprint.Fingerprint, error) {
-	return fingerprint.NewFromFile(file, f.FingerprintSize, f.Compression != "")
+	return f.NewFingerprintWithSize(file, f.FingerprintSize)
+}
+
+// NewFingerprintWithSize computes the fingerprint of a file over the given number of bytes, rather than
+// the configured fingerprint size.
+func (f *Factory) NewFingerprintWithSize(file *os.File, size int) (*fingerprint.Fingerprint, error) {
+	return newFingerprint(file, size, f.Compression, f.DecompressFingerprint, f.PrefixCache)
 }
 
 func (f *Factory) NewReader(file *os.File, fp *fingerprint.Fingerprint) (*Reader, error) {
-	attributes, err := f.Attributes.Resolve(file)
+	file, symlinkPath, err := f.openSymlinkTarget(file)
 	if err != nil {
 		return nil, err
 	}
-	var filetype string
-	if filepath.Ext(file.Name()) == gzipExtension {
-		filetype = gzipExtension
+	attributes, err := f.Attributes.Resolve(file)
+	if err != nil {
+		return nil, err
 	}
+	filetype := f.fileType(file.Name(), symlinkPath)
 
 	m := &Metadata{
 		Fingerprint:    fp,
@@ -73,53 +194,288 @@ func (f *Factory) NewReader(file *os.File, fp *fingerprint.Fingerprint) (*Reader
 		FlushState: flush.State{
 			LastDataChange: time.Now(),
 		},
-		FileType: filetype,
+		FileType:    filetype,
+		symlinkPath: symlinkPath,
+	}
+	if fp.Len() > f.FingerprintSize {
+		// The fingerprint was grown to tell the file apart from another one
+		m.FingerprintSize = fp.Len()
 	}
 	return f.NewReaderFromMetadata(file, m)
 }
 
 func (f *Factory) NewReaderFromMetadata(file *os.File, m *Metadata) (r *Reader, err error) {
+	if err = f.validatePolicies(); err != nil {
+		// Nothing is returned for the caller to close on an error
+		_ = file.Close()
+		return nil, err
+	}
+	file, symlinkPath, err := f.openSymlinkTarget(file)
+	if err != nil {
+		return nil, err
+	}
+	if symlinkPath != "" {
+		m.symlinkPath = symlinkPath
+	}
+
 	r = &Reader{
-		Metadata:          m,
-		set:               f.TelemetrySettings,
-		file:              file,
-		fileName:          file.Name(),
-		fingerprintSize:   f.FingerprintSize,
-		bufPool:           &f.BufPool,
-		initialBufferSize: f.InitialBufferSize,
-		maxLogSize:        f.MaxLogSize,
-		decoder:           f.Encoding.NewDecoder(),
-		deleteAtEOF:       f.DeleteAtEOF,
-		compression:       f.Compression,
-		acquireFSLock:     f.AcquireFSLock,
-		maxBatchSize:      DefaultMaxBatchSize,
-		emitFunc:          f.EmitFunc,
+		Metadata:                   m,
+		set:                        f.TelemetrySettings,
+		file:                       file,
+		fileName:                   file.Name(),
+		fingerprintSize:            f.FingerprintSize,
+		bufPool:                    &f.BufPool,
+		zeroPooledBuffers:          f.ZeroPooledBuffers,
+		initialBufferSize:          f.InitialBufferSize,
+		maxLogSize:                 f.MaxLogSize,
+		bufferGrowth:               f.BufferGrowth,
+		deleteAtEOF:                f.DeleteAtEOF,
+		inPlaceEditPolicy:          f.InPlaceEditPolicy,
+		emitTruncation:             f.EmitTruncationEvents,
+		maxStalledScans:            f.MaxStalledScans,
+		hashFingerprint:            f.FingerprintAlgorithm == fingerprint.AlgorithmXXHash,
+		deleteRetries:              f.DeleteRetries,
+		deleteArchiveDir:           f.DeleteArchiveDir,
+		deleteRetryBackoff:         f.DeleteRetryBackoff,
+		compression:                f.Compression,
+		sniffCompression:           f.SniffCompression,
+		includeGzipHeader:          f.IncludeGzipHeader,
+		includeGzipOffset:          f.IncludeGzipOffset,
+		mixedCompression:           f.MixedCompression,
+		incrementalGzip:            f.IncrementalGzip,
+		gzipReaderLimiter:          f.GzipReaderLimiter,
+		tokenRateLimiter:           f.TokenRateLimiter,
+		prefixCache:                f.PrefixCache,
+		includeDetectedCompression: f.IncludeAutoDetectedCompression,
+		includeSourceCodec:         f.IncludeSourceCodec,
+		maxDecompressedSize:        f.MaxDecompressedSize,
+		decompressionPool:          f.DecompressionPool,
+		telemetryBuilder:           f.TelemetryBuilder,
+		contentStartMarker:         f.ContentStartMarker,
+		maxPreambleSize:            f.MaxPreambleSize,
+		acquireFSLock:              f.AcquireFSLock,
+		directIO:                   f.DirectIO,
+		readaheadMinSize:           f.ReadaheadMinSize,
+		maxFSLockHold:              f.MaxFSLockHold,
+		emitTimeout:                f.EmitTimeout,
+		checksumVerifier:           f.ChecksumVerifier,
+		timestampNormalizer:        f.TimestampNormalizer,
+		sessionizer:                f.Sessionizer,
+		fingerprintMatch:           f.FingerprintMatch,
+		severityExtractor:          f.SeverityExtractor,
+		uuidNamespace:              f.UUIDNamespace,
+		hostSequence:               f.HostSequence,
+		includeFileID:              f.IncludeFileID,
+		fileIDIncludeInode:         f.FileIDIncludeInode,
+		includeFileIdentity:        f.IncludeFileIdentity,
+		fileIdentityInode:          f.FileIdentityIncludeInode,
+		includeByteRange:           f.IncludeByteRange,
+		identitySize:               f.FingerprintSize,
+		timestampParser:            f.TimestampParser,
+		tokenTransform:             f.TokenTransform,
+		transformWorkers:           f.TransformWorkers,
+		includeFirstRecord:         f.IncludeFileFirstRecord,
+		includeScanTime:            f.IncludeScanTime,
+		includePollCycle:           f.IncludePollCycle,
+		contextBeforeSize:          f.ContextBeforeSize,
+		emitScanErrors:             f.EmitScanErrors,
+		decodeFallback:             f.DecodeFallback,
+		decodeErrorAction:          f.DecodeErrorAction,
+		coalesceRepeats:            f.CoalesceRepeats,
+		maxRepeatCount:             f.MaxRepeatCount,
+		repeatFlushTimeout:         f.RepeatFlushTimeout,
+		errorTokenInterval:         f.ErrorTokenInterval,
+		includeCumulativeCounters:  f.IncludeCumulativeCounters,
+		decompressFP:               f.DecompressFingerprint,
+		maxDecodedSize:             f.MaxDecodedSize,
+		trailingDelimiter:          f.TrailingDelimiter,
+		trimLineEnding:             f.TrimLineEnding,
+		normalizeCRLF:              f.NormalizeNewlines,
+		stripRecordBOM:             f.StripRecordBOM,
+		decodedSizePolicy:          f.DecodedSizePolicy,
+		maxLineLength:              f.MaxLineLength,
+		lineLengthPolicy:           f.LineLengthPolicy,
+		maxBatchSize:               DefaultMaxBatchSize,
+		maxBatchMemory:             f.MaxBatchMemory,
+		sampleRate:                 f.SampleRate,
+		minBatchSize:               f.MinBatchSize,
+		minBatchTimeout:            f.MinBatchTimeout,
+		emitFunc:                   f.EmitFunc,
+		fanOut:                     f.FanOut,
+		onBatchEmitted:             f.OnBatchEmitted,
+		diskPressure:               f.DiskPressure,
+		tokenBatchBuffer:           f.TokenBatchBuffer,
+		staticLabels:               f.StaticLabels,
+		staticLabelsOverride:       f.StaticLabelsOverride,
 	}
 	r.set.Logger = r.set.Logger.With(zap.String("path", r.fileName))
+	if r.errorTokenInterval <= 0 {
+		r.errorTokenInterval = defaultErrorTokenInterval
+	}
+	if r.deleteRetryBackoff <= 0 {
+		r.deleteRetryBackoff = defaultDeleteRetryBackoff
+	}
+	if f.ReadChunkSize > 0 {
+		r.chunks = bufio.NewReaderSize(chunkSource{r}, f.ReadChunkSize)
+	}
+	if r.maxStalledScans <= 0 {
+		r.maxStalledScans = defaultMaxStalledScans
+	}
+
+	if f.MultipartGzip {
+		if matches := gzipPartPattern.FindStringSubmatch(r.fileName); matches != nil {
+			r.partPrefix = matches[1]
+		}
+	}
+	if f.LineGzip {
+		r.lineGzip = new(lineGzip)
+	}
+
+	if m.FingerprintSize > f.FingerprintSize && f.MaxFingerprintSize > f.FingerprintSize {
+		r.fingerprintSize = min(m.FingerprintSize, f.MaxFingerprintSize)
+	}
+	m.FingerprintSize = 0
+	if r.fingerprintSize > f.FingerprintSize {
+		m.FingerprintSize = r.fingerprintSize
+	}
 
 	if r.Fingerprint.Len() > r.fingerprintSize {
 		// User has reconfigured fingerprint_size
-		shorter, rereadErr := fingerprint.NewFromFile(file, r.fingerprintSize, r.compression != "")
+		shorter, rereadErr := r.newFingerprint(file)
 		if rereadErr != nil {
 			return nil, fmt.Errorf("reread fingerprint: %w", rereadErr)
 		}
-		if !r.Fingerprint.StartsWith(shorter) {
+		if r.Fingerprint.IsHashed() {
+			// The hashed bytes are not retained, so they are read again to check the file still starts with them
+			previous, previousErr := newFingerprint(file, r.Fingerprint.Len(), r.compression, r.decompressFP, nil)
+			if previousErr != nil {
+				return nil, fmt.Errorf("reread fingerprint: %w", previousErr)
+			}
+			if !previous.StartsWith(r.Fingerprint) {
+				return nil, errors.New("file truncated")
+			}
+		} else if !r.Fingerprint.StartsWith(shorter) {
 			return nil, errors.New("file truncated")
 		}
 		m.Fingerprint = shorter
 	}
 
-	if !f.FromBeginning {
+	// Offsets within compressed files cannot be realigned by searching for the fingerprint
+	if f.ResumeByContent && f.Compression == "" && m.Offset > 0 {
+		maxSearchSize := f.MaxResumeSearchSize
+		if maxSearchSize <= 0 {
+			maxSearchSize = defaultMaxResumeSearchSize
+		}
+		if err = r.resumeByContent(maxSearchSize); err != nil {
+			return nil, fmt.Errorf("resume by content: %w", err)
+		}
+	}
+	// An offset which was persisted after a crash may be in the middle of a line
+	if f.ResumeLineTerminator != nil && f.Compression == "" && m.restored && m.Offset > 0 {
+		maxSearchSize := f.MaxResumeSearchSize
+		if maxSearchSize <= 0 {
+			maxSearchSize = defaultMaxResumeSearchSize
+		}
+		if err = r.alignResumeOffset(f.ResumeLineTerminator, maxSearchSize); err != nil {
+			return nil, fmt.Errorf("align resume offset: %w", err)
+		}
+	}
+
+	if r.hashFingerprint {
+		m.Fingerprint = m.Fingerprint.Hashed()
+	}
+
+	if f.RecentTokensSize > 0 && m.recentTokens == nil {
+		m.recentTokens = newTokenRing(f.RecentTokensSize)
+	}
+	if f.RotationOverlapSize > 0 && m.rotationOverlap == nil {
+		m.rotationOverlap = newOverlapRing(f.RotationOverlapSize)
+	}
+	switch {
+	case f.DedupCapacity <= 0:
+		m.Dedup = nil
+	case m.Dedup == nil || !m.Dedup.matches(f.DedupCapacity, f.DedupFalsePositiveRate):
+		m.Dedup = newDedupFilter(f.DedupCapacity, f.DedupFalsePositiveRate)
+	}
+	if !f.CoalesceRepeats && m.RepeatRun != nil {
+		// The tokens of the run were never emitted, so they are read again
+		m.Offset, m.RepeatRun = m.RepeatRun.Offset, nil
+	}
+
+	// Skip any content which was written while the collector was not running
+	resumeAtEnd := f.ResumeAtEndOnRestart && m.restored
+	m.restored = false
+
+	if !f.FromBeginning || resumeAtEnd {
 		var info os.FileInfo
 		if info, err = r.file.Stat(); err != nil {
 			return nil, fmt.Errorf("stat: %w", err)
 		}
 		r.Offset = info.Size()
+		// Recent lines are replayed when starting at the end, if their timestamps can be parsed
+		if !f.FromBeginning && !resumeAtEnd && f.StartAtEndLookback > 0 && f.TimestampParser != nil && f.Compression == "" {
+			r.Offset = r.lookbackOffset(r.Offset, time.Now().Add(-f.StartAtEndLookback))
+		}
+		if resumeAtEnd {
+			// Any partial token which was pending at shutdown is skipped along with the rest of the content
+			m.TokenLenState = tokenlen.State{}
+			m.FlushState = flush.State{LastDataChange: time.Now()}
+		}
+	}
+
+	// The split funcs are bound to the encoding, which may change part way through the file
+	r.bindEncoding = func(enc encoding.Encoding, splitFunc bufio.SplitFunc) {
+		r.decoder = enc.NewDecoder()
+		tokenLenFunc := m.TokenLenState.Func(splitFunc)
+		newContentSplitFunc := func(flushTimeout time.Duration) bufio.SplitFunc {
+			flushFunc := m.FlushState.Func(tokenLenFunc, flushTimeout)
+			if f.PartialChunkSize > 0 {
+				flushFunc = r.chunkPartialTokens(flushFunc, f.PartialChunkSize)
+			}
+			if f.IncludeFileTruncated && f.MaxLogSize > 0 {
+				flushFunc = r.flagTruncatedTokens(flushFunc, f.MaxLogSize)
+			}
+			contentSplitFunc := trim.WithFunc(trim.ToLength(flushFunc, f.MaxLogSize), f.TrimFunc)
+			if f.ResumeLineTerminator != nil {
+				contentSplitFunc = r.trackMidLine(contentSplitFunc, f.ResumeLineTerminator)
+			}
+			return contentSplitFunc
+		}
+		r.contentSplitFunc = newContentSplitFunc(f.FlushTimeout)
+
+		if f.BackfillProfile != nil && !m.CaughtUp {
+			r.maxBatchSize, r.contentSplitFunc = f.BackfillProfile.resolve(f.FlushTimeout, newContentSplitFunc)
+		}
+		if f.FollowProfile != nil {
+			r.follow = new(readPhase)
+			r.follow.maxBatchSize, r.follow.splitFunc = f.FollowProfile.resolve(f.FlushTimeout, newContentSplitFunc)
+			if m.CaughtUp {
+				r.maxBatchSize, r.contentSplitFunc = r.follow.maxBatchSize, r.follow.splitFunc
+			}
+		}
+	}
+	r.encodingSwitch = f.EncodingSwitch
+	r.headerEncodingAttribute = f.HeaderEncodingAttribute
+	r.headerEncodingSplitFunc = f.HeaderEncodingSplitFunc
+	if r.headerEncodingSplitFunc == nil {
+		r.headerEncodingSplitFunc = func(encoding.Encoding) (bufio.SplitFunc, error) { return f.SplitFunc, nil }
+	}
+	switch {
+	case r.encodingSwitch != nil && m.EncodingSwitched:
+		r.bindEncoding(r.encodingSwitch.Encoding, r.encodingSwitch.SplitFunc)
+	case r.headerEncodingAttribute != "" && m.HeaderEncoding != "" && r.bindHeaderEncoding(m.HeaderEncoding) == nil:
+		// The rest of the file is in the encoding which was declared by its header
+	default:
+		r.bindEncoding(f.Encoding, f.SplitFunc)
 	}
 
-	tokenLenFunc := m.TokenLenState.Func(f.SplitFunc)
-	flushFunc := m.FlushState.Func(tokenLenFunc, f.FlushTimeout)
-	r.contentSplitFunc = trim.WithFunc(trim.ToLength(flushFunc, f.MaxLogSize), f.TrimFunc)
+	r.headerConfig = f.HeaderConfig
+	r.headerResourceAttributes = f.HeaderResourceAttributes
+	r.emitHeader = f.EmitHeader
+	r.lastHeaderRearm = -1
+	if f.HeaderConfig != nil {
+		r.repeatedHeaderStart = f.RepeatedHeaderStart
+	}
 
 	if f.HeaderConfig != nil && !m.HeaderFinalized {
 		r.headerSplitFunc = f.HeaderConfig.SplitFunc
@@ -137,6 +493,13 @@ func (f *Factory) NewReaderFromMetadata(file *os.File, m *Metadata) (r *Reader,
 	for k, v := range attributes {
 		r.FileAttributes[k] = v
 	}
+	if f.SymlinkMode == SymlinkModeLinkName && m.symlinkPath != "" {
+		r.FileAttributes[attrs.LogFileSymlinkName] = filepath.Base(m.symlinkPath)
+	}
+	r.assignFileID()
+	r.assignFileIdentity()
+
+	r.publishSnapshot()
 
 	return r, nil
 }
@@ -4,6 +4,8 @@
 package reader
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -156,3 +158,54 @@ func TestStartAt(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(len(content)), r.Offset)
 }
+
+func TestResumeAtEndOnRestart(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "before shutdown\n")
+
+	f, sink := testFactory(t)
+	f.ResumeAtEndOnRestart = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("before shutdown"))
+
+	// Simulate persisting the metadata and shutting down
+	encoded, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "while stopped\n")
+
+	// Metadata which is reused while running is not affected
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("before shutdown\n")), r.Offset)
+	r.Close()
+
+	// Metadata which is restored at startup resumes at the end of the file
+	m = new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	m.MarkRestored()
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Equal(t, int64(len("before shutdown\nwhile stopped\n")), r.Offset)
+
+	filetest.WriteString(t, temp, "after restart\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("after restart"))
+	sink.ExpectNoCalls(t)
+
+	// The reader otherwise tails the file normally
+	filetest.WriteString(t, temp, "still tailing\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("still tailing"))
+	sink.ExpectNoCalls(t)
+}
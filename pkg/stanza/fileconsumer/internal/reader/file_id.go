@@ -0,0 +1,36 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"encoding/binary"
+
+	"github.com/google/uuid"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+)
+
+// fileIDNamespace is the namespace of the UUIDs which identify files.
+var fileIDNamespace = uuid.NewSHA1(uuid.NameSpaceOID, []byte(attrs.LogFileID))
+
+// assignFileID derives a UUID for the file from the same content of its fingerprint as its identity, and
+// optionally its inode, and attaches it to every record. The id is derived again as the file grows until the
+// fingerprint is full, after which it only changes when the file is found to hold new content.
+func (r *Reader) assignFileID() {
+	if !r.includeFileID {
+		return
+	}
+	if src, ok := r.identitySource(r.FileIDSize, r.FileID != "", r.fileIDIncludeInode); ok {
+		name := append([]byte{}, src.prefix...)
+		if src.hasKey {
+			name = binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(name, src.key.dev), src.key.ino)
+		}
+		r.FileID, r.FileIDSize = uuid.NewSHA1(fileIDNamespace, name).String(), len(src.prefix)
+	}
+	if r.FileID == "" {
+		delete(r.FileAttributes, attrs.LogFileID)
+		return
+	}
+	r.FileAttributes[attrs.LogFileID] = r.FileID
+}
@@ -0,0 +1,139 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestFileID(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	// The first line fills the fingerprint which the id is derived from
+	first := strings.Repeat("a", identityTestSize)
+	filetest.WriteString(t, temp, first+"\n")
+
+	f, sink := testFactory(t, withFingerprintSize(identityTestSize))
+	f.IncludeFileID = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	token, attributes := sink.NextCall(t)
+	assert.Equal(t, []byte(first), token)
+	id, ok := attributes[attrs.LogFileID].(string)
+	require.True(t, ok)
+	_, err = uuid.Parse(id)
+	require.NoError(t, err)
+
+	// The id is kept as the file grows over later polls
+	filetest.WriteString(t, temp, "testlog2\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("testlog2"), map[string]any{
+		attrs.LogFileName: r.FileAttributes[attrs.LogFileName],
+		attrs.LogFileID:   id,
+	})
+	assert.Equal(t, identityTestSize, r.Fingerprint.Len())
+	r.Close()
+
+	// The id does not depend on how much of the file had been written when it was first read
+	fp, err = f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err = f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	assert.Equal(t, id, r.FileID)
+	r.Close()
+
+	// A file with different content has a different id
+	other := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, other, "otherlog1\n")
+	fp, err = f.NewFingerprint(other)
+	require.NoError(t, err)
+	r, err = f.NewReader(filetest.OpenFile(t, other.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	_, attributes = sink.NextCall(t)
+	assert.NotEmpty(t, attributes[attrs.LogFileID])
+	assert.NotEqual(t, id, attributes[attrs.LogFileID])
+}
+
+func TestFileIDEmptyFile(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+
+	f, sink := testFactory(t)
+	f.IncludeFileID = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Empty(t, r.FileID)
+	assert.NotContains(t, r.FileAttributes, attrs.LogFileID)
+
+	// The id is assigned once the file has content
+	filetest.WriteString(t, temp, "testlog1\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog1"))
+	assert.NotEmpty(t, r.FileID)
+	assert.Equal(t, r.FileID, r.FileAttributes[attrs.LogFileID])
+
+	// It is derived again while the file is shorter than the prefix
+	short := r.FileID
+	filetest.WriteString(t, temp, "testlog2\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog2"))
+	assert.NotEqual(t, short, r.FileID)
+	assert.Equal(t, r.FileID, r.FileAttributes[attrs.LogFileID])
+}
+
+func TestFileIDIncludeInode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("inodes are not available on windows")
+	}
+	tempDir := t.TempDir()
+	first := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, first, "testlog1\n")
+	second := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, second, "testlog1\n")
+
+	ids := func(includeInode bool) []string {
+		f, _ := testFactory(t)
+		f.IncludeFileID = true
+		f.FileIDIncludeInode = includeInode
+		var ids []string
+		for _, temp := range []string{first.Name(), second.Name()} {
+			file := filetest.OpenFile(t, temp)
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err := f.NewReader(file, fp)
+			require.NoError(t, err)
+			ids = append(ids, r.FileID)
+			r.Close()
+		}
+		return ids
+	}
+
+	// Files with the same content can only be told apart by their inodes
+	withoutInode := ids(false)
+	assert.Equal(t, withoutInode[0], withoutInode[1])
+	withInode := ids(true)
+	assert.NotEqual(t, withInode[0], withInode[1])
+	assert.NotEqual(t, withoutInode[0], withInode[0])
+}
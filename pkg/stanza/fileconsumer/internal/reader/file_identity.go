@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"fmt"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+)
+
+// identitySource is the content of a file, and optionally its device and inode, from which both its identity and
+// its id are derived.
+type identitySource struct {
+	prefix []byte
+	key    fileKey
+	hasKey bool
+}
+
+// identitySource returns the source of an identity which was derived from the given number of bytes, or false if
+// it would be unchanged. The source is the content of the fingerprint, so it is read again as content is appended
+// only until the fingerprint is full, and files which differ anywhere within their fingerprints differ. The hash
+// of a hashed fingerprint changes as it grows, so it is only used until an identity was assigned.
+func (r *Reader) identitySource(size int, assigned, includeInode bool) (identitySource, bool) {
+	if size >= r.identitySize || r.Fingerprint.Len() <= size {
+		return identitySource{}, false
+	}
+	if r.Fingerprint.IsHashed() && assigned || includeInode && r.file == nil {
+		return identitySource{}, false
+	}
+	prefix := r.Fingerprint.Bytes()
+	src := identitySource{prefix: prefix[:min(len(prefix), r.identitySize)]}
+	if includeInode {
+		if info, err := r.file.Stat(); err == nil {
+			src.key, src.hasKey = fileKeyOf(info)
+		}
+	}
+	return src, true
+}
+
+// Identity returns a string which identifies the file across renames and appends. It is the hex encoded hash of
+// the first bytes of the file, followed by the device and inode of the file if they are included. The identity
+// changes as content is appended only until there is enough content to fill the fingerprint.
+func (r *Reader) Identity() string {
+	src, ok := r.identitySource(r.FileIdentitySize, r.FileIdentity != "", r.fileIdentityInode)
+	if !ok {
+		return r.FileIdentity
+	}
+	identity := fmt.Sprintf("%016x", xxhash.Sum64(src.prefix))
+	if src.hasKey {
+		identity = fmt.Sprintf("%s-%x-%x", identity, src.key.dev, src.key.ino)
+	}
+	r.FileIdentity, r.FileIdentitySize = identity, len(src.prefix)
+	return identity
+}
+
+// assignFileIdentity attaches the identity of the file to every record.
+func (r *Reader) assignFileIdentity() {
+	if !r.includeFileIdentity {
+		return
+	}
+	if identity := r.Identity(); identity != "" {
+		r.FileAttributes[attrs.LogFileIdentity] = identity
+		return
+	}
+	delete(r.FileAttributes, attrs.LogFileIdentity)
+}
@@ -0,0 +1,148 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+// identityTestSize is a fingerprint size which the content of a test file can fill, so that its identity is final.
+const identityTestSize = 64
+
+func TestFileIdentity(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "short\n")
+
+	f, sink := testFactory(t, withFingerprintSize(identityTestSize))
+	f.IncludeFileIdentity = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	_, attributes := sink.NextCall(t)
+	short := r.Identity()
+	assert.Len(t, short, 16)
+	assert.Equal(t, short, attributes[attrs.LogFileIdentity])
+
+	// The identity changes until there is enough content to fill the fingerprint. The fingerprint is refreshed once
+	// the content is read, so the records read with it carry the identity from before.
+	line := strings.Repeat("x", identityTestSize) + "\n"
+	filetest.WriteString(t, temp, line)
+	r.ReadToEnd(context.Background())
+	_, attributes = sink.NextCall(t)
+	assert.Equal(t, short, attributes[attrs.LogFileIdentity])
+	identity := r.Identity()
+	assert.NotEqual(t, short, identity)
+	assert.Equal(t, identity, r.FileAttributes[attrs.LogFileIdentity])
+
+	// Then it is kept as the file grows, including after a restart
+	filetest.WriteString(t, temp, line)
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte(line[:identityTestSize]), map[string]any{
+		attrs.LogFileName:     r.FileAttributes[attrs.LogFileName],
+		attrs.LogFileIdentity: identity,
+	})
+	assert.Equal(t, identityTestSize, r.Fingerprint.Len())
+	assert.Equal(t, identity, r.Identity())
+}
+
+func TestFileIdentitySharedPrefix(t *testing.T) {
+	// The files share a banner which is longer than a short prefix, and differ within the fingerprint
+	banner := strings.Repeat("=", 64) + "\n"
+	f, _ := testFactory(t)
+	var identities []string
+	for _, content := range []string{banner + "first file\n", banner + "second file\n"} {
+		temp := filetest.OpenTemp(t, t.TempDir())
+		filetest.WriteString(t, temp, content)
+		fp, err := f.NewFingerprint(temp)
+		require.NoError(t, err)
+		r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+		require.NoError(t, err)
+		identities = append(identities, r.Identity())
+		r.Close()
+	}
+	assert.NotEqual(t, identities[0], identities[1])
+}
+
+func TestFileIdentityRename(t *testing.T) {
+	for _, includeInode := range []bool{false, true} {
+		if includeInode && runtime.GOOS == "windows" {
+			continue
+		}
+		tempDir := t.TempDir()
+		temp := filetest.OpenTemp(t, tempDir)
+		filetest.WriteString(t, temp, "testlog1\n")
+		require.NoError(t, temp.Close())
+
+		f, _ := testFactory(t)
+		f.FileIdentityIncludeInode = includeInode
+		identity := func(path string) string {
+			file := filetest.OpenFile(t, path)
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err := f.NewReader(file, fp)
+			require.NoError(t, err)
+			defer r.Close()
+			return r.Identity()
+		}
+
+		before := identity(temp.Name())
+		renamed := filepath.Join(tempDir, "renamed.log")
+		require.NoError(t, os.Rename(temp.Name(), renamed))
+		assert.Equal(t, before, identity(renamed))
+
+		// A copy of the file has the same content, so it can only be told apart by its inode
+		copied := filepath.Join(tempDir, "copied.log")
+		require.NoError(t, os.WriteFile(copied, []byte("testlog1\n"), 0o600))
+		assert.Equal(t, !includeInode, before == identity(copied))
+	}
+}
+
+func TestFileIdentityResetToStart(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "other\n")
+
+	f, sink := testFactory(t, withFingerprintSize(7))
+	f.ResumeByContent = true
+	f.IncludeFileIdentity = true
+	f.IncludeFileID = true
+	// The stored offset and identity belong to content which is no longer in the file
+	m := &Metadata{
+		Fingerprint:      fingerprint.New([]byte("line1\n")),
+		Offset:           int64(len("line1\n")),
+		FileID:           "stale",
+		FileIDSize:       7,
+		FileIdentity:     "stale",
+		FileIdentitySize: 7,
+		FileAttributes:   map[string]any{attrs.LogFileID: "stale", attrs.LogFileIdentity: "stale"},
+	}
+	r, err := f.NewReaderFromMetadata(temp, m)
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Zero(t, r.Offset)
+
+	// The file is read again as new content, which has its own identity and id
+	r.ReadToEnd(context.Background())
+	_, attributes := sink.NextCall(t)
+	assert.NotEqual(t, "stale", r.Identity())
+	assert.Equal(t, r.Identity(), attributes[attrs.LogFileIdentity])
+	assert.NotEqual(t, "stale", r.FileID)
+	assert.Equal(t, r.FileID, attributes[attrs.LogFileID])
+}
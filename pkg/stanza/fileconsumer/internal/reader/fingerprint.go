@@ -0,0 +1,102 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
+)
+
+// newFingerprint computes the fingerprint of a file. When decompress is set, gzip compressed files are
+// fingerprinted over their decompressed content, so that a file which is recompressed is not treated as new.
+func newFingerprint(file *os.File, size int, compression string, decompress bool, cache *PrefixCache) (*fingerprint.Fingerprint, error) {
+	if compression != "" && filepath.Ext(file.Name()) == gzipExtension {
+		if decompress {
+			return fingerprint.NewFromDecompressedFile(file, size)
+		}
+		return fingerprint.NewFromFile(file, size, true)
+	}
+	if cache != nil {
+		prefix, err := cache.readPrefix(file, size)
+		if err != nil {
+			return nil, fmt.Errorf("reading fingerprint bytes: %w", err)
+		}
+		// The cached prefix is shared, so the fingerprint must have its own copy
+		return fingerprint.New(bytes.Clone(prefix)), nil
+	}
+	return fingerprint.NewFromFile(file, size, compression != "")
+}
+
+func (r *Reader) newFingerprint(file *os.File) (*fingerprint.Fingerprint, error) {
+	return newFingerprint(file, r.fingerprintSize, r.compression, r.decompressFP, r.prefixCache)
+}
+
+// GrowFingerprint identifies the file by the given number of bytes, rather than the configured fingerprint size, so
+// that it can be told apart from another file with the same content at the start. The size is kept with the metadata.
+func (r *Reader) GrowFingerprint(size int) error {
+	if size <= r.fingerprintSize {
+		return nil
+	}
+	fp, err := r.FingerprintWithSize(size)
+	if err != nil {
+		return err
+	}
+	r.fingerprintSize = size
+	r.FingerprintSize = size
+	r.Fingerprint = fp
+	r.assignFileID()
+	return nil
+}
+
+// FingerprintWithSize computes the fingerprint of the file over the given number of bytes, without changing the
+// fingerprint which identifies the reader.
+func (r *Reader) FingerprintWithSize(size int) (*fingerprint.Fingerprint, error) {
+	if r.file == nil {
+		return nil, errReaderClosed
+	}
+	if r.hashFingerprint {
+		return nil, errors.New("a hashed fingerprint cannot be grown")
+	}
+	return newFingerprint(r.file, size, r.compression, r.decompressFP, r.prefixCache)
+}
+
+// FingerprintMatchFunc returns true if a fingerprint which is read again from a file still identifies the
+// file which the stored fingerprint was read from.
+type FingerprintMatchFunc func(stored, refreshed *fingerprint.Fingerprint) bool
+
+// HammingFingerprintMatch matches a refreshed fingerprint which differs from the stored fingerprint in
+// at most maxDistance of the bytes which they have in common, as when a banner at the start of a file is
+// rewritten. Hashed fingerprints cannot be compared byte by byte, so they must match exactly.
+func HammingFingerprintMatch(maxDistance int) FingerprintMatchFunc {
+	return func(stored, refreshed *fingerprint.Fingerprint) bool {
+		if stored.IsHashed() || refreshed.IsHashed() {
+			return refreshed.StartsWith(stored)
+		}
+		old, current := stored.Bytes(), refreshed.Bytes()
+		if len(old) == 0 || len(old) > len(current) {
+			return false
+		}
+		var distance int
+		for i := range old {
+			if old[i] != current[i] {
+				distance++
+			}
+		}
+		return distance <= maxDistance
+	}
+}
+
+// fingerprintMatches returns true if a fingerprint read again from the file still identifies it. By
+// default, the refreshed fingerprint must start with the stored one.
+func (r *Reader) fingerprintMatches(refreshed *fingerprint.Fingerprint) bool {
+	if r.fingerprintMatch != nil {
+		return r.fingerprintMatch(r.Fingerprint, refreshed)
+	}
+	return refreshed.StartsWith(r.Fingerprint)
+}
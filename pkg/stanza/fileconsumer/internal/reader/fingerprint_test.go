@@ -4,8 +4,11 @@
 package reader
 
 import (
+	"compress/gzip"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -347,3 +350,152 @@ func TestReadingWithLargeFingerPrintSizeAndFileLargerThanScannerBuf(t *testing.T
 
 	sink.ExpectTokens(t, expected...)
 }
+
+func TestDecompressFingerprint(t *testing.T) {
+	tempDir := t.TempDir()
+	content := []byte("the same content\ncompressed two different ways\n")
+
+	writeGzip := func(name string, level int) *os.File {
+		file := filetest.OpenFile(t, filepath.Join(tempDir, name))
+		gzipWriter, err := gzip.NewWriterLevel(file, level)
+		require.NoError(t, err)
+		gzipWriter.Name = name
+		_, err = gzipWriter.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, gzipWriter.Close())
+		return file
+	}
+	fast := writeGzip("fast.log.gz", gzip.BestSpeed)
+	small := writeGzip("small.log.gz", gzip.HuffmanOnly)
+
+	f, sink := testFactory(t, withFingerprintSize(len(content)))
+	f.Compression = "gzip"
+
+	// By default the compressed bytes are fingerprinted
+	fastFP, err := f.NewFingerprint(fast)
+	require.NoError(t, err)
+	smallFP, err := f.NewFingerprint(small)
+	require.NoError(t, err)
+	require.False(t, fastFP.Equal(smallFP))
+
+	f.DecompressFingerprint = true
+	fastFP, err = f.NewFingerprint(fast)
+	require.NoError(t, err)
+	smallFP, err = f.NewFingerprint(small)
+	require.NoError(t, err)
+	require.True(t, fastFP.Equal(smallFP))
+	require.Equal(t, fingerprint.New(content), fastFP)
+
+	// The fingerprint remains stable as the file is read and validated
+	r, err := f.NewReader(fast, fastFP)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("the same content"), []byte("compressed two different ways"))
+	require.True(t, r.Validate())
+	require.Equal(t, fingerprint.New(content), r.Fingerprint)
+
+	// The recompressed file is recognized as the same file
+	require.True(t, smallFP.StartsWith(r.Fingerprint))
+}
+
+func TestHashedFingerprint(t *testing.T) {
+	t.Parallel()
+
+	fpSize := 20
+	f, sink := testFactory(t, withFingerprintSize(fpSize))
+	f.FingerprintAlgorithm = fingerprint.AlgorithmXXHash
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog1\n")
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	require.Equal(t, fingerprint.New([]byte("testlog1\n")).Hashed(), r.Fingerprint)
+
+	// The fingerprint grows with the file and stays hashed
+	content := "testlog1\ntestlog2\ntestlog3\n"
+	filetest.WriteString(t, temp, "testlog2\ntestlog3\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"), []byte("testlog3"))
+	require.Equal(t, fingerprint.New([]byte(content[:fpSize])).Hashed(), r.Fingerprint)
+	require.True(t, r.Validate())
+
+	// The same file is still identified by its hashed fingerprint
+	current, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	require.True(t, current.Equal(r.Fingerprint))
+	require.True(t, current.StartsWith(r.Fingerprint))
+
+	// A smaller fingerprint size is accepted for the same content
+	f.FingerprintSize = fpSize / 2
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	require.Equal(t, fingerprint.New([]byte(content[:fpSize/2])).Hashed(), r.Fingerprint)
+
+	// A file with other content is not identified by the hashed fingerprint
+	other := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, other, "otherlog1\notherlog2\n")
+	otherFP, err := f.NewFingerprint(other)
+	require.NoError(t, err)
+	require.False(t, otherFP.StartsWith(r.Fingerprint))
+	require.False(t, otherFP.Equal(r.Fingerprint))
+
+	// Nor is a truncated file which has since been rewritten
+	require.NoError(t, temp.Truncate(0))
+	_, err = temp.Seek(0, 0)
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "rewritten1\nrewritten2\n")
+	require.False(t, r.Validate())
+	f.FingerprintSize = fpSize / 4
+	_, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.EqualError(t, err, "file truncated")
+}
+
+func TestGrowFingerprint(t *testing.T) {
+	tempDir := t.TempDir()
+	prefix := "a common header which is longer than the fingerprint\n"
+	temp1 := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp1, prefix+"file1\n")
+	temp2 := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp2, prefix+"file2\n")
+
+	f, _ := testFactory(t, withFingerprintSize(16))
+	f.MaxFingerprintSize = 1024
+
+	readers := make([]*Reader, 2)
+	for i, temp := range []*os.File{temp1, temp2} {
+		fp, err := f.NewFingerprint(temp)
+		require.NoError(t, err)
+		readers[i], err = f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+		require.NoError(t, err)
+		defer readers[i].Close()
+	}
+	require.True(t, readers[0].Fingerprint.Equal(readers[1].Fingerprint))
+
+	// Growing the fingerprints beyond the common prefix tells the files apart
+	for _, r := range readers {
+		require.NoError(t, r.GrowFingerprint(128))
+		assert.Equal(t, 128, r.FingerprintSize)
+	}
+	assert.Equal(t, fingerprint.New([]byte(prefix+"file1\n")), readers[0].Fingerprint)
+	assert.Equal(t, fingerprint.New([]byte(prefix+"file2\n")), readers[1].Fingerprint)
+
+	// The grown size is kept with the metadata, rather than being shortened to the configured size
+	r, err := f.NewReaderFromMetadata(filetest.OpenFile(t, temp1.Name()), readers[0].Close())
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Equal(t, fingerprint.New([]byte(prefix+"file1\n")), r.Fingerprint)
+	assert.Equal(t, 128, r.fingerprintSize)
+
+	// The fingerprint is shortened once growing is disabled
+	f.MaxFingerprintSize = 0
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp1.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Equal(t, fingerprint.New([]byte(prefix[:16])), r.Fingerprint)
+	assert.Zero(t, r.FingerprintSize)
+}
@@ -0,0 +1,44 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "context"
+
+// GzipReaderLimiter bounds the number of gzip readers which are open at once across the readers sharing it.
+// Each gzip reader holds the state needed to decompress its file, so this bounds the memory used when many
+// compressed files are read at the same time. Readers wait for a gzip reader to be released before opening one.
+type GzipReaderLimiter struct {
+	slots chan struct{}
+}
+
+// NewGzipReaderLimiter creates a limiter which allows up to maxOpen gzip readers to be open at once.
+func NewGzipReaderLimiter(maxOpen int) *GzipReaderLimiter {
+	return &GzipReaderLimiter{slots: make(chan struct{}, maxOpen)}
+}
+
+// acquire waits until a gzip reader may be opened. It returns false if the context is done first.
+func (l *GzipReaderLimiter) acquire(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (l *GzipReaderLimiter) release() {
+	<-l.slots
+}
+
+// releaseGzipReader discards the gzip reader opened for the current read, so that its state
+// is not held onto between reads, and allows another reader to open one in its place.
+func (r *Reader) releaseGzipReader() {
+	r.gzipPosition = nil
+	if !r.gzipReaderAcquired {
+		return
+	}
+	r.reader = nil
+	r.gzipReaderAcquired = false
+	r.gzipReaderLimiter.release()
+}
@@ -0,0 +1,102 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGzipReaderLimiter(t *testing.T) {
+	const numFiles = 20
+	const maxOpen = 2
+	limiter := NewGzipReaderLimiter(maxOpen)
+
+	tempDir := t.TempDir()
+	var mu sync.Mutex
+	var maxObserved int
+	var emitted int
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		mu.Lock()
+		maxObserved = max(maxObserved, len(limiter.slots))
+		emitted += len(tokens)
+		mu.Unlock()
+		// Hold the gzip reader open for long enough that the other readers are waiting for it
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	f.Compression = "gzip"
+	f.GzipReaderLimiter = limiter
+
+	readers := make([]*Reader, numFiles)
+	for i := range readers {
+		path := filepath.Join(tempDir, fmt.Sprintf("file%d.log.gz", i))
+		writeGzipFile(t, path, fmt.Sprintf("file %d line 1\nfile %d line 2\n", i, i))
+		file, err := os.Open(path)
+		require.NoError(t, err)
+		fp, err := f.NewFingerprint(file)
+		require.NoError(t, err)
+		readers[i], err = f.NewReader(file, fp)
+		require.NoError(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, r := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.ReadToEnd(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2*numFiles, emitted)
+	assert.Positive(t, maxObserved)
+	assert.LessOrEqual(t, maxObserved, maxOpen)
+	// All gzip readers are released once reading has finished
+	assert.Empty(t, limiter.slots)
+	for _, r := range readers {
+		assert.Nil(t, r.reader)
+		r.Close()
+	}
+}
+
+func TestGzipReaderLimiterContextCanceled(t *testing.T) {
+	limiter := NewGzipReaderLimiter(1)
+	require.True(t, limiter.acquire(context.Background()))
+
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "file.log.gz")
+	writeGzipFile(t, path, "line\n")
+
+	f, sink := testFactory(t)
+	f.Compression = "gzip"
+	f.GzipReaderLimiter = limiter
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// No gzip reader is available, so reading stops once the context is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ReadToEnd(ctx)
+	sink.ExpectNoCalls(t)
+	assert.Zero(t, r.Offset)
+
+	limiter.release()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("line"))
+}
@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+)
+
+// completeGzipMembersEnd returns the offset at which the last complete gzip member between start and end finishes,
+// or start if there is no complete member. A member is only complete once its trailer has been written, which can
+// only be verified by decompressing it, so the members are decompressed and the output discarded.
+func completeGzipMembersEnd(src io.ReaderAt, start, end int64) int64 {
+	counter := &countingByteReader{reader: bufio.NewReader(io.NewSectionReader(src, start, end-start))}
+	completeEnd := start
+	var gzipReader gzip.Reader
+	for {
+		// The gzip reader reads exactly one member when it is given an io.ByteReader,
+		// so the count of bytes read is the offset of the end of the member.
+		if err := gzipReader.Reset(counter); err != nil {
+			return completeEnd
+		}
+		gzipReader.Multistream(false)
+		if _, err := io.Copy(io.Discard, &gzipReader); err != nil {
+			return completeEnd
+		}
+		completeEnd = start + counter.n
+	}
+}
+
+type countingByteReader struct {
+	reader *bufio.Reader
+	n      int64
+}
+
+func (c *countingByteReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingByteReader) ReadByte() (byte, error) {
+	b, err := c.reader.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func gzipMember(t *testing.T, content string) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	_, err := gzipWriter.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+func TestIncrementalGzip(t *testing.T) {
+	tempDir := t.TempDir()
+	name := filepath.Join(tempDir, "app.log.gz")
+	file, err := os.Create(name)
+	require.NoError(t, err)
+	defer file.Close()
+
+	first := gzipMember(t, "first\n")
+	second := gzipMember(t, "second\nthird\n")
+	_, err = file.Write(first)
+	require.NoError(t, err)
+
+	f, sink := testFactory(t)
+	f.Compression = "gzip"
+	f.IncrementalGzip = true
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, name), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("first"))
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(len(first)), r.Offset)
+
+	// The second member is incomplete until its trailer has been written
+	_, err = file.Write(second[:len(second)-4])
+	require.NoError(t, err)
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, name), r.Close())
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(len(first)), r.Offset)
+
+	_, err = file.Write(second[len(second)-4:])
+	require.NoError(t, err)
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, name), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("second"), []byte("third"))
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(len(first)+len(second)), r.Offset)
+}
+
+func TestCompleteGzipMembersEnd(t *testing.T) {
+	first := gzipMember(t, "first\n")
+	second := gzipMember(t, "second\n")
+	data := append(append([]byte{}, first...), second...)
+
+	testCases := []struct {
+		name     string
+		start    int64
+		end      int64
+		expected int64
+	}{
+		{"Empty", 0, 0, 0},
+		{"PartialHeader", 0, 5, 0},
+		{"PartialFirstMember", 0, int64(len(first) - 1), 0},
+		{"FirstMember", 0, int64(len(first)), int64(len(first))},
+		{"PartialSecondMember", 0, int64(len(data) - 1), int64(len(first))},
+		{"BothMembers", 0, int64(len(data)), int64(len(data))},
+		{"FromSecondMember", int64(len(first)), int64(len(data)), int64(len(data))},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, completeGzipMembersEnd(bytes.NewReader(data), tc.start, tc.end))
+		})
+	}
+}
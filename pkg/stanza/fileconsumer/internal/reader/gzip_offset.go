@@ -0,0 +1,46 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bufio"
+	"io"
+)
+
+// compressedPosition tracks how far into a compressed file the decompressor has read. It implements
+// io.ByteReader so that the decompressor reads from it directly, rather than buffering ahead of it.
+// The position is only approximate for the tokens read from it, since the decompressor holds some
+// bits of the input and the scanner reads ahead of the token which it returns. It is never earlier
+// than the data which a token was decompressed from, and is usually within the same gzip member.
+type compressedPosition struct {
+	reader *bufio.Reader
+	pos    int64
+}
+
+func newCompressedPosition(src io.Reader, offset int64) *compressedPosition {
+	return &compressedPosition{reader: bufio.NewReader(src), pos: offset}
+}
+
+func (p *compressedPosition) Read(dst []byte) (int, error) {
+	n, err := p.reader.Read(dst)
+	p.pos += int64(n)
+	return n, err
+}
+
+func (p *compressedPosition) ReadByte() (byte, error) {
+	b, err := p.reader.ReadByte()
+	if err == nil {
+		p.pos++
+	}
+	return b, err
+}
+
+// compressedOffset returns the compressed position of the data which was last read from the decompressor. When
+// decompression happens on a pool, the position is the one sent by the worker with the chunk being read.
+func (r *Reader) compressedOffset() int64 {
+	if pr, ok := r.reader.(*pooledReader); ok {
+		return pr.compressedPos
+	}
+	return r.gzipPosition.pos
+}
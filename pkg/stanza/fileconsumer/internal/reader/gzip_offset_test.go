@@ -0,0 +1,90 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestGzipCompressedOffset(t *testing.T) {
+	// Each member holds lines which do not compress well, so that the compressed offset advances
+	const numMembers, linesPerMember = 3, 200
+	rnd := rand.New(rand.NewPCG(1, 2))
+	var buf bytes.Buffer
+	memberStarts := make([]int64, numMembers)
+	for m := range numMembers {
+		memberStarts[m] = int64(buf.Len())
+		var content strings.Builder
+		for i := range linesPerMember {
+			fmt.Fprintf(&content, "member %d line %d %016x%016x\n", m, i, rnd.Uint64(), rnd.Uint64())
+		}
+		gzipWriter := gzip.NewWriter(&buf)
+		_, err := gzipWriter.Write([]byte(content.String()))
+		require.NoError(t, err)
+		require.NoError(t, gzipWriter.Close())
+	}
+	name := filepath.Join(t.TempDir(), "app.log.gz")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o600))
+
+	for _, pooled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("pooled=%t", pooled), func(t *testing.T) {
+			testGzipCompressedOffset(t, name, pooled, buf.Len(), memberStarts, numMembers*linesPerMember)
+		})
+	}
+}
+
+func testGzipCompressedOffset(t *testing.T, name string, pooled bool, size int, memberStarts []int64, numLines int) {
+	var offsets []int64
+	var members []int
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, attributes map[string]any, _ int64, _ []int64) error {
+		require.Contains(t, attributes, attrs.LogFileGzipCompressedOffset)
+		for _, token := range tokens {
+			var m, i int
+			_, err := fmt.Sscanf(string(token), "member %d line %d", &m, &i)
+			require.NoError(t, err)
+			offsets = append(offsets, attributes[attrs.LogFileGzipCompressedOffset].(int64))
+			members = append(members, m)
+		}
+		return nil
+	})
+	f.Compression = "gzip"
+	f.IncludeGzipOffset = true
+	if pooled {
+		// The position is advanced by the workers while the reader scans the chunk before
+		f.DecompressionPool = NewDecompressionPool(2)
+		f.DecompressionPool.chunkSize = 1024
+		defer f.DecompressionPool.Stop()
+	}
+	file := filetest.OpenFile(t, name)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	require.Len(t, offsets, numLines)
+	for i, offset := range offsets {
+		if i > 0 {
+			require.GreaterOrEqual(t, offset, offsets[i-1])
+		}
+		// The offset is never before the member which the token was decompressed from
+		require.Greater(t, offset, memberStarts[members[i]])
+		require.LessOrEqual(t, offset, int64(size))
+	}
+	require.Greater(t, offsets[len(offsets)-1], offsets[0])
+}
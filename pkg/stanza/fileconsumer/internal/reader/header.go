@@ -0,0 +1,136 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"context"
+	"maps"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/header"
+)
+
+// isRepeatedHeaderStart returns true if the token, which starts at the given offset, begins another header block.
+func (r *Reader) isRepeatedHeaderStart(token []byte, offset int64) bool {
+	if r.repeatedHeaderStart == nil {
+		return false
+	}
+	// The header can only be re-read if we are able to seek back to it
+	if !r.readingFile() {
+		return false
+	}
+	// If the header was already re-armed at this token, but it was not consumed as a header line,
+	// treat it as content. Otherwise, we would never make progress.
+	if offset == r.lastHeaderRearm {
+		return false
+	}
+	return r.repeatedHeaderStart.Match(token)
+}
+
+// rearmHeader prepares the header machinery to parse another header block starting at the current offset.
+// Attributes parsed from the new block are upserted over those of the previous block.
+func (r *Reader) rearmHeader() {
+	headerReader, err := header.NewReader(r.set, *r.headerConfig)
+	if err != nil {
+		r.set.Logger.Error("failed to re-arm header reader", zap.Error(err))
+		return
+	}
+	if err = r.seekToOffset(); err != nil {
+		r.set.Logger.Error("failed to seek to header", zap.Error(err))
+		if err = headerReader.Stop(); err != nil {
+			r.set.Logger.Error("failed to stop header pipeline", zap.Error(err))
+		}
+		return
+	}
+
+	// Tokens which were already emitted keep the attributes of the previous header
+	r.FileAttributes = maps.Clone(r.FileAttributes)
+	r.headerReader = headerReader
+	r.HeaderFinalized = false
+	r.lastHeaderRearm = r.Offset
+}
+
+// promoteHeaderAttributes moves the configured attributes parsed from the header to resource attributes,
+// which are held together under attrs.LogFileResourceAttributes so that they cannot be mistaken for other attributes.
+func (r *Reader) promoteHeaderAttributes() {
+	// The map may already have been emitted, so it is replaced rather than modified
+	resource, _ := r.FileAttributes[attrs.LogFileResourceAttributes].(map[string]any)
+	resource = maps.Clone(resource)
+	for key, resourceKey := range r.headerResourceAttributes {
+		v, ok := r.FileAttributes[key]
+		if !ok {
+			continue
+		}
+		if resource == nil {
+			resource = make(map[string]any, len(r.headerResourceAttributes))
+		}
+		delete(r.FileAttributes, key)
+		resource[resourceKey] = v
+	}
+	if resource != nil {
+		r.FileAttributes[attrs.LogFileResourceAttributes] = resource
+	}
+}
+
+// applyHeaderEncoding switches the rest of the file to the encoding named by the configured attribute of the
+// header, if the header declared one.
+func (r *Reader) applyHeaderEncoding() {
+	if r.headerEncodingAttribute == "" {
+		return
+	}
+	name, ok := r.FileAttributes[r.headerEncodingAttribute].(string)
+	if !ok || name == "" || name == r.HeaderEncoding {
+		return
+	}
+	if err := r.bindHeaderEncoding(name); err != nil {
+		r.set.Logger.Warn("header declares an encoding which cannot be used", zap.String("encoding", name), zap.Error(err))
+		return
+	}
+	r.HeaderEncoding = name
+}
+
+// bindHeaderEncoding rebinds the decoder and split func to the named encoding.
+func (r *Reader) bindHeaderEncoding(name string) error {
+	enc, err := textutils.LookupEncoding(name)
+	if err != nil {
+		return err
+	}
+	splitFunc, err := r.headerEncodingSplitFunc(enc)
+	if err != nil {
+		return err
+	}
+	r.bindEncoding(enc, splitFunc)
+	return nil
+}
+
+// emitHeaderToken emits a header token as a record, in addition to parsing it, when header tokens are emitted.
+// The header is only read while it is not finalized, so its tokens are not emitted again when reading resumes.
+// It returns false if reading should stop, because the emit callback was interrupted. The token is read again
+// unless the callback is still running, in which case it is treated as emitted.
+func (r *Reader) emitHeaderToken(ctx context.Context, token string, offset, end int64) bool {
+	if !r.emitHeader {
+		return true
+	}
+	r.RecordNum++
+	tokenAttributes := []map[string]any{{attrs.LogFileIsHeader: true}}
+	if r.includeScanTime {
+		tokenAttributes[0][attrs.LogFileScanTimeUnixNano] = r.scanTime.UnixNano()
+	}
+	err := r.emitBatch(ctx, [][]byte{[]byte(token)}, tokenAttributes, []int64{offset, end})
+	if r.emitInterrupted(ctx, err) {
+		if r.inFlight == nil || r.inFlight.stuck == nil {
+			r.RecordNum--
+			r.inFlight = nil
+		} else {
+			r.Offset = end
+		}
+		return false
+	} else if err != nil {
+		r.set.Logger.Error("failed to emit header token", zap.Error(err))
+	}
+	return true
+}
@@ -0,0 +1,368 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.uber.org/zap/zaptest"
+	"golang.org/x/text/encoding/japanese"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/header"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/parser/regex"
+)
+
+func TestRepeatedHeader(t *testing.T) {
+	f, sink := testFactory(t)
+
+	regexConf := regex.NewConfig()
+	regexConf.Regex = "^#(?P<key>[a-z]+): (?P<value>.*)"
+
+	enc, err := textutils.LookupEncoding("utf-8")
+	require.NoError(t, err)
+
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+	require.NoError(t, err)
+	f.HeaderConfig = h
+	f.RepeatedHeaderStart = regexp.MustCompile("^#")
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "#key: first\naaa\nbbb\n#key: second\nccc\n")
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+
+	fileName := filepath.Base(temp.Name())
+	first := map[string]any{attrs.LogFileName: fileName, "key": "key", "value": "first"}
+	second := map[string]any{attrs.LogFileName: fileName, "key": "key", "value": "second"}
+	sink.ExpectCall(t, []byte("aaa"), first)
+	sink.ExpectCall(t, []byte("bbb"), first)
+	sink.ExpectCall(t, []byte("ccc"), second)
+	sink.ExpectNoCalls(t)
+	require.True(t, r.HeaderFinalized)
+
+	// A third header block appended later is also picked up
+	filetest.WriteString(t, temp, "#key: third\nddd\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("ddd"), map[string]any{attrs.LogFileName: fileName, "key": "key", "value": "third"})
+	sink.ExpectNoCalls(t)
+}
+
+func TestRepeatedHeaderStartNotAHeader(t *testing.T) {
+	f, sink := testFactory(t)
+
+	regexConf := regex.NewConfig()
+	regexConf.Regex = "^#(?P<header>.*)"
+
+	enc, err := textutils.LookupEncoding("utf-8")
+	require.NoError(t, err)
+
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+	require.NoError(t, err)
+	f.HeaderConfig = h
+	// The marker matches a line which the header does not consume
+	f.RepeatedHeaderStart = regexp.MustCompile("^report")
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "#first\naaa\nreport\nbbb\n")
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("aaa"), []byte("report"), []byte("bbb"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestHeaderFileTruncatedDuringHeaderRead(t *testing.T) {
+	f, sink := testFactory(t)
+
+	regexConf := regex.NewConfig()
+	regexConf.Regex = "^#(?P<key>[a-z]+): (?P<value>.*)"
+
+	enc, err := textutils.LookupEncoding("utf-8")
+	require.NoError(t, err)
+
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+	require.NoError(t, err)
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "#key: a long header value which will disappear\nold content\n")
+
+	// Truncate and rewrite the file once the header scanner has buffered the original content
+	headerSplitFunc := h.SplitFunc
+	var truncated bool
+	h.SplitFunc = func(data []byte, atEOF bool) (int, []byte, error) {
+		if !truncated {
+			truncated = true
+			require.NoError(t, temp.Truncate(0))
+			_, err = temp.WriteAt([]byte("#key: new\nnew content\n"), 0)
+			require.NoError(t, err)
+		}
+		return headerSplitFunc(data, atEOF)
+	}
+	f.HeaderConfig = h
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	require.True(t, truncated)
+
+	fileName := filepath.Base(temp.Name())
+	sink.ExpectCall(t, []byte("new content"), map[string]any{attrs.LogFileName: fileName, "key": "key", "value": "new"})
+	sink.ExpectNoCalls(t)
+	require.True(t, r.HeaderFinalized)
+	require.Equal(t, int64(len("#key: new\nnew content\n")), r.Offset)
+}
+
+func TestStaticLabels(t *testing.T) {
+	testCases := []struct {
+		name        string
+		override    bool
+		expectValue string
+	}{
+		{name: "HeaderTakesPrecedence", override: false, expectValue: "header"},
+		{name: "StaticLabelsOverride", override: true, expectValue: "static"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, sink := testFactory(t)
+
+			regexConf := regex.NewConfig()
+			regexConf.Regex = "^#(?P<key>[a-z]+): (?P<value>.*)"
+
+			enc, err := textutils.LookupEncoding("utf-8")
+			require.NoError(t, err)
+
+			set := componenttest.NewNopTelemetrySettings()
+			set.Logger = zaptest.NewLogger(t)
+			h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+			require.NoError(t, err)
+			f.HeaderConfig = h
+			f.StaticLabels = map[string]any{"env": "prod", "value": "static"}
+			f.StaticLabelsOverride = tc.override
+
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, "#key: header\naaa\nbbb\n")
+
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+
+			r.ReadToEnd(context.Background())
+
+			expectAttrs := map[string]any{
+				attrs.LogFileName: filepath.Base(temp.Name()),
+				"env":             "prod",
+				"key":             "key",
+				"value":           tc.expectValue,
+			}
+			sink.ExpectCall(t, []byte("aaa"), expectAttrs)
+			sink.ExpectCall(t, []byte("bbb"), expectAttrs)
+			sink.ExpectNoCalls(t)
+
+			// Static labels are not persisted with the file attributes
+			require.NotContains(t, r.FileAttributes, "env")
+			require.Equal(t, "header", r.FileAttributes["value"])
+		})
+	}
+}
+
+func TestHeaderResourceAttributes(t *testing.T) {
+	f, sink := testFactory(t)
+
+	regexConf := regex.NewConfig()
+	regexConf.Regex = "^#(?P<key>[a-z]+): (?P<value>.*)"
+
+	enc, err := textutils.LookupEncoding("utf-8")
+	require.NoError(t, err)
+
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+	require.NoError(t, err)
+	f.HeaderConfig = h
+	f.HeaderResourceAttributes = map[string]string{"value": "service.name", "missing": "host.name"}
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "#service: checkout\naaa\n")
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("aaa"), map[string]any{
+		attrs.LogFileName:               filepath.Base(temp.Name()),
+		"key":                           "service",
+		attrs.LogFileResourceAttributes: map[string]any{"service.name": "checkout"},
+	})
+	sink.ExpectNoCalls(t)
+}
+
+func TestHeaderEncoding(t *testing.T) {
+	f, sink := testFactory(t)
+
+	regexConf := regex.NewConfig()
+	regexConf.Regex = "^#encoding=(?P<encoding>.*)"
+
+	enc, err := textutils.LookupEncoding("utf-8")
+	require.NoError(t, err)
+
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+	require.NoError(t, err)
+	f.HeaderConfig = h
+	f.HeaderEncodingAttribute = "encoding"
+
+	body, err := japanese.ShiftJIS.NewEncoder().String("こんにちは\n世界\n")
+	require.NoError(t, err)
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "#encoding=shift_jis\n"+body)
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	expected := map[string]any{attrs.LogFileName: filepath.Base(temp.Name()), "encoding": "shift_jis"}
+	sink.ExpectCall(t, []byte("こんにちは"), expected)
+	sink.ExpectCall(t, []byte("世界"), expected)
+	sink.ExpectNoCalls(t)
+
+	// The encoding is restored along with the metadata, since the header is not read again
+	encoded, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	require.Equal(t, "shift_jis", m.HeaderEncoding)
+	body, err = japanese.ShiftJIS.NewEncoder().String("さようなら\n")
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, body)
+
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("さようなら"), expected)
+	sink.ExpectNoCalls(t)
+}
+
+func TestEmitHeader(t *testing.T) {
+	f, sink := testFactory(t)
+
+	regexConf := regex.NewConfig()
+	regexConf.Regex = "^#(?P<key>[a-z]+): (?P<value>.*)"
+
+	enc, err := textutils.LookupEncoding("utf-8")
+	require.NoError(t, err)
+
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+	require.NoError(t, err)
+	f.HeaderConfig = h
+	f.EmitHeader = true
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "#key: first\naaa\n")
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	fileName := filepath.Base(temp.Name())
+	expected := map[string]any{attrs.LogFileName: fileName, "key": "key", "value": "first"}
+	sink.ExpectCall(t, []byte("#key: first"), map[string]any{
+		attrs.LogFileName:     fileName,
+		"key":                 "key",
+		"value":               "first",
+		attrs.LogFileIsHeader: true,
+	})
+	sink.ExpectCall(t, []byte("aaa"), expected)
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(2), r.RecordNum)
+
+	// The header is not emitted again when reading resumes
+	m := r.Close()
+	filetest.WriteString(t, temp, "bbb\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("bbb"), expected)
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(len("#key: first\naaa\nbbb\n")), r.Offset)
+}
+
+func TestEmitHeaderScanTime(t *testing.T) {
+	f, sink := testFactory(t)
+
+	regexConf := regex.NewConfig()
+	regexConf.Regex = "^#(?P<key>[a-z]+): (?P<value>.*)"
+
+	enc, err := textutils.LookupEncoding("utf-8")
+	require.NoError(t, err)
+
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+	require.NoError(t, err)
+	f.HeaderConfig = h
+	f.EmitHeader = true
+	f.IncludeScanTime = true
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "#key: first\naaa\n")
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	before := time.Now().UnixNano()
+	r.ReadToEnd(context.Background())
+
+	// The header token is stamped with the time it was scanned, like the tokens after it
+	token, attributes := sink.NextCall(t)
+	require.Equal(t, []byte("#key: first"), token)
+	require.GreaterOrEqual(t, attributes[attrs.LogFileScanTimeUnixNano].(int64), before)
+	_, attributes = sink.NextCall(t)
+	require.GreaterOrEqual(t, attributes[attrs.LogFileScanTimeUnixNano].(int64), before)
+	sink.ExpectNoCalls(t)
+}
@@ -0,0 +1,95 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestHostSequence(t *testing.T) {
+	var seq atomic.Uint64
+	var emitted []uint64
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, attributes map[string]any, _ int64, _ []int64) error {
+		for range tokens {
+			emitted = append(emitted, attributes[attrs.LogFileHostSeq].(uint64))
+		}
+		return nil
+	})
+	f.HostSequence = func() uint64 { return seq.Add(1) }
+
+	tempDir := t.TempDir()
+	for _, content := range []string{"a1\na2\na3\n", "b1\nb2\n"} {
+		temp := filetest.OpenTemp(t, tempDir)
+		filetest.WriteString(t, temp, content)
+		fp, err := f.NewFingerprint(temp)
+		require.NoError(t, err)
+		r, err := f.NewReader(temp, fp)
+		require.NoError(t, err)
+		r.ReadToEnd(context.Background())
+		r.Close()
+	}
+
+	// The readers share the allocator, so the tokens of both files are ordered by one sequence
+	assert.Equal(t, []uint64{1, 2, 3, 4, 5}, emitted)
+}
+
+func TestHostSequenceWithinReader(t *testing.T) {
+	var seq atomic.Uint64
+	f, sink := testFactory(t)
+	f.HostSequence = func() uint64 { return seq.Add(10) }
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	filetest.WriteString(t, temp, "testlog3\n")
+	r.ReadToEnd(context.Background())
+
+	var last uint64
+	for range 3 {
+		_, attributes := sink.NextCall(t)
+		current, ok := attributes[attrs.LogFileHostSeq].(uint64)
+		require.True(t, ok)
+		assert.Greater(t, current, last)
+		last = current
+	}
+	sink.ExpectNoCalls(t)
+}
+
+func TestHostSequenceAtEmit(t *testing.T) {
+	var seq atomic.Uint64
+	var emitted []uint64
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, attributes map[string]any, _ int64, _ []int64) error {
+		current := attributes[attrs.LogFileHostSeq].(uint64)
+		// No number is allocated ahead of the token it is emitted with
+		assert.Equal(t, seq.Load(), current)
+		emitted = append(emitted, current)
+		assert.Len(t, tokens, 1)
+		return nil
+	})
+	f.HostSequence = func() uint64 { return seq.Add(1) }
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\ntestlog3\n")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, []uint64{1, 2, 3}, emitted)
+}
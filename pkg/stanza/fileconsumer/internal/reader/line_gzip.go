@@ -0,0 +1,74 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var errExpandedLineTooLarge = errors.New("expanded line exceeds the maximum size")
+
+// lineGzip holds the state used to expand tokens which are independently compressed. The gzip reader
+// is reset for each token, rather than allocated, since files of this kind are usually made of many small lines.
+type lineGzip struct {
+	compressed []byte
+	gzipReader *gzip.Reader
+	expanded   bytes.Buffer
+}
+
+// expandLineGzip returns the contents of a decoded token which is a base64 encoded gzip blob.
+func (r *Reader) expandLineGzip(token []byte) ([]byte, error) {
+	lg := r.lineGzip
+	n, err := base64.StdEncoding.Decode(growBytes(&lg.compressed, base64.StdEncoding.DecodedLen(len(token))), token)
+	if err != nil {
+		return nil, fmt.Errorf("decode base64: %w", err)
+	}
+	src := bytes.NewReader(lg.compressed[:n])
+	if lg.gzipReader == nil {
+		lg.gzipReader, err = gzip.NewReader(src)
+	} else {
+		err = lg.gzipReader.Reset(src)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gunzip: %w", err)
+	}
+
+	lg.expanded.Reset()
+	limit := r.maxExpandedLineSize()
+	if _, err = io.Copy(&lg.expanded, io.LimitReader(lg.gzipReader, limit+1)); err != nil {
+		return nil, fmt.Errorf("gunzip: %w", err)
+	}
+	if int64(lg.expanded.Len()) > limit {
+		return nil, fmt.Errorf("gunzip: %w of %d bytes", errExpandedLineTooLarge, limit)
+	}
+	// The buffer is reused for the next token, while this one may still be waiting in the batch
+	return append([]byte{}, lg.expanded.Bytes()...), nil
+}
+
+// maxExpandedLineSize is the most that a token may expand to, which is the max log size, or the max decompressed
+// size if it is smaller, so that a small blob cannot expand without bound.
+func (r *Reader) maxExpandedLineSize() int64 {
+	limit := int64(math.MaxInt64 - 1)
+	if r.maxLogSize > 0 {
+		limit = int64(r.maxLogSize)
+	}
+	if r.maxDecompressedSize > 0 {
+		limit = min(limit, r.maxDecompressedSize)
+	}
+	return limit
+}
+
+// growBytes returns a slice of *b of length n, reallocating it if it is too small.
+func growBytes(b *[]byte, n int) []byte {
+	if cap(*b) < n {
+		*b = make([]byte, n)
+	}
+	return (*b)[:n]
+}
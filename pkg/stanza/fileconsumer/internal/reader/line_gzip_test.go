@@ -0,0 +1,136 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func gzipLine(t *testing.T, s string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(s))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestLineGzip(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, gzipLine(t, "first record")+"\n"+gzipLine(t, "")+"\n"+gzipLine(t, "second record")+"\n")
+
+	f, sink := testFactory(t)
+	f.LineGzip = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("first record"), []byte{}, []byte("second record"))
+	sink.ExpectNoCalls(t)
+
+	// Lines appended later are expanded by the same gzip reader
+	filetest.WriteString(t, temp, gzipLine(t, "third record")+"\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("third record"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestLineGzipMalformed(t *testing.T) {
+	notGzip := base64.StdEncoding.EncodeToString([]byte("plain text"))
+	testCases := []struct {
+		name      string
+		line      string
+		action    string
+		expectLog bool
+		expectRec bool
+	}{
+		{name: "NotBase64", line: "not base64!", expectLog: true},
+		{name: "NotGzip", line: notGzip, action: DecodeErrorLog, expectLog: true},
+		{name: "Emit", line: notGzip, action: DecodeErrorEmit, expectRec: true},
+		{name: "LogAndEmit", line: "not base64!", action: DecodeErrorLogAndEmit, expectLog: true, expectRec: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			valid := gzipLine(t, "valid")
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, valid+"\n"+tc.line+"\n"+gzipLine(t, "after")+"\n")
+
+			var bodies []string
+			var attributes []map[string]any
+			f := newTestFactory(t, func(_ context.Context, tokens [][]byte, tokenAttrs map[string]any, _ int64, _ []int64) error {
+				for _, token := range tokens {
+					bodies = append(bodies, string(token))
+					attributes = append(attributes, tokenAttrs)
+				}
+				return nil
+			})
+			f.LineGzip = true
+			f.DecodeErrorAction = tc.action
+			core, logs := observer.New(zapcore.ErrorLevel)
+			f.TelemetrySettings.Logger = zap.New(core)
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			assert.Equal(t, int64(len(valid)+len(tc.line)+2+len(gzipLine(t, "after"))+1), r.Offset)
+			if tc.expectLog {
+				assert.Equal(t, 1, logs.FilterMessage("failed to decode token").Len())
+			} else {
+				assert.Zero(t, logs.Len())
+			}
+			if !tc.expectRec {
+				assert.Equal(t, []string{"valid", "after"}, bodies)
+				return
+			}
+			require.Equal(t, []string{"valid", "", "after"}, bodies)
+			assert.Equal(t, true, attributes[1][attrs.LogDecodeError])
+			assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(tc.line)), attributes[1][attrs.LogDecodeErrorBytes])
+			assert.Equal(t, int64(len(valid)+1), attributes[1][attrs.LogFileRecordOffset])
+			assert.NotContains(t, attributes[2], attrs.LogDecodeError)
+		})
+	}
+}
+
+func TestLineGzipTooLarge(t *testing.T) {
+	// The blob is small enough to be a token, but expands to more than the max log size
+	large := gzipLine(t, strings.Repeat("a", 1000))
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, large+"\n"+gzipLine(t, "after")+"\n")
+
+	f, sink := testFactory(t, withMaxLogSize(64))
+	f.LineGzip = true
+	f.DecodeErrorAction = DecodeErrorEmit
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	token, attributes := sink.NextCall(t)
+	assert.Empty(t, token)
+	assert.Contains(t, attributes[attrs.LogFileError], "expanded line exceeds the maximum size of 64 bytes")
+	sink.ExpectToken(t, []byte("after"))
+	sink.ExpectNoCalls(t)
+}
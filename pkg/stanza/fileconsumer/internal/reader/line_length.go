@@ -0,0 +1,42 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "slices"
+
+// limitLineLength applies the oversized token policy, one of the DecodedSizePolicy values, to the tokens which are
+// longer than the maximum line length. The pieces which are emitted in place of such a line are flagged with the
+// log.file.line_too_long attribute. Unlike max_log_size, which bounds the buffer that a line is read into, the
+// maximum line length only limits which lines are accepted, so a line which is too long is still read in full
+// before the policy is applied.
+func (r *Reader) limitLineLength(tokens [][]byte) [][]byte {
+	r.tooLongPieces = r.tooLongPieces[:0]
+	if r.maxLineLength <= 0 || !slices.ContainsFunc(tokens, r.lineTooLong) {
+		return tokens
+	}
+	kept := make([][]byte, 0, len(tokens))
+	for _, token := range tokens {
+		if !r.lineTooLong(token) {
+			kept = append(kept, token)
+			continue
+		}
+		n := len(kept)
+		kept = r.limitSize(kept, token, r.maxLineLength, r.lineLengthPolicy, "maximum line length")
+		r.tooLongPieces = append(r.tooLongPieces, kept[n:]...)
+	}
+	return kept
+}
+
+func (r *Reader) lineTooLong(token []byte) bool {
+	return len(token) > r.maxLineLength
+}
+
+// isTooLongPiece returns true if the token is one of the pieces emitted in place of a line which was too long.
+// The other pieces of the same scanned token, such as those split from it at the maximum decoded size, are not.
+func (r *Reader) isTooLongPiece(token []byte) bool {
+	return slices.ContainsFunc(r.tooLongPieces, func(piece []byte) bool {
+		// The pieces are compared by identity, since another piece may have the same content
+		return len(piece) == len(token) && len(piece) > 0 && &piece[0] == &token[0]
+	})
+}
@@ -0,0 +1,80 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestMaxLineLength(t *testing.T) {
+	// The long line is within max_log_size, so it is read as a single token
+	long := strings.Repeat("a", 30)
+	testCases := []struct {
+		name    string
+		policy  string
+		expect  []string
+		tooLong []string
+	}{
+		{name: "DefaultTruncate", expect: []string{"short", long[:20], "after"}, tooLong: []string{long[:20]}},
+		{name: "Truncate", policy: DecodedSizePolicyTruncate, expect: []string{"short", long[:20], "after"}, tooLong: []string{long[:20]}},
+		{name: "Drop", policy: DecodedSizePolicyDrop, expect: []string{"short", "after"}},
+		{name: "Split", policy: DecodedSizePolicySplit, expect: []string{"short", long[:20], long[20:], "after"}, tooLong: []string{long[:20], long[20:]}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, sink := testFactory(t, withMaxLogSize(100))
+			f.MaxLineLength = 20
+			f.LineLengthPolicy = tc.policy
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, "short\n"+long+"\nafter\n")
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			fileName := filepath.Base(temp.Name())
+			for _, token := range tc.expect {
+				expectAttrs := map[string]any{attrs.LogFileName: fileName}
+				if slices.Contains(tc.tooLong, token) {
+					expectAttrs[attrs.LogFileLineTooLong] = true
+				}
+				sink.ExpectCall(t, []byte(token), expectAttrs)
+			}
+			sink.ExpectNoCalls(t)
+			require.Equal(t, int64(len("short\n"+long+"\nafter\n")), r.Offset)
+		})
+	}
+}
+
+func TestMaxLineLengthPieces(t *testing.T) {
+	f, sink := testFactory(t, withMaxLogSize(100))
+	f.MaxDecodedSize = 10
+	f.DecodedSizePolicy = DecodedSizePolicySplit
+	f.MaxLineLength = 8
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "aaaaaaaaaabbb\n")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// Only the piece which exceeded the maximum line length is flagged
+	r.ReadToEnd(context.Background())
+	fileName := filepath.Base(temp.Name())
+	sink.ExpectCall(t, []byte("aaaaaaaa"), map[string]any{attrs.LogFileName: fileName, attrs.LogFileLineTooLong: true})
+	sink.ExpectCall(t, []byte("bbb"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectNoCalls(t)
+}
@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// lookbackChunkSize is how much of the file is read at a time while searching backwards for the lookback offset
+var lookbackChunkSize int64 = 64 * 1024
+
+// lookbackOffset searches backwards from the end of the file for the newest line with a timestamp before the
+// cutoff, and returns the offset of the line after it, so that only recent lines are read. If every line with a
+// timestamp is recent, the file is read from the start. If no line has a timestamp, or the file cannot be read,
+// the end of the file is returned. An incomplete line at the end of the file is not considered.
+func (r *Reader) lookbackOffset(size int64, cutoff time.Time) int64 {
+	// data holds the content of the file from pos up to the end of the newest line which has not been examined
+	pos := size
+	var data []byte
+	readMore := func() bool {
+		n := min(lookbackChunkSize, pos)
+		chunk := make([]byte, n, n+int64(len(data)))
+		if _, err := r.file.ReadAt(chunk, pos-n); err != nil && !errors.Is(err, io.EOF) {
+			r.set.Logger.Error("failed to read file for lookback", zap.Error(err))
+			return false
+		}
+		pos -= n
+		data = append(chunk, data...)
+		return true
+	}
+
+	// Skip the incomplete line at the end of the file, if there is one
+	for bytes.IndexByte(data, '\n') < 0 {
+		if pos == 0 || !readMore() {
+			return size
+		}
+	}
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+
+	found := false
+	for len(data) > 0 {
+		// The line ends with the last byte of data, and starts after the previous newline or at the start of the file
+		i := bytes.LastIndexByte(data[:len(data)-1], '\n')
+		if i < 0 && pos > 0 {
+			if !readMore() {
+				return size
+			}
+			continue
+		}
+		line := bytes.TrimSuffix(data[i+1:len(data)-1], []byte("\r"))
+		if ts, ok := r.timestampParser(line); ok {
+			if ts.Before(cutoff) {
+				return pos + int64(len(data))
+			}
+			found = true
+		}
+		data = data[:i+1]
+	}
+	if !found {
+		return size
+	}
+	return 0
+}
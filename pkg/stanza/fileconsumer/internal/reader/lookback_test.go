@@ -0,0 +1,114 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+// parseLeadingTimestamp parses an RFC3339 timestamp at the start of a token.
+func parseLeadingTimestamp(token []byte) (time.Time, bool) {
+	field, _, _ := bytes.Cut(token, []byte(" "))
+	ts, err := time.Parse(time.RFC3339, string(field))
+	return ts, err == nil
+}
+
+func TestStartAtEndLookback(t *testing.T) {
+	now := time.Now()
+	line := func(age time.Duration, msg string) string {
+		return fmt.Sprintf("%s %s", now.Add(-age).UTC().Format(time.RFC3339), msg)
+	}
+	old, older := line(3*time.Hour, "old"), line(2*time.Hour, "older")
+	recent, newest := line(30*time.Minute, "recent"), line(time.Minute, "newest")
+
+	testCases := []struct {
+		name     string
+		lines    []string
+		expected []string
+	}{
+		{name: "RecentLines", lines: []string{old, older, recent, newest}, expected: []string{recent, newest}},
+		{name: "UnparseableLinesAreKept", lines: []string{older, "continuation", newest}, expected: []string{"continuation", newest}},
+		{name: "LookbackExceedsFile", lines: []string{recent, "no timestamp", newest}, expected: []string{recent, "no timestamp", newest}},
+		{name: "NoTimestamps", lines: []string{"first", "second"}},
+		{name: "NothingRecent", lines: []string{old, older}},
+	}
+	for _, tc := range testCases {
+		for _, chunkSize := range []int64{7, 64 * 1024} {
+			t.Run(fmt.Sprintf("%s/chunk%d", tc.name, chunkSize), func(t *testing.T) {
+				defer func(size int64) { lookbackChunkSize = size }(lookbackChunkSize)
+				lookbackChunkSize = chunkSize
+
+				temp := filetest.OpenTemp(t, t.TempDir())
+				filetest.WriteString(t, temp, strings.Join(tc.lines, "\n")+"\n")
+
+				f, sink := testFactory(t)
+				f.FromBeginning = false
+				f.StartAtEndLookback = time.Hour
+				f.TimestampParser = parseLeadingTimestamp
+				fp, err := f.NewFingerprint(temp)
+				require.NoError(t, err)
+				r, err := f.NewReader(temp, fp)
+				require.NoError(t, err)
+				defer r.Close()
+
+				r.ReadToEnd(context.Background())
+				for _, expected := range tc.expected {
+					sink.ExpectToken(t, []byte(expected))
+				}
+				sink.ExpectNoCalls(t)
+			})
+		}
+	}
+}
+
+func TestStartAtEndLookbackIncompleteLine(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Minute).UTC().Format(time.RFC3339) + " recent"
+	partial := now.Add(-3*time.Hour).UTC().Format(time.RFC3339) + " partial"
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, recent+"\n"+partial)
+
+	f, sink := testFactory(t)
+	f.FromBeginning = false
+	f.StartAtEndLookback = time.Hour
+	f.TimestampParser = parseLeadingTimestamp
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The old timestamp of the line which is still being written does not stop the lookback
+	filetest.WriteString(t, temp, "\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte(recent), []byte(partial))
+	sink.ExpectNoCalls(t)
+}
+
+func TestStartAtEndLookbackRequiresParser(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, time.Now().UTC().Format(time.RFC3339)+" recent\n")
+
+	f, sink := testFactory(t)
+	f.FromBeginning = false
+	f.StartAtEndLookback = time.Hour
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+}
@@ -0,0 +1,24 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "time"
+
+// holdBatch returns true if the batch of tokens found at the end of the file is too small to be emitted yet.
+// Held tokens are not consumed, so they are read again, along with any tokens written since, on the next poll.
+// Once tokens have been held for the minimum batch timeout, they are emitted regardless of how many there are.
+func (r *Reader) holdBatch(numTokens int) bool {
+	if numTokens == 0 || numTokens >= r.minBatchSize {
+		r.batchHeldSince = time.Time{}
+		return false
+	}
+	if r.batchHeldSince.IsZero() {
+		r.batchHeldSince = time.Now()
+	}
+	if time.Since(r.batchHeldSince) >= r.minBatchTimeout {
+		r.batchHeldSince = time.Time{}
+		return false
+	}
+	return true
+}
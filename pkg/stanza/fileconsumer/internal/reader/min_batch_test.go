@@ -0,0 +1,82 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestMinBatchSize(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+
+	var calls [][]string
+	var recordNums []int64
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, lastRecordNumber int64, _ []int64) error {
+		call := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			call = append(call, string(token))
+		}
+		calls = append(calls, call)
+		recordNums = append(recordNums, lastRecordNumber)
+		return nil
+	})
+	f.MinBatchSize = 3
+	f.MinBatchTimeout = 200 * time.Millisecond
+
+	filetest.WriteString(t, temp, "one\n")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	// A trickle of tokens is held
+	r.ReadToEnd(context.Background())
+	filetest.WriteString(t, temp, "two\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	require.Empty(t, calls)
+	assert.Zero(t, r.Offset)
+	assert.Zero(t, r.RecordNum)
+
+	// Until the timeout elapses, at which point they are emitted together
+	time.Sleep(f.MinBatchTimeout)
+	r.ReadToEnd(context.Background())
+	require.Equal(t, [][]string{{"one", "two"}}, calls)
+	assert.Equal(t, []int64{2}, recordNums)
+	assert.Equal(t, int64(len("one\ntwo\n")), r.Offset)
+
+	// Tokens are emitted without waiting once there are enough of them
+	filetest.WriteString(t, temp, "three\n")
+	r.ReadToEnd(context.Background())
+	filetest.WriteString(t, temp, "four\nfive\n")
+	r.ReadToEnd(context.Background())
+	require.Equal(t, [][]string{{"one", "two"}, {"three", "four", "five"}}, calls)
+	assert.Equal(t, []int64{2, 5}, recordNums)
+
+	r.Close()
+}
+
+func TestHoldBatch(t *testing.T) {
+	r := &Reader{Metadata: &Metadata{}, minBatchSize: 2, minBatchTimeout: time.Hour}
+	assert.False(t, r.holdBatch(0))
+	assert.True(t, r.holdBatch(1))
+	heldSince := r.batchHeldSince
+	assert.True(t, r.holdBatch(1))
+	assert.Equal(t, heldSince, r.batchHeldSince, "the timeout starts when tokens are first held")
+	assert.False(t, r.holdBatch(2))
+	assert.True(t, r.batchHeldSince.IsZero())
+
+	// The option is disabled by default
+	r = &Reader{Metadata: &Metadata{}}
+	assert.False(t, r.holdBatch(1))
+}
@@ -0,0 +1,153 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"go.uber.org/multierr"
+)
+
+// gzipPartPattern matches the parts of a gzip stream which has been split across
+// multiple files, e.g. "app.log.gz.001", "app.log.gz.002".
+var gzipPartPattern = regexp.MustCompile(`^(.*\.gz)\.(\d+)$`)
+
+var errNotFirstPart = errors.New("not the first part of a multipart file")
+
+// multipartFile presents the parts of a split file as a single contiguous io.ReaderAt.
+type multipartFile struct {
+	files   []*os.File
+	offsets []int64 // offset at which each part starts
+	sizes   []int64
+	size    int64
+}
+
+// openParts opens all parts of the multipart file which the reader's file belongs to, in numeric order.
+// The reader's file must be the first part, otherwise errNotFirstPart is returned.
+func (r *Reader) openParts() (*multipartFile, error) {
+	names, err := findParts(r.partPrefix)
+	if err != nil {
+		return nil, err
+	}
+	// The names of the parts are cleaned, while the name of the file is as it was matched
+	fileName := filepath.Clean(r.fileName)
+	if len(names) == 0 || names[0] != fileName {
+		return nil, errNotFirstPart
+	}
+
+	m := &multipartFile{}
+	for _, name := range names {
+		var file *os.File
+		if name == fileName {
+			file = r.file
+		} else if file, err = os.Open(name); err != nil { // #nosec - operator must read in files defined by user
+			return nil, multierr.Append(err, m.Close(r.file))
+		}
+		info, err := file.Stat()
+		if err != nil {
+			return nil, multierr.Append(fmt.Errorf("stat: %w", err), m.Close(r.file))
+		}
+		m.files = append(m.files, file)
+		m.offsets = append(m.offsets, m.size)
+		m.sizes = append(m.sizes, info.Size())
+		m.size += info.Size()
+	}
+	return m, nil
+}
+
+// findParts returns the cleaned names of all parts that share the given prefix, ordered by part number.
+func findParts(prefix string) ([]string, error) {
+	prefix = filepath.Clean(prefix)
+	dir := filepath.Dir(prefix)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type part struct {
+		name   string
+		number uint64
+	}
+	parts := make([]part, 0, len(entries))
+	for _, entry := range entries {
+		name := filepath.Join(dir, entry.Name())
+		matches := gzipPartPattern.FindStringSubmatch(name)
+		if matches == nil || matches[1] != prefix {
+			continue
+		}
+		number, err := strconv.ParseUint(matches[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, part{name: name, number: number})
+	}
+	slices.SortFunc(parts, func(a, b part) int {
+		switch {
+		case a.number < b.number:
+			return -1
+		case a.number > b.number:
+			return 1
+		}
+		return 0
+	})
+
+	names := make([]string, 0, len(parts))
+	for _, p := range parts {
+		names = append(names, p.name)
+	}
+	return names, nil
+}
+
+func (m *multipartFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= m.size {
+		return 0, io.EOF
+	}
+
+	// Find the last part which starts at or before the offset
+	i, found := slices.BinarySearch(m.offsets, off)
+	if !found {
+		i--
+	}
+
+	var n int
+	for ; n < len(p) && i < len(m.files); i++ {
+		partOffset := off + int64(n) - m.offsets[i]
+		buf := p[n:]
+		if remaining := m.sizes[i] - partOffset; int64(len(buf)) > remaining {
+			buf = buf[:remaining]
+		}
+		read, err := m.files[i].ReadAt(buf, partOffset)
+		n += read
+		if err != nil && !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		if read < len(buf) {
+			// The part was truncated since it was opened
+			return n, io.EOF
+		}
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close closes all parts except for keep, which is owned by the reader.
+func (m *multipartFile) Close(keep *os.File) error {
+	var errs error
+	for _, file := range m.files {
+		if file != keep {
+			errs = multierr.Append(errs, file.Close())
+		}
+	}
+	m.files = nil
+	return errs
+}
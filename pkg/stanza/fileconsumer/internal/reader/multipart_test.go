@@ -0,0 +1,128 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestMultipartGzip(t *testing.T) {
+	tempDir := t.TempDir()
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	expected := make([][]byte, 0, 100)
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("this is line number %d", i)
+		expected = append(expected, []byte(line))
+		_, err := gzipWriter.Write([]byte(line + "\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, gzipWriter.Close())
+
+	// Split the compressed stream into two parts
+	compressed := buf.Bytes()
+	first := filepath.Join(tempDir, "app.log.gz.001")
+	second := filepath.Join(tempDir, "app.log.gz.002")
+	require.NoError(t, os.WriteFile(first, compressed[:len(compressed)/2], 0o600))
+	require.NoError(t, os.WriteFile(second, compressed[len(compressed)/2:], 0o600))
+
+	f, sink := testFactory(t, withSinkChanSize(len(expected)))
+	f.Compression = "gzip"
+	f.MultipartGzip = true
+
+	// The second part is read as part of the first, so it is skipped on its own
+	secondFile := filetest.OpenFile(t, second)
+	fp, err := f.NewFingerprint(secondFile)
+	require.NoError(t, err)
+	r, err := f.NewReader(secondFile, fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	r.Close()
+
+	firstFile := filetest.OpenFile(t, first)
+	fp, err = f.NewFingerprint(firstFile)
+	require.NoError(t, err)
+	r, err = f.NewReader(firstFile, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, expected...)
+	sink.ExpectNoCalls(t)
+
+	// Offset tracking spans both parts
+	require.Equal(t, int64(len(compressed)), r.Offset)
+}
+
+func TestMultipartFileReadAt(t *testing.T) {
+	tempDir := t.TempDir()
+	prefix := filepath.Join(tempDir, "data.gz")
+	content := []byte("0123456789abcdefghij")
+	require.NoError(t, os.WriteFile(prefix+".10", content[15:], 0o600))
+	require.NoError(t, os.WriteFile(prefix+".1", content[:7], 0o600))
+	require.NoError(t, os.WriteFile(prefix+".2", content[7:15], 0o600))
+	require.NoError(t, os.WriteFile(prefix+".txt", []byte("not a part"), 0o600))
+
+	names, err := findParts(prefix)
+	require.NoError(t, err)
+	require.Equal(t, []string{prefix + ".1", prefix + ".2", prefix + ".10"}, names)
+
+	r := &Reader{fileName: prefix + ".1", file: filetest.OpenFile(t, prefix+".1"), partPrefix: prefix}
+	m, err := r.openParts()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Close(r.file))
+	}()
+	require.Equal(t, int64(len(content)), m.size)
+
+	for off := 0; off < len(content); off++ {
+		for n := 1; off+n <= len(content); n++ {
+			buf := make([]byte, n)
+			read, err := m.ReadAt(buf, int64(off))
+			require.NoError(t, err)
+			require.Equal(t, n, read)
+			require.Equal(t, content[off:off+n], buf)
+		}
+	}
+
+	buf := make([]byte, 10)
+	read, err := m.ReadAt(buf, 15)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, content[15:], buf[:read])
+}
+
+func TestMultipartUncleanPath(t *testing.T) {
+	tempDir := t.TempDir()
+	prefix := filepath.Join(tempDir, "data.gz")
+	require.NoError(t, os.WriteFile(prefix+".1", []byte("first"), 0o600))
+	require.NoError(t, os.WriteFile(prefix+".2", []byte("second"), 0o600))
+
+	// The file was matched by a path which is not clean
+	unclean := tempDir + string(filepath.Separator) + "." + string(filepath.Separator) + "data.gz"
+	names, err := findParts(unclean)
+	require.NoError(t, err)
+	require.Equal(t, []string{prefix + ".1", prefix + ".2"}, names)
+
+	r := &Reader{fileName: unclean + ".1", file: filetest.OpenFile(t, prefix+".1"), partPrefix: unclean}
+	m, err := r.openParts()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, m.Close(r.file))
+	}()
+	require.Equal(t, r.file, m.files[0])
+	require.Equal(t, int64(len("firstsecond")), m.size)
+}
@@ -0,0 +1,32 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// Open opens a file for reading with the factory's additional open flags. The flags are platform specific
+// (e.g. syscall.O_NOATIME on Linux), so if the file cannot be opened with them it is opened without them.
+// Symlinks are not opened at all with SymlinkModeSkip.
+func (f *Factory) Open(path string) (*os.File, error) {
+	if f.SymlinkMode == SymlinkModeSkip {
+		if symlink, err := isSymlink(path); err == nil && symlink {
+			return nil, ErrSymlinkSkipped
+		}
+	}
+
+	if f.OpenFlags == 0 {
+		return os.Open(path) // #nosec - operator must read in files defined by user
+	}
+
+	file, err := os.OpenFile(path, os.O_RDONLY|f.OpenFlags, 0) // #nosec - operator must read in files defined by user
+	if err == nil {
+		return file, nil
+	}
+	f.TelemetrySettings.Logger.Debug("failed to open file with open flags, opening without them", zap.String("path", path), zap.Error(err))
+	return os.Open(path) // #nosec - operator must read in files defined by user
+}
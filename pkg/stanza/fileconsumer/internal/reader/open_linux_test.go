@@ -0,0 +1,50 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package reader
+
+import (
+	"context"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestOpenWithFlags(t *testing.T) {
+	testCases := []struct {
+		name  string
+		flags int
+	}{
+		{"NoFlags", 0},
+		{"NoAtime", syscall.O_NOATIME},
+		// Opening a regular file as a directory fails, so the file is opened without the flag
+		{"Fallback", syscall.O_DIRECTORY},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+			filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+			f, sink := testFactory(t)
+			f.OpenFlags = tc.flags
+			file, err := f.Open(temp.Name())
+			require.NoError(t, err)
+
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err := f.NewReader(file, fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"slices"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// overlapRing retains the hashes of the most recently emitted tokens of a file. When the file is reset to its
+// start, as it is when it was truncated by a copytruncate rotation, the tokens at the start of the new content
+// which were already emitted from the end of the old content are suppressed.
+type overlapRing struct {
+	hashes []uint64
+	next   int
+	full   bool
+	// seam holds the retained hashes from when the file was reset, until a token which is not among them is read
+	seam map[uint64]struct{}
+	// pending holds the hashes of the tokens which were kept, until they are emitted
+	pending []uint64
+}
+
+func newOverlapRing(size int) *overlapRing {
+	return &overlapRing{hashes: make([]uint64, size)}
+}
+
+// clone copies the retained hashes, but not the tokens which are pending or the seam.
+func (o *overlapRing) clone() *overlapRing {
+	return &overlapRing{hashes: slices.Clone(o.hashes), next: o.next, full: o.full}
+}
+
+// arm starts suppressing the tokens which match those emitted before the reset.
+func (o *overlapRing) arm() {
+	count := o.next
+	if o.full {
+		count = len(o.hashes)
+	}
+	if count == 0 {
+		return
+	}
+	o.seam = make(map[uint64]struct{}, count)
+	for _, h := range o.hashes[:count] {
+		o.seam[h] = struct{}{}
+	}
+}
+
+// keep returns false if the token should be suppressed as a duplicate from across the seam. Otherwise the token
+// is retained once it is emitted.
+func (o *overlapRing) keep(token []byte) bool {
+	h := xxhash.Sum64(token)
+	if o.seam != nil {
+		if _, ok := o.seam[h]; ok {
+			return false
+		}
+		o.seam = nil
+	}
+	o.pending = append(o.pending, h)
+	return true
+}
+
+// record retains the hashes of the tokens which were kept and have since been emitted.
+func (o *overlapRing) record() {
+	for _, h := range o.pending {
+		o.hashes[o.next] = h
+		o.next++
+		if o.next == len(o.hashes) {
+			o.next = 0
+			o.full = true
+		}
+	}
+	o.pending = o.pending[:0]
+}
+
+// dropRotationOverlap removes the tokens which were already emitted before the file was reset to its start.
+func (r *Reader) dropRotationOverlap(tokens [][]byte) [][]byte {
+	if r.rotationOverlap == nil {
+		return tokens
+	}
+	kept := tokens[:0]
+	for _, token := range tokens {
+		if r.rotationOverlap.keep(token) {
+			kept = append(kept, token)
+		}
+	}
+	return kept
+}
+
+// recordRotationOverlap retains the tokens which were kept by dropRotationOverlap, once the batches they were
+// read in have been emitted.
+func (r *Reader) recordRotationOverlap() {
+	if r.rotationOverlap != nil {
+		r.rotationOverlap.record()
+	}
+}
+
+// ContinueRotationOverlap suppresses the tokens at the start of the file which were recently emitted by the reader
+// of its previous content. A file which was truncated by a copytruncate rotation is read by a new reader, which
+// otherwise does not know what was emitted before the file was reset.
+func (r *Reader) ContinueRotationOverlap(prev *Reader) {
+	if r.rotationOverlap == nil || prev.rotationOverlap == nil || len(prev.rotationOverlap.hashes) != len(r.rotationOverlap.hashes) {
+		return
+	}
+	r.rotationOverlap = prev.rotationOverlap.clone()
+	r.rotationOverlap.arm()
+}
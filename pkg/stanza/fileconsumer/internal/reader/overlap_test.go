@@ -0,0 +1,131 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestRotationOverlap(t *testing.T) {
+	testCases := []struct {
+		name         string
+		overlapSize  int
+		newContent   string
+		expectTokens []string
+	}{
+		{
+			name:         "suppressed",
+			overlapSize:  3,
+			newContent:   "testlog2\ntestlog3\ntestlog4\n",
+			expectTokens: []string{"testlog4"},
+		},
+		{
+			name:         "disabled",
+			newContent:   "testlog2\ntestlog3\ntestlog4\n",
+			expectTokens: []string{"testlog2", "testlog3", "testlog4"},
+		},
+		{
+			name:         "beyond_seam",
+			overlapSize:  3,
+			newContent:   "testlog3\ntestlog4\ntestlog3\n",
+			expectTokens: []string{"testlog4", "testlog3"},
+		},
+		{
+			name:         "no_overlap",
+			overlapSize:  3,
+			newContent:   "testlog4\ntestlog3\n",
+			expectTokens: []string{"testlog4", "testlog3"},
+		},
+		{
+			name:         "older_than_retained",
+			overlapSize:  1,
+			newContent:   "testlog2\ntestlog3\ntestlog4\n",
+			expectTokens: []string{"testlog2", "testlog3", "testlog4"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, "testlog1\ntestlog2\ntestlog3\n")
+
+			f, sink := testFactory(t)
+			f.InPlaceEditPolicy = InPlaceEditRestart
+			f.RotationOverlapSize = tc.overlapSize
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"), []byte("testlog3"))
+
+			// A copytruncate rotation truncates the file, and some of the last lines are written to it again
+			require.NoError(t, temp.Truncate(0))
+			_, err = temp.Seek(0, 0)
+			require.NoError(t, err)
+			filetest.WriteString(t, temp, tc.newContent)
+
+			r.ReadToEnd(context.Background())
+			for _, token := range tc.expectTokens {
+				sink.ExpectToken(t, []byte(token))
+			}
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
+
+func TestRotationOverlapNotEmitted(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	failed := false
+	emitted := make(chan string, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	f := newTestFactory(t, func(ctx context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		if !failed {
+			failed = true
+			cancel()
+			return ctx.Err()
+		}
+		for _, token := range tokens {
+			emitted <- string(token)
+		}
+		return nil
+	})
+	f.InPlaceEditPolicy = InPlaceEditRestart
+	f.RotationOverlapSize = 3
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The tokens are read, but not emitted
+	r.ReadToEnd(ctx)
+	require.Zero(t, r.Offset)
+	require.Empty(t, emitted)
+
+	// The file is reset before they are read again, so they are not suppressed
+	require.NoError(t, temp.Truncate(0))
+	_, err = temp.Seek(0, 0)
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "testlog2\ntestlog3\n")
+	r.ReadToEnd(context.Background())
+	for _, token := range []string{"testlog2", "testlog3"} {
+		select {
+		case got := <-emitted:
+			require.Equal(t, token, got)
+		case <-time.After(time.Second):
+			require.FailNow(t, "token was not emitted", token)
+		}
+	}
+	require.Empty(t, emitted)
+}
@@ -0,0 +1,33 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bufio"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/flush"
+)
+
+// chunkPartialTokens wraps a bufio.SplitFunc so that an incomplete token which has reached chunkSize bytes is
+// returned in chunks instead of being held until it is complete. Chunks are flagged with the partial attribute,
+// and the token which completes them is not, so the original token is the concatenation of consecutive partial
+// tokens and the token which follows them. Chunks are cut at a byte count, which may fall within a multi-byte
+// character, and should not be trimmed if they are to be reassembled.
+func (r *Reader) chunkPartialTokens(splitFunc bufio.SplitFunc, chunkSize int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		r.partialToken = false
+		advance, token, err := splitFunc(data, atEOF)
+		if advance != 0 || token != nil || err != nil || len(data) < chunkSize {
+			return advance, token, err
+		}
+
+		// The rest of the token is still to come, so it should neither be flushed early
+		// nor need a buffer sized for what has been returned already.
+		r.partialToken = true
+		r.FlushState = flush.State{LastDataChange: time.Now()}
+		r.TokenLenState.MinimumLength = 0
+		return chunkSize, data[:chunkSize], nil
+	}
+}
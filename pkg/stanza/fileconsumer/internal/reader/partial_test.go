@@ -0,0 +1,94 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/trim"
+)
+
+func TestPartialChunks(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "short line\n")
+
+	type token struct {
+		body    string
+		partial bool
+	}
+	var tokens []token
+	f := newTestFactory(t, func(_ context.Context, bodies [][]byte, attributes map[string]any, _ int64, _ []int64) error {
+		for _, body := range bodies {
+			tokens = append(tokens, token{string(body), attributes[attrs.LogFilePartial] == true})
+		}
+		return nil
+	})
+	f.PartialChunkSize = 16
+	f.InitialBufferSize = 8
+	f.FlushTimeout = time.Hour
+	f.TrimFunc = trim.Nop
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []token{{"short line", false}}, tokens)
+
+	// A long line arrives a few bytes at a time, across many polls
+	long := strings.Repeat("0123456789", 5)
+	for i := 0; i < len(long); i += 3 {
+		filetest.WriteString(t, temp, long[i:min(i+3, len(long))])
+		r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+		require.NoError(t, err)
+		r.ReadToEnd(context.Background())
+	}
+	filetest.WriteString(t, temp, "\nnext line\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+
+	require.Equal(t, []token{
+		{"short line", false},
+		{long[0:16], true},
+		{long[16:32], true},
+		{long[32:48], true},
+		{long[48:], false},
+		{"next line", false},
+	}, tokens)
+
+	var reassembled strings.Builder
+	for _, tok := range tokens[1:5] {
+		reassembled.WriteString(tok.body)
+	}
+	assert.Equal(t, long, reassembled.String())
+	assert.Equal(t, int64(len("short line\n")+len(long)+len("\nnext line\n")), r.Offset)
+	assert.Zero(t, r.TokenLenState.MinimumLength)
+}
+
+func TestPartialChunksDisabled(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, strings.Repeat("x", 100))
+
+	f, sink := testFactory(t, withFlushPeriod(time.Hour))
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+}
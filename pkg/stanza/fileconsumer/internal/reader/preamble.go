@@ -0,0 +1,49 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"go.uber.org/zap"
+)
+
+const defaultMaxPreambleSize = 64 * 1024
+
+// skipPreamble looks for the content start marker near the beginning of the file and moves the offset past it.
+// It returns false if the marker may still be written, in which case the file should not be read yet.
+func (r *Reader) skipPreamble() bool {
+	if r.Offset > 0 {
+		// Reading started from somewhere other than the start of the file
+		r.PreambleSkipped = true
+		return true
+	}
+
+	maxPreambleSize := r.maxPreambleSize
+	if maxPreambleSize <= 0 {
+		maxPreambleSize = defaultMaxPreambleSize
+	}
+	buf := make([]byte, maxPreambleSize+len(r.contentStartMarker))
+	n, err := r.file.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.set.Logger.Error("failed to read preamble", zap.Error(err))
+		return false
+	}
+
+	if i := bytes.Index(buf[:n], r.contentStartMarker); i >= 0 {
+		r.Offset = int64(i + len(r.contentStartMarker))
+		r.PreambleSkipped = true
+		return true
+	}
+	if n < len(buf) {
+		// The end of the preamble has not been written yet
+		return false
+	}
+
+	r.set.Logger.Warn("content start marker not found, reading from the start of the file", zap.Int("max_preamble_size", maxPreambleSize))
+	r.PreambleSkipped = true
+	return true
+}
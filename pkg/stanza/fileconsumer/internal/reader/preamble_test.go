@@ -0,0 +1,95 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestContentStartMarker(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	preamble := "\x01\x02 binary preamble\nwith a newline\x00\x00DA"
+	filetest.WriteString(t, temp, preamble)
+
+	f, sink := testFactory(t)
+	f.ContentStartMarker = []byte("\x00\x00DATA")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	// The marker has not been fully written yet
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	assert.False(t, r.PreambleSkipped)
+	assert.Zero(t, r.Offset)
+
+	filetest.WriteString(t, temp, "TAfirst\nsecond\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("first"), []byte("second"))
+	sink.ExpectNoCalls(t)
+	assert.True(t, r.PreambleSkipped)
+
+	// Content which follows is not scanned for the marker again
+	filetest.WriteString(t, temp, "\x00\x00DATA third\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("\x00\x00DATA third"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestContentStartMarkerNotFound(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "no marker here\n")
+
+	f, sink := testFactory(t)
+	f.ContentStartMarker = []byte("DATA:")
+	f.MaxPreambleSize = 20
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The marker could still be written within the bound
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+
+	// Once the bound is exceeded the file is read from the start
+	filetest.WriteString(t, temp, strings.Repeat("x", 10)+"\nDATA: too late\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("no marker here"), []byte(strings.Repeat("x", 10)), []byte("DATA: too late"))
+	sink.ExpectNoCalls(t)
+	assert.True(t, r.PreambleSkipped)
+}
+
+func TestContentStartMarkerFromEnd(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "preamble DATA: old\n")
+
+	f, sink := testFactory(t, fromEnd())
+	f.ContentStartMarker = []byte("DATA:")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	filetest.WriteString(t, temp, "new\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("new"))
+	sink.ExpectNoCalls(t)
+}
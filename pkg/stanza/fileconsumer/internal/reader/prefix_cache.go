@@ -0,0 +1,165 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// prefixCacheRacyWindow is the coarsest granularity of modification times which is expected of a filesystem.
+const prefixCacheRacyWindow = 2 * time.Second
+
+// PrefixCache holds the first bytes of files so that readers sharing it do not read them from disk repeatedly
+// when fingerprinting or validating a file, or when they start reading its content. Files are identified by device
+// and inode, and a cached prefix is discarded when the size or modification time of the file changes.
+//
+// A file which is rewritten with the same size shortly after it was modified may keep its modification time,
+// which only has the granularity of the filesystem. A prefix which was cached within that granularity of the
+// modification time is therefore checked against the content of the file before it is served.
+type PrefixCache struct {
+	mu         sync.Mutex
+	entries    map[fileKey]prefixEntry
+	maxEntries int
+}
+
+type fileKey struct {
+	dev uint64
+	ino uint64
+}
+
+type prefixEntry struct {
+	size     int64
+	modTime  time.Time
+	cachedAt time.Time
+	data     []byte
+}
+
+// racy returns true if the file may have been modified since the prefix was cached without its
+// modification time changing.
+func (e prefixEntry) racy() bool {
+	return !e.modTime.Before(e.cachedAt.Add(-prefixCacheRacyWindow))
+}
+
+// prefixFile is the part of *os.File used to read a prefix.
+type prefixFile interface {
+	io.ReaderAt
+	Stat() (os.FileInfo, error)
+}
+
+// NewPrefixCache creates a cache which holds the prefixes of up to maxEntries files.
+// Once it is full, an arbitrary entry is evicted for each new file.
+func NewPrefixCache(maxEntries int) *PrefixCache {
+	return &PrefixCache{
+		entries:    make(map[fileKey]prefixEntry, maxEntries),
+		maxEntries: maxEntries,
+	}
+}
+
+// readPrefix returns up to size bytes from the start of the file. The returned slice must not be modified.
+func (c *PrefixCache) readPrefix(file prefixFile, size int) ([]byte, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	key, ok := fileKeyOf(info)
+	if !ok {
+		return readAtStart(file, size)
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	hit := ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) &&
+		(len(entry.data) >= size || int64(len(entry.data)) == entry.size)
+	if hit && !entry.racy() {
+		return entry.data[:min(size, len(entry.data))], nil
+	}
+
+	readSize := size
+	if hit {
+		readSize = max(size, len(entry.data))
+	}
+	cachedAt := time.Now()
+	data, err := readAtStart(file, readSize)
+	if err != nil {
+		return nil, err
+	}
+	// The content was checked, so the prefix which was already cached remains valid
+	if hit && bytes.Equal(data, entry.data) {
+		data = entry.data
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok = c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = prefixEntry{size: info.Size(), modTime: info.ModTime(), cachedAt: cachedAt, data: data}
+	return data[:min(size, len(data))], nil
+}
+
+func readAtStart(file io.ReaderAt, size int) ([]byte, error) {
+	buf := make([]byte, size)
+	n, err := file.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("reading prefix: %w", err)
+	}
+	return buf[:n], nil
+}
+
+// prefixReader serves a cached prefix of the file before reading the rest of it from the file.
+type prefixReader struct {
+	prefix []byte
+	file   *os.File
+}
+
+func (p *prefixReader) Read(dst []byte) (int, error) {
+	if len(p.prefix) == 0 {
+		return p.file.Read(dst)
+	}
+	n := copy(dst, p.prefix)
+	p.prefix = p.prefix[n:]
+	return n, nil
+}
+
+// readingFile returns true if the content is read from the file itself, rather than through a decompressor.
+func (r *Reader) readingFile() bool {
+	switch r.reader.(type) {
+	case *prefixReader, *directReader:
+		return true
+	}
+	return r.reader == io.Reader(r.file)
+}
+
+// seekToOffset positions the file for reading from the current offset. When the offset is within
+// the cached prefix of the file, the prefix is served from memory.
+func (r *Reader) seekToOffset() error {
+	r.discardChunks()
+	if direct, ok := r.reader.(*directReader); ok {
+		direct.reset(r.Offset)
+		return nil
+	}
+	if r.prefixCache != nil && r.readingFile() {
+		prefix, err := r.prefixCache.readPrefix(r.file, r.fingerprintSize)
+		if err == nil && r.Offset < int64(len(prefix)) {
+			if _, err = r.file.Seek(int64(len(prefix)), 0); err != nil {
+				return err
+			}
+			r.reader = &prefixReader{prefix: prefix[r.Offset:], file: r.file}
+			return nil
+		}
+		r.reader = r.file
+	}
+	_, err := r.file.Seek(r.Offset, 0)
+	return err
+}
@@ -0,0 +1,197 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package reader
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+// countingFile is an in memory file which counts how many times its content is read.
+type countingFile struct {
+	ino     uint64
+	content []byte
+	modTime time.Time
+	reads   int
+}
+
+func (f *countingFile) ReadAt(p []byte, off int64) (int, error) {
+	f.reads++
+	return bytes.NewReader(f.content).ReadAt(p, off)
+}
+
+func (f *countingFile) Stat() (os.FileInfo, error) {
+	return countingFileInfo{f}, nil
+}
+
+type countingFileInfo struct{ f *countingFile }
+
+func (i countingFileInfo) Name() string       { return "counting.log" }
+func (i countingFileInfo) Size() int64        { return int64(len(i.f.content)) }
+func (i countingFileInfo) Mode() fs.FileMode  { return 0o600 }
+func (i countingFileInfo) ModTime() time.Time { return i.f.modTime }
+func (i countingFileInfo) IsDir() bool        { return false }
+func (i countingFileInfo) Sys() any           { return &syscall.Stat_t{Ino: i.f.ino} }
+
+func TestPrefixCache(t *testing.T) {
+	cache := NewPrefixCache(10)
+	// The file was last modified well before it is cached, so its content is not checked
+	modTime := time.Now().Add(-time.Minute)
+	file := &countingFile{ino: 1, content: []byte("0123456789"), modTime: modTime}
+
+	data, err := cache.readPrefix(file, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "0123", string(data))
+	assert.Equal(t, 1, file.reads)
+
+	// Repeated reads of the prefix, including shorter ones, are served from the cache
+	for _, size := range []int{4, 2} {
+		data, err = cache.readPrefix(file, size)
+		require.NoError(t, err)
+		assert.Equal(t, "0123"[:size], string(data))
+	}
+	assert.Equal(t, 1, file.reads)
+
+	// A longer prefix than was cached must be read
+	data, err = cache.readPrefix(file, 8)
+	require.NoError(t, err)
+	assert.Equal(t, "01234567", string(data))
+	assert.Equal(t, 2, file.reads)
+
+	// The same content in another file is not shared
+	other := &countingFile{ino: 2, content: file.content, modTime: modTime}
+	_, err = cache.readPrefix(other, 8)
+	require.NoError(t, err)
+	assert.Equal(t, 1, other.reads)
+
+	// Changes in size or modification time invalidate the cached prefix
+	file.content = []byte("abcdefghijk")
+	data, err = cache.readPrefix(file, 8)
+	require.NoError(t, err)
+	assert.Equal(t, "abcdefgh", string(data))
+	assert.Equal(t, 3, file.reads)
+
+	file.content = []byte("ABCDEFGHIJK")
+	file.modTime = modTime.Add(time.Second)
+	data, err = cache.readPrefix(file, 8)
+	require.NoError(t, err)
+	assert.Equal(t, "ABCDEFGH", string(data))
+	assert.Equal(t, 4, file.reads)
+}
+
+func TestPrefixCacheShortFile(t *testing.T) {
+	cache := NewPrefixCache(10)
+	file := &countingFile{ino: 1, content: []byte("short"), modTime: time.Now().Add(-time.Minute)}
+
+	for i := 0; i < 3; i++ {
+		data, err := cache.readPrefix(file, 100)
+		require.NoError(t, err)
+		assert.Equal(t, "short", string(data))
+	}
+	// The whole file is cached, so it does not need to be read again for a longer prefix
+	assert.Equal(t, 1, file.reads)
+}
+
+func TestPrefixCacheRacy(t *testing.T) {
+	cache := NewPrefixCache(10)
+	modTime := time.Now()
+	file := &countingFile{ino: 1, content: []byte("0123456789"), modTime: modTime}
+
+	data, err := cache.readPrefix(file, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "0123", string(data))
+
+	// The file was modified as it was cached, so a rewrite of the same size may keep its modification time
+	file.content = []byte("abcdefghij")
+	data, err = cache.readPrefix(file, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", string(data))
+	assert.Equal(t, 2, file.reads)
+
+	// Once the prefix was cached long enough after the modification, it is served without being checked
+	cache.entries[fileKey{ino: 1}] = prefixEntry{
+		size: int64(len(file.content)), modTime: modTime, cachedAt: modTime.Add(time.Minute), data: []byte("abcd"),
+	}
+	data, err = cache.readPrefix(file, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "abcd", string(data))
+	assert.Equal(t, 2, file.reads)
+}
+
+func TestPrefixCacheEviction(t *testing.T) {
+	cache := NewPrefixCache(2)
+	for i := uint64(1); i <= 5; i++ {
+		_, err := cache.readPrefix(&countingFile{ino: i, content: []byte("content"), modTime: time.Now()}, 4)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(cache.entries), 2)
+	}
+}
+
+func TestPrefixCacheReader(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	f, sink := testFactory(t, withFingerprintSize(12))
+	f.PrefixCache = NewPrefixCache(10)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("testlog1\ntes"), fp.Bytes())
+
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	require.True(t, r.Validate())
+
+	// The first bytes of the content are served from the cache, and the rest from the file
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+
+	// Appended content invalidates the cached prefix
+	filetest.WriteString(t, temp, "testlog3\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog3"))
+	sink.ExpectNoCalls(t)
+	assert.True(t, r.Validate())
+
+	// A file which is rewritten is no longer valid
+	require.NoError(t, temp.Truncate(0))
+	_, err = temp.WriteAt([]byte("different content\n"), 0)
+	require.NoError(t, err)
+	assert.False(t, r.Validate())
+	r.Close()
+}
+
+func TestPrefixCacheValidateSameSizeRewrite(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	f, _ := testFactory(t, withFingerprintSize(12))
+	f.PrefixCache = NewPrefixCache(10)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	require.True(t, r.Validate())
+
+	// The file is rewritten with the same size and keeps its modification time
+	info, err := temp.Stat()
+	require.NoError(t, err)
+	_, err = temp.WriteAt([]byte("different\n"), 0)
+	require.NoError(t, err)
+	require.NoError(t, os.Chtimes(temp.Name(), info.ModTime(), info.ModTime()))
+	assert.False(t, r.Validate())
+}
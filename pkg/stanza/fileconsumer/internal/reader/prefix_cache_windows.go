@@ -0,0 +1,13 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "os"
+
+// The file index is not available from os.FileInfo on windows, so prefixes are not cached.
+func fileKeyOf(os.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
@@ -0,0 +1,46 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bufio"
+	"time"
+)
+
+// ThroughputProfile configures how tokens are batched and flushed during one phase of reading a file.
+// A reader starts in the backfill phase and switches to the follow phase once it has caught up to EOF.
+// Zero values fall back to the defaults of the factory.
+type ThroughputProfile struct {
+	MaxBatchSize int
+	FlushTimeout time.Duration
+}
+
+// readPhase is a ThroughputProfile resolved against the factory defaults.
+type readPhase struct {
+	maxBatchSize int
+	splitFunc    bufio.SplitFunc
+}
+
+func (p *ThroughputProfile) resolve(flushTimeout time.Duration, newSplitFunc func(time.Duration) bufio.SplitFunc) (int, bufio.SplitFunc) {
+	maxBatchSize := DefaultMaxBatchSize
+	if p.MaxBatchSize > 0 {
+		maxBatchSize = p.MaxBatchSize
+	}
+	if p.FlushTimeout > 0 {
+		flushTimeout = p.FlushTimeout
+	}
+	return maxBatchSize, newSplitFunc(flushTimeout)
+}
+
+// catchUp marks the reader as having reached EOF and switches it to the follow profile, if one is configured.
+func (r *Reader) catchUp() {
+	r.readToEOF = true
+	if r.CaughtUp {
+		return
+	}
+	r.CaughtUp = true
+	if r.follow != nil {
+		r.maxBatchSize, r.contentSplitFunc = r.follow.maxBatchSize, r.follow.splitFunc
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenRateLimiter bounds the rate at which tokens are emitted across the readers sharing it, so that many
+// files being read at once do not overwhelm the consumer. It is a token bucket which holds up to burst tokens
+// and is refilled at perSecond tokens per second. Readers wait before emitting a batch until the bucket
+// has enough tokens for it.
+type TokenRateLimiter struct {
+	perSecond float64
+	burst     float64
+
+	mu        sync.Mutex
+	available float64
+	last      time.Time
+}
+
+// NewTokenRateLimiter creates a limiter which allows perSecond tokens to be emitted per second, and up to
+// burst tokens to be emitted at once after a period of inactivity. Both are at least 1.
+func NewTokenRateLimiter(perSecond, burst int) *TokenRateLimiter {
+	perSecond, burst = max(perSecond, 1), max(burst, 1)
+	return &TokenRateLimiter{
+		perSecond: float64(perSecond),
+		burst:     float64(burst),
+		available: float64(burst),
+		last:      time.Now(),
+	}
+}
+
+// wait blocks until n tokens may be emitted. A batch larger than the burst is allowed once the bucket is full,
+// and the following batches wait for the deficit to be refilled. It returns false if the context is done first.
+func (l *TokenRateLimiter) wait(ctx context.Context, n int) bool {
+	delay := l.reserve(float64(n))
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		l.cancel(float64(n))
+		return false
+	}
+}
+
+// reserve takes n tokens from the bucket, and returns how long to wait until they would have been available.
+func (l *TokenRateLimiter) reserve(n float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.available = min(l.burst, l.available+now.Sub(l.last).Seconds()*l.perSecond)
+	l.last = now
+	// A batch which is larger than the burst could never fit in the bucket, so it only waits for a full bucket
+	needed := min(n, l.burst)
+	wait := time.Duration((needed - l.available) / l.perSecond * float64(time.Second))
+	l.available -= n
+	return wait
+}
+
+// cancel returns the tokens of a reservation which was not used.
+func (l *TokenRateLimiter) cancel(n float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.available = min(l.burst, l.available+n)
+}
@@ -0,0 +1,131 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestTokenRateLimiter(t *testing.T) {
+	const numFiles = 3
+	const linesPerFile = 20
+	const perSecond = 100
+	const burst = 10
+	limiter := NewTokenRateLimiter(perSecond, burst)
+
+	tempDir := t.TempDir()
+	var mu sync.Mutex
+	var emitted []time.Time
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for range tokens {
+			emitted = append(emitted, time.Now())
+		}
+		return nil
+	})
+	f.TokenRateLimiter = limiter
+
+	readers := make([]*Reader, numFiles)
+	for i := range readers {
+		temp := filetest.OpenTemp(t, tempDir)
+		filetest.WriteString(t, temp, strings.Repeat(fmt.Sprintf("file %d line\n", i), linesPerFile))
+		fp, err := f.NewFingerprint(temp)
+		require.NoError(t, err)
+		readers[i], err = f.NewReader(temp, fp)
+		require.NoError(t, err)
+		readers[i].maxBatchSize = 5
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, r := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.ReadToEnd(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	// Beyond the burst, the tokens of all readers together are emitted at no more than the configured rate
+	require.Len(t, emitted, numFiles*linesPerFile)
+	minDuration := time.Duration(numFiles*linesPerFile-burst) * time.Second / perSecond
+	assert.GreaterOrEqual(t, time.Since(start), minDuration-10*time.Millisecond)
+	for i := burst; i < len(emitted); i++ {
+		allowed := time.Duration(i+1-burst) * time.Second / perSecond
+		assert.GreaterOrEqual(t, emitted[i].Sub(start), allowed-10*time.Millisecond, "token %d", i)
+	}
+}
+
+func TestTokenRateLimiterLargeBatch(t *testing.T) {
+	limiter := NewTokenRateLimiter(100, 10)
+
+	// A batch larger than the burst is not held back forever, but the next batch waits for the deficit
+	start := time.Now()
+	require.True(t, limiter.wait(context.Background(), 20))
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+	require.True(t, limiter.wait(context.Background(), 1))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestTokenRateLimiterCancel(t *testing.T) {
+	limiter := NewTokenRateLimiter(1, 1)
+	require.True(t, limiter.wait(context.Background(), 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	assert.False(t, limiter.wait(ctx, 1))
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestTokenRateLimiterCancelOffset(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "first\nsecond\n")
+
+	var emitted []string
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		for _, token := range tokens {
+			emitted = append(emitted, string(token))
+		}
+		return nil
+	})
+	f.TokenRateLimiter = NewTokenRateLimiter(1, 1)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.maxBatchSize = 1
+
+	// The second batch waits for the limiter when the context is cancelled, so it is not consumed
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	r.ReadToEnd(ctx)
+	assert.Equal(t, []string{"first"}, emitted)
+	assert.Equal(t, int64(len("first\n")), r.Offset)
+	assert.Equal(t, int64(1), r.RecordNum)
+
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, []string{"first", "second"}, emitted)
+	assert.Equal(t, int64(len("first\nsecond\n")), r.Offset)
+	assert.Equal(t, int64(2), r.RecordNum)
+}
@@ -0,0 +1,30 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+// chunkSource reads from the current source of the reader, which is replaced from one poll to the next.
+type chunkSource struct {
+	r *Reader
+}
+
+func (c chunkSource) Read(p []byte) (int, error) {
+	return c.r.reader.Read(p)
+}
+
+// readSource reads from the source of the reader. If a read chunk size is set, the source is read in chunks
+// of at least that size, however small the buffer which the scanner reads into.
+func (r *Reader) readSource(dst []byte) (int, error) {
+	if r.chunks == nil {
+		return r.reader.Read(dst)
+	}
+	return r.chunks.Read(dst)
+}
+
+// discardChunks drops whatever was read ahead of the scanner, so that the next read starts from the current
+// position of the source. It must be called whenever the source is moved to another offset.
+func (r *Reader) discardChunks() {
+	if r.chunks != nil {
+		r.chunks.Reset(chunkSource{r})
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+// slowSource is a backend which takes a fixed time to serve each read, however much is read.
+type slowSource struct {
+	reader  *strings.Reader
+	latency time.Duration
+	calls   int
+}
+
+func (s *slowSource) Read(p []byte) (int, error) {
+	s.calls++
+	time.Sleep(s.latency)
+	return s.reader.Read(p)
+}
+
+// readFromSource reads the contents of a reader from a slow source, returning the number of reads it made
+func readFromSource(t testing.TB, f *Factory, content string, latency time.Duration) int {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, content)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	src := &slowSource{reader: strings.NewReader(content), latency: latency}
+	r.reader = src
+	r.discardChunks()
+	r.readContents(context.Background())
+	return src.calls
+}
+
+func TestReadChunkSize(t *testing.T) {
+	var content strings.Builder
+	expected := make([][]byte, 0, 500)
+	for i := range 500 {
+		line := fmt.Sprintf("line %d", i)
+		content.WriteString(line + "\n")
+		expected = append(expected, []byte(line))
+	}
+
+	f, sink := testFactory(t, withInitialBufferSize(16), withSinkChanSize(1000))
+	unchunked := readFromSource(t, f, content.String(), 0)
+	sink.ExpectTokens(t, expected...)
+
+	f, sink = testFactory(t, withInitialBufferSize(16), withSinkChanSize(1000))
+	f.ReadChunkSize = 64 * 1024
+	chunked := readFromSource(t, f, content.String(), 0)
+	sink.ExpectTokens(t, expected...)
+	sink.ExpectNoCalls(t)
+
+	require.Less(t, chunked*100, unchunked)
+}
+
+func TestReadChunkSizeAcrossPolls(t *testing.T) {
+	f, sink := testFactory(t, withInitialBufferSize(16))
+	f.ReadChunkSize = 4096
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "aaa\nbbb\nincomplete")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("aaa"), []byte("bbb"))
+	require.Equal(t, int64(8), r.Offset)
+
+	// Whatever was read ahead of the last token is read again from the offset
+	filetest.WriteString(t, temp, " line\nccc\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("incomplete line"), []byte("ccc"))
+	sink.ExpectNoCalls(t)
+}
+
+func BenchmarkReadChunkSize(b *testing.B) {
+	var content strings.Builder
+	for range 1000 {
+		content.Write(filetest.TokenWithLength(100))
+		content.WriteString("\n")
+	}
+
+	for _, chunkSize := range []int{0, 16 * 1024, 256 * 1024} {
+		b.Run(fmt.Sprintf("ChunkSize%d", chunkSize), func(b *testing.B) {
+			f := newTestFactory(b, func(context.Context, [][]byte, map[string]any, int64, []int64) error {
+				return nil
+			})
+			f.InitialBufferSize = 1024
+			f.ReadChunkSize = chunkSize
+			var calls int
+			b.ResetTimer()
+			for range b.N {
+				calls += readFromSource(b, f, content.String(), 100*time.Microsecond)
+			}
+			b.ReportMetric(float64(calls)/float64(b.N), "reads/op")
+		})
+	}
+}
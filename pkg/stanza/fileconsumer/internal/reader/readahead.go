@@ -0,0 +1,30 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"go.uber.org/zap"
+)
+
+// readaheadWindow bounds how much of the file the kernel is asked to read ahead of the scanner at once
+const readaheadWindow = 16 * 1024 * 1024
+
+// adviseReadahead tells the kernel that the file is about to be read sequentially from the current offset, so that
+// it reads ahead of the scanner. It is only done when at least the configured number of bytes remain to be read.
+func (r *Reader) adviseReadahead() {
+	if r.readaheadMinSize <= 0 || r.directIO || !r.readingFile() {
+		return
+	}
+	info, err := r.file.Stat()
+	if err != nil {
+		return
+	}
+	remaining := info.Size() - r.Offset
+	if remaining < r.readaheadMinSize {
+		return
+	}
+	if err = adviseSequential(r.file, r.Offset, min(remaining, readaheadWindow)); err != nil {
+		r.set.Logger.Debug("failed to advise readahead", zap.Error(err))
+	}
+}
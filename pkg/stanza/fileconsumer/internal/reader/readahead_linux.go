@@ -0,0 +1,20 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+func adviseSequential(file *os.File, offset, length int64) error {
+	fd := int(file.Fd())
+	if err := unix.Fadvise(fd, 0, 0, unix.FADV_SEQUENTIAL); err != nil {
+		return err
+	}
+	return unix.Fadvise(fd, offset, length, unix.FADV_WILLNEED)
+}
@@ -0,0 +1,92 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func writeLines(tb testing.TB, file *os.File, size int) int {
+	var content strings.Builder
+	numLines := 0
+	for ; content.Len() < size; numLines++ {
+		fmt.Fprintf(&content, "this is line number %d\n", numLines)
+	}
+	filetest.WriteString(tb, file, content.String())
+	return numLines
+}
+
+func TestReadahead(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	numLines := writeLines(t, temp, 4*1024*1024)
+	evictPageCache(t, temp)
+
+	var tokens int
+	f := newTestFactory(t, func(_ context.Context, batch [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		tokens += len(batch)
+		return nil
+	})
+	f.ReadaheadMinSize = 1024 * 1024
+	file := filetest.OpenFile(t, temp.Name())
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The kernel is asked to read the file into the page cache before it is scanned
+	r.reader = r.file
+	r.adviseReadahead()
+	assert.Eventually(t, func() bool { return residentFraction(t, temp) > 0.5 }, 5*time.Second, 10*time.Millisecond)
+
+	r.ReadToEnd(context.Background())
+	require.Equal(t, numLines, tokens)
+}
+
+func BenchmarkReadahead(b *testing.B) {
+	temp := filetest.OpenTemp(b, b.TempDir())
+	writeLines(b, temp, 64*1024*1024)
+	info, err := temp.Stat()
+	require.NoError(b, err)
+
+	for _, readahead := range []bool{false, true} {
+		b.Run(fmt.Sprintf("readahead=%t", readahead), func(b *testing.B) {
+			f := newTestFactory(b, func(context.Context, [][]byte, map[string]any, int64, []int64) error { return nil })
+			if readahead {
+				f.ReadaheadMinSize = 1024 * 1024
+			}
+			b.SetBytes(info.Size())
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Each read starts from disk rather than the page cache
+				b.StopTimer()
+				require.NoError(b, temp.Sync())
+				require.NoError(b, unix.Fadvise(int(temp.Fd()), 0, 0, unix.FADV_DONTNEED))
+				file := filetest.OpenFile(b, temp.Name())
+				fp, err := f.NewFingerprint(file)
+				require.NoError(b, err)
+				r, err := f.NewReader(file, fp)
+				require.NoError(b, err)
+				b.StartTimer()
+
+				r.ReadToEnd(context.Background())
+				require.Equal(b, info.Size(), r.Offset)
+				r.Close()
+			}
+		})
+	}
+}
@@ -10,143 +10,474 @@ import (
 	"errors"
 	"io"
 	"os"
+	"regexp"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
 	"go.opentelemetry.io/collector/component"
 	"go.uber.org/zap"
 	"golang.org/x/text/encoding"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/emit"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/header"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/metadata"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/scanner"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/flush"
+	internaltime "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/time"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/tokenlen"
 )
 
-const gzipExtension = ".gz"
+const (
+	gzipExtension = ".gz"
+	zstdExtension = ".zst"
+)
 
 type Metadata struct {
-	Fingerprint     *fingerprint.Fingerprint
-	Offset          int64
-	RecordNum       int64
-	FileAttributes  map[string]any
-	HeaderFinalized bool
-	FlushState      flush.State
-	TokenLenState   tokenlen.State
-	FileType        string
+	Fingerprint         *fingerprint.Fingerprint
+	Offset              int64
+	RecordNum           int64
+	FileAttributes      map[string]any
+	HeaderFinalized     bool
+	FlushState          flush.State
+	TokenLenState       tokenlen.State
+	FileType            string
+	CaughtUp            bool
+	LastTimestamp       time.Time
+	PreambleSkipped     bool
+	DetectedCompression string
+	CumulativeBytes     int64
+	BytesRead           int64
+	SwitchBOMPending    bool
+	EncodingSwitched    bool
+	FileID              string
+	FileIDSize          int
+	FileIdentity        string
+	FileIdentitySize    int
+	HeaderEncoding      string
+	FingerprintSize     int
+	Dedup               *DedupFilter
+	RepeatRun           *RepeatRun
+	DecodeErrors        int64
+	ReadDuration        time.Duration
+	SessionTimestamp    time.Time
+	TruncationPending   bool
+	TruncatedSize       int64
+	MidLineOffset       int64
+	DecompressedOffset  int64
+
+	// restored is set for metadata which was loaded from a checkpoint at startup
+	restored bool
+	// lastErrorToken is when a token was last emitted for a scan error
+	lastErrorToken time.Time
+	// batchHeldSince is when tokens at the end of the file were first held back to fill a minimum batch
+	batchHeldSince time.Time
+	// pendingDelete is set when the file could not be deleted after it was read to the end
+	pendingDelete bool
+	// archivedCopy is the path of the copy of the file which was made in the archive directory,
+	// if the file itself could not then be removed
+	archivedCopy string
+	// editStopped is set when the file was edited in place and is not read any further
+	editStopped bool
+	// pollCycle counts the calls to ReadToEnd for the file since it was first seen by this process
+	pollCycle int64
+	// symlinkPath is the path of the symlink through which the file was matched, if any
+	symlinkPath string
+	// recentTokens retains the most recently emitted tokens while the file is tracked
+	recentTokens *tokenRing
+	// rotationOverlap retains the hashes of recently emitted tokens, to suppress them if the file is reset
+	rotationOverlap *overlapRing
+	// readToEOF is set when the last call to ReadToEnd reached the end of the file
+	readToEOF bool
+	// skippedBytes counts the bytes of the tokens which were skipped because they could not be decoded
+	skippedBytes int64
+	// timestampLayout is the index of the layout which last parsed a timestamp of the file
+	timestampLayout int
+	// dedupPending holds the hashes of the tokens which are not duplicates, until they are emitted
+	dedupPending []dedupHash
+	// sessionPending is the timestamp of the last token read which had one, until it is emitted
+	sessionPending time.Time
+	// inFlight is a batch which is still being emitted because the emit callback did not return in time
+	inFlight *inFlightEmit
+}
+
+// MarkRestored flags metadata which was loaded from a checkpoint at startup.
+func (m *Metadata) MarkRestored() {
+	m.restored = true
 }
 
+// BatchEmittedFunc is called after each call to the emit callback with the number of tokens
+// and bytes that were emitted, and how long the emit callback took.
+type BatchEmittedFunc func(tokenCount int, bytes int, duration time.Duration)
+
 // Reader manages a single file
 type Reader struct {
 	*Metadata
-	set                    component.TelemetrySettings
-	fileName               string
-	file                   *os.File
-	reader                 io.Reader
-	fingerprintSize        int
-	bufPool                *sync.Pool
-	initialBufferSize      int
-	maxLogSize             int
-	headerSplitFunc        bufio.SplitFunc
-	contentSplitFunc       bufio.SplitFunc
-	decoder                *encoding.Decoder
-	headerReader           *header.Reader
-	emitFunc               emit.Callback
-	deleteAtEOF            bool
-	needsUpdateFingerprint bool
-	compression            string
-	acquireFSLock          bool
-	maxBatchSize           int
+	set                        component.TelemetrySettings
+	fileName                   string
+	file                       *os.File
+	reader                     io.Reader
+	fingerprintSize            int
+	hashFingerprint            bool
+	bufPool                    *sync.Pool
+	zeroPooledBuffers          bool
+	initialBufferSize          int
+	maxLogSize                 int
+	bufferGrowth               scanner.Growth
+	headerSplitFunc            bufio.SplitFunc
+	contentSplitFunc           bufio.SplitFunc
+	decoder                    *encoding.Decoder
+	headerReader               *header.Reader
+	headerConfig               *header.Config
+	headerResourceAttributes   map[string]string
+	headerEncodingAttribute    string
+	emitHeader                 bool
+	headerEncodingSplitFunc    func(encoding.Encoding) (bufio.SplitFunc, error)
+	repeatedHeaderStart        *regexp.Regexp
+	lastHeaderRearm            int64
+	emitFunc                   emit.Callback
+	fanOut                     []emit.Callback
+	tokenBatchBuffer           int
+	onBatchEmitted             BatchEmittedFunc
+	diskPressure               DiskPressureFunc
+	staticLabels               map[string]any
+	staticLabelsOverride       bool
+	maxDecodedSize             int
+	trailingDelimiter          []byte
+	trimLineEnding             bool
+	normalizeCRLF              bool
+	stripRecordBOM             bool
+	maxDecompressedSize        int64
+	decompressedSizeExceeded   bool
+	decompressionPool          *DecompressionPool
+	telemetryBuilder           *metadata.TelemetryBuilder
+	compressedWindow           int64
+	readingWindow              bool
+	decompressedBytes          int64
+	chunks                     *bufio.Reader
+	sampleRate                 float64
+	batchRecordNums            []int64
+	incrementalGzip            bool
+	gzipReaderLimiter          *GzipReaderLimiter
+	tokenRateLimiter           *TokenRateLimiter
+	gzipReaderAcquired         bool
+	prefixCache                *PrefixCache
+	contentStartMarker         []byte
+	maxPreambleSize            int
+	decodedSizePolicy          string
+	maxLineLength              int
+	lineLengthPolicy           string
+	deleteAtEOF                bool
+	inPlaceEditPolicy          string
+	emitTruncation             bool
+	maxStalledScans            int
+	deleteRetries              int
+	deleteArchiveDir           string
+	deleteRetryBackoff         time.Duration
+	needsUpdateFingerprint     bool
+	compression                string
+	sniffCompression           bool
+	includeGzipHeader          bool
+	includeGzipOffset          bool
+	mixedCompression           bool
+	gzipPosition               *compressedPosition
+	includeDetectedCompression bool
+	includeSourceCodec         bool
+	acquireFSLock              bool
+	directIO                   bool
+	readaheadMinSize           int64
+	maxFSLockHold              time.Duration
+	emitTimeout                time.Duration
+	lockAcquiredAt             time.Time
+	maxBatchSize               int
+	maxBatchMemory             int
+	minBatchSize               int
+	minBatchTimeout            time.Duration
+	severityExtractor          *SeverityExtractor
+	checksumVerifier           *ChecksumVerifier
+	timestampNormalizer        *TimestampNormalizer
+	sessionizer                *Sessionizer
+	fingerprintMatch           FingerprintMatchFunc
+	uuidNamespace              *uuid.UUID
+	hostSequence               func() uint64
+	includeFileID              bool
+	fileIDIncludeInode         bool
+	includeFileIdentity        bool
+	fileIdentityInode          bool
+	includeByteRange           bool
+	identitySize               int
+	timestampParser            func([]byte) (time.Time, bool)
+	tokenTransform             TokenTransform
+	transformWorkers           int
+	includeFirstRecord         bool
+	includeCumulativeCounters  bool
+	partialToken               bool
+	truncatedToken             bool
+	scanMidLine                bool
+	tooLongPieces              [][]byte
+	includeScanTime            bool
+	includePollCycle           bool
+	emitScanErrors             bool
+	decodeFallback             string
+	decodeFallbackUsed         bool
+	decodeErrorAction          string
+	coalesceRepeats            bool
+	maxRepeatCount             int64
+	repeatFlushTimeout         time.Duration
+	lineGzip                   *lineGzip
+	errorTokenInterval         time.Duration
+	scanTime                   time.Time
+	contextBeforeSize          int
+	contextBefore              []byte
+	encodingSwitch             *EncodingSwitch
+	bindEncoding               func(encoding.Encoding, bufio.SplitFunc)
+	decompressFP               bool
+	partPrefix                 string
+	parts                      *multipartFile
+	follow                     *readPhase
+	sourceGone                 bool
+	snapshot                   snapshotState
 }
 
 // ReadToEnd will read until the end of the file
 func (r *Reader) ReadToEnd(ctx context.Context) {
+	r.sourceGone = false
+	if r.underDiskPressure() {
+		return
+	}
+	if !r.settleInFlight(ctx) {
+		return
+	}
+	r.pollCycle++
+	r.readToEOF = false
+	if r.telemetryBuilder != nil {
+		defer r.startReadTimer()()
+	}
 	if r.acquireFSLock {
 		if !r.tryLockFile() {
 			return
 		}
+		r.lockAcquiredAt = time.Now()
 		defer r.unlockFile()
 	}
+	if !r.checkInPlaceEdit() {
+		return
+	}
+	r.emitTruncationEvent(ctx)
+	defer r.checkSourceGone()
+	defer r.closeParts()
+	defer r.releaseGzipReader()
+	defer r.stopPrefetch()
+	defer r.publishSnapshot()
+	defer r.recordCompressionRatio(ctx)
 
 	switch r.compression {
 	case "gzip":
-		currentEOF, err := r.createGzipReader()
+		start, currentEOF, err := r.createGzipReader(ctx)
 		if err != nil {
 			return
 		}
-		// Offset tracking in an uncompressed file is based on the length of emitted tokens, but in this case
-		// we need to set the offset to the end of the file.
-		defer func() {
-			r.Offset = currentEOF
-		}()
+		defer r.finishWindow(start, currentEOF)
 	case "auto":
-		// Identifying a filename by its extension may not always be correct. We could have a compressed file without the .gz extension
-		if r.FileType == gzipExtension {
-			currentEOF, err := r.createGzipReader()
+		if !r.detectCompression() {
+			return
+		}
+		if r.FileType == gzipExtension || r.FileType == zstdExtension {
+			start, currentEOF, err := r.createGzipReader(ctx)
 			if err != nil {
 				return
 			}
-			// Offset tracking in an uncompressed file is based on the length of emitted tokens, but in this case
-			// we need to set the offset to the end of the file.
-			defer func() {
-				r.Offset = currentEOF
-			}()
+			defer r.finishWindow(start, currentEOF)
 		} else {
 			r.reader = r.file
 		}
 	default:
 		r.reader = r.file
 	}
+	if r.includeSourceCodec {
+		r.FileAttributes[attrs.LogFileSourceCodec] = r.sourceCodec()
+	}
 
-	if _, err := r.file.Seek(r.Offset, 0); err != nil {
+	// Compressed files do not have a preamble which could be skipped without decompressing them
+	if r.contentStartMarker != nil && !r.PreambleSkipped && r.reader == io.Reader(r.file) {
+		if !r.skipPreamble() {
+			return
+		}
+	}
+
+	if err := r.seekToOffset(); err != nil {
 		r.set.Logger.Error("failed to seek", zap.Error(err))
 		return
 	}
+	r.adviseReadahead()
+	r.startDirectIO()
+	defer r.stopDirectIO()
 
 	defer func() {
 		if r.needsUpdateFingerprint {
 			r.updateFingerprint()
+			r.emitTruncationEvent(ctx)
 		}
 	}()
 
-	if r.headerReader != nil {
-		if r.readHeader(ctx) {
+	for {
+		// The header may be re-armed while it is being read
+		for r.headerReader != nil {
+			if r.readHeader(ctx) {
+				return
+			}
+		}
+
+		if !r.readContents(ctx) {
 			return
 		}
 	}
-
-	r.readContents(ctx)
 }
 
-// createGzipReader creates gzip reader and returns the file offset
-func (r *Reader) createGzipReader() (int64, error) {
+// createGzipReader creates gzip reader and returns the offsets of the start and end of the compressed window.
+// A file which was detected to be zstd compressed is read with a zstd decoder instead, without the features which
+// depend on the gzip format.
+func (r *Reader) createGzipReader(ctx context.Context) (start, currentEOF int64, err error) {
+	if r.gzipReaderLimiter != nil {
+		if !r.gzipReaderLimiter.acquire(ctx) {
+			return 0, 0, ctx.Err()
+		}
+		r.gzipReaderAcquired = true
+	}
+
 	// We need to create a gzip reader each time ReadToEnd is called because the underlying
 	// SectionReader can only read a fixed window (from previous offset to EOF).
-	info, err := r.file.Stat()
-	if err != nil {
-		r.set.Logger.Error("failed to stat", zap.Error(err))
-		return 0, err
+	var src io.ReaderAt = r.file
+	if r.partPrefix != "" {
+		parts, err := r.openParts()
+		if err != nil {
+			if errors.Is(err, errNotFirstPart) {
+				r.set.Logger.Debug("skipping part, it is read as part of the first part")
+			} else {
+				r.set.Logger.Error("failed to open parts", zap.Error(err))
+			}
+			return 0, 0, err
+		}
+		r.parts = parts
+		src, currentEOF = parts, parts.size
+	} else {
+		info, err := r.file.Stat()
+		if err != nil {
+			r.set.Logger.Error("failed to stat", zap.Error(err))
+			return 0, 0, err
+		}
+		currentEOF = info.Size()
+	}
+	isZstd := r.FileType == zstdExtension
+	if r.incrementalGzip && !isZstd {
+		// Members which are still being written are read once they are complete
+		currentEOF = completeGzipMembersEnd(src, r.Offset, currentEOF)
+		if currentEOF == r.Offset {
+			return 0, 0, io.EOF
+		}
 	}
-	currentEOF := info.Size()
 	// use a gzip Reader with an underlying SectionReader to pick up at the last
 	// offset of a gzip compressed file
-	gzipReader, err := gzip.NewReader(io.NewSectionReader(r.file, r.Offset, currentEOF))
+	var section io.Reader = io.NewSectionReader(src, r.Offset, currentEOF-r.Offset)
+	if r.includeGzipOffset {
+		r.gzipPosition = newCompressedPosition(section, r.Offset)
+		section = r.gzipPosition
+	}
+	if isZstd {
+		// With a concurrency of one the decoder decodes on the caller's goroutine, so it does not need to be closed
+		decoder, err := zstd.NewReader(section, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			r.set.Logger.Error("failed to create zstd reader", zap.Error(err))
+			return 0, 0, err
+		}
+		r.reader = decoder
+	} else if err = r.newGzipReader(section); err != nil {
+		return 0, 0, err
+	}
+	// A window which was cut short by the maximum decompressed size is decompressed again from its start,
+	// skipping the bytes which were consumed on earlier polls
+	start = r.Offset
+	if r.DecompressedOffset > 0 {
+		if _, err = io.CopyN(io.Discard, r.reader, r.DecompressedOffset); err != nil {
+			r.set.Logger.Error("failed to skip decompressed bytes which were already read", zap.Error(err))
+			return 0, 0, err
+		}
+		r.Offset += r.DecompressedOffset
+	}
+	r.decompressedSizeExceeded = false
+	r.compressedWindow, r.decompressedBytes = currentEOF-start, 0
+	r.readingWindow = true
+	if r.maxDecompressedSize > 0 {
+		r.reader = &decompressedSizeLimiter{reader: r.reader, remaining: r.maxDecompressedSize}
+	}
+	if r.decompressionPool != nil {
+		r.reader = r.decompressionPool.newReader(ctx, r.reader, r.gzipPosition)
+	}
+	return start, currentEOF, nil
+}
+
+// newGzipReader sets the reader to decompress the gzip members of the section.
+func (r *Reader) newGzipReader(section io.Reader) error {
+	var segments *bufio.Reader
+	if r.mixedCompression {
+		segments = bufio.NewReader(section)
+		section = segments
+	}
+	gzipReader, err := gzip.NewReader(section)
 	if err != nil {
 		if !errors.Is(err, io.EOF) {
 			r.set.Logger.Error("failed to create gzip reader", zap.Error(err))
 		}
-		return 0, err
+		return err
 	}
 	r.reader = gzipReader
-	return currentEOF, nil
+	if segments != nil {
+		r.reader = newSegmentReader(segments, gzipReader)
+	}
+	if r.includeGzipHeader {
+		if gzipReader.Name != "" {
+			r.FileAttributes[attrs.LogFileGzipOriginalName] = gzipReader.Name
+		}
+		if !gzipReader.ModTime.IsZero() {
+			r.FileAttributes[attrs.LogFileGzipMtime] = gzipReader.ModTime.UTC().Format(time.RFC3339)
+		}
+	}
+	return nil
+}
+
+// finishWindow moves the offset to the end of the compressed window which was read. Offset tracking in an
+// uncompressed file is based on the length of emitted tokens, but the offsets of the decompressed tokens do
+// not apply to the compressed file. If a batch of the window is in flight, the offset is moved once it is emitted.
+func (r *Reader) finishWindow(start, end int64) {
+	r.readingWindow = false
+	if r.inFlight != nil && r.inFlight.after != nil {
+		r.inFlight.after.offset, r.inFlight.after.decompressedOffset = r.windowResume(start, end, r.inFlight.after.offset)
+		r.Offset = start
+		return
+	}
+	r.Offset, r.DecompressedOffset = r.windowResume(start, end, r.Offset)
+}
+
+// windowResume returns the offset and the decompressed offset from which reading resumes once the window
+// has been read up to the given decompressed position. A window which was cut short by the maximum
+// decompressed size is read again from its start, skipping the decompressed bytes which were consumed.
+func (r *Reader) windowResume(start, end, pos int64) (offset, decompressedOffset int64) {
+	if r.decompressedSizeExceeded {
+		return start, pos - start
+	}
+	return end, 0
 }
 
 func (r *Reader) readHeader(ctx context.Context) (doneReadingFile bool) {
 	bufPtr := r.getBufPtrFromPool()
-	defer r.bufPool.Put(bufPtr)
 	s := scanner.New(r, r.maxLogSize, *bufPtr, r.Offset, r.headerSplitFunc)
+	defer r.putBufPtrToPool(bufPtr, s)
+	s.SetGrowth(r.bufferGrowth)
+	stall := r.newStallGuard()
 
 	// Read the tokens from the file until no more header tokens are found or the end of file is reached.
 	for {
@@ -157,18 +488,29 @@ func (r *Reader) readHeader(ctx context.Context) (doneReadingFile bool) {
 		}
 
 		ok := s.Scan()
+		if ok && r.includeScanTime {
+			r.scanTime = internaltime.Now()
+		}
 		if !ok {
 			if err := s.Error(); err != nil {
 				r.set.Logger.Error("failed during header scan", zap.Error(err))
+				r.emitErrorToken(ctx, err)
 			} else {
 				r.set.Logger.Debug("end of file reached", zap.Bool("delete_at_eof", r.deleteAtEOF))
 				if r.deleteAtEOF {
-					r.delete()
+					r.delete(ctx)
 				}
 			}
 			// Either end of file was reached, or file cannot be scanned.
 			return true
 		}
+		if stall.stalled(s.Pos()) {
+			if !stall.exceeded() {
+				continue
+			}
+			r.logStalled(stall)
+			return true
+		}
 
 		token, err := textutils.DecodeAsString(r.decoder, s.Bytes())
 		if err != nil {
@@ -186,6 +528,9 @@ func (r *Reader) readHeader(ctx context.Context) (doneReadingFile bool) {
 			r.set.Logger.Error("failed to process header token", zap.Error(err))
 		}
 
+		if !r.emitHeaderToken(ctx, token, r.Offset, s.Pos()) {
+			return true
+		}
 		r.Offset = s.Pos()
 	}
 
@@ -195,9 +540,28 @@ func (r *Reader) readHeader(ctx context.Context) (doneReadingFile bool) {
 	}
 	r.headerReader = nil
 	r.HeaderFinalized = true
+	r.applyHeaderEncoding()
+	r.promoteHeaderAttributes()
+
+	if r.readingFile() {
+		info, err := r.file.Stat()
+		if err != nil {
+			r.set.Logger.Error("failed to stat post-header", zap.Error(err))
+			return true
+		}
+		if r.Offset > info.Size() {
+			// The header which was read may no longer be in the file, so read the header again from the start
+			r.set.Logger.Warn("file was truncated while reading header, reading it again", zap.Int64("offset", r.Offset), zap.Int64("size", info.Size()))
+			r.Offset = 0
+			r.flagTruncation()
+			r.emitTruncationEvent(ctx)
+			r.rearmHeader()
+			return r.headerReader == nil
+		}
+	}
 
 	// Reset position in file to r.Offest after the header scanner might have moved it past a content token.
-	if _, err := r.file.Seek(r.Offset, 0); err != nil {
+	if err := r.seekToOffset(); err != nil {
 		r.set.Logger.Error("failed to seek post-header", zap.Error(err))
 		return true
 	}
@@ -205,12 +569,14 @@ func (r *Reader) readHeader(ctx context.Context) (doneReadingFile bool) {
 	return false
 }
 
-func (r *Reader) readContents(ctx context.Context) {
+// readContents reads and emits tokens until the end of the file. It returns true if reading should
+// continue from the current offset, because a repeated header or an encoding switch was found.
+func (r *Reader) readContents(ctx context.Context) bool {
 	var buf []byte
+	var bufPtr *[]byte
 	if r.TokenLenState.MinimumLength <= r.initialBufferSize {
-		bufPtr := r.getBufPtrFromPool()
+		bufPtr = r.getBufPtrFromPool()
 		buf = *bufPtr
-		defer r.bufPool.Put(bufPtr)
 	} else {
 		// If we previously saw a potential token larger than the default buffer,
 		// size the buffer to be at least one byte larger so we can see if there's more data.
@@ -218,65 +584,252 @@ func (r *Reader) readContents(ctx context.Context) {
 		buf = make([]byte, 0, r.TokenLenState.MinimumLength+1)
 	}
 	s := scanner.New(r, r.maxLogSize, buf, r.Offset, r.contentSplitFunc)
+	if bufPtr != nil {
+		defer r.putBufPtrToPool(bufPtr, s)
+	}
+	s.SetGrowth(r.bufferGrowth)
+	if r.contextBeforeSize > 0 {
+		s.KeepContext(r.contextBeforeSize)
+	}
 
 	tokenBodies := make([][]byte, r.maxBatchSize)
 	tokenOffsets := make([]int64, r.maxBatchSize+1)
+	tokenAttributes := make([]map[string]any, r.maxBatchSize)
+	var decodedTokens [][]byte
+	// The record numbers of a batch are not contiguous when tokens are sampled out of it, or boundaries inserted
+	if r.sampling() || r.sessionizer != nil {
+		r.batchRecordNums = make([]int64, r.maxBatchSize)
+	}
 
-	numTokensBatched := 0
+	numTokensBatched, batchBytes := 0, 0
 	tokenOffsets[0] = r.Offset
+	batch := r.saveBatchState()
+	// The tokens of the batch are recorded as emitted once reading stops, unless they are read again
+	defer r.recordDuplicates()
+	defer r.recordRotationOverlap()
+	defer r.recordSession()
+	stall := r.newStallGuard()
 	// Iterate over the contents of the file.
 	for {
 		select {
 		case <-ctx.Done():
-			return
+			return false
 		default:
 		}
 
 		ok := s.Scan()
+		if ok && r.scanMidLine {
+			r.MidLineOffset = s.Pos()
+		}
+		if ok && r.includeScanTime {
+			r.scanTime = internaltime.Now()
+		}
+		if ok && r.contextBeforeSize > 0 {
+			r.contextBefore = s.ContextBefore()
+		}
 		if !ok {
-			if err := s.Error(); err != nil {
-				r.set.Logger.Error("failed during scan", zap.Error(err))
+			scanErr := s.Error()
+			if scanErr == nil && r.holdBatch(numTokensBatched) {
+				// Undo the effects of reading the held tokens, since they will be read again
+				r.restoreBatchState(batch)
+				r.catchUp()
+				return false
+			}
+
+			if run := r.flushRepeatsAtEOF(scanErr); run != nil {
+				tokenBodies[numTokensBatched] = run.Token
+				tokenOffsets[numTokensBatched], tokenOffsets[numTokensBatched+1] = run.Offset, run.End
+				tokenAttributes[numTokensBatched] = r.repeatAttributes(run)
+				numTokensBatched++
+				r.RecordNum++
+				r.noteRecordNum(numTokensBatched - 1)
+			}
+
+			if errors.Is(s.Err(), errDecompressedSizeExceeded) {
+				// The remainder of the compressed file is read on the next poll
+				r.decompressedSizeExceeded = true
+				r.set.Logger.Warn("stopped reading compressed file", zap.Error(s.Err()), zap.Int64("max_decompressed_size", r.maxDecompressedSize))
+			} else if scanErr != nil {
+				r.set.Logger.Error("failed during scan", zap.Error(scanErr))
 			} else if r.deleteAtEOF {
-				r.delete()
+				r.delete(ctx)
 			}
 
 			if numTokensBatched > 0 {
-				err := r.emitFunc(ctx, tokenBodies[:numTokensBatched], r.FileAttributes, r.RecordNum, tokenOffsets)
-				if err != nil {
+				err := r.emitBatch(ctx, tokenBodies[:numTokensBatched], tokenAttributes[:numTokensBatched], tokenOffsets)
+				if r.emitInterrupted(ctx, err) {
+					r.interruptBatch(err, batch, s.Pos())
+					return false
+				} else if err != nil {
 					r.set.Logger.Error("failed to emit token", zap.Error(err))
 				}
 				r.Offset = s.Pos()
 			}
-			return
+
+			if scanErr == nil {
+				r.catchUp()
+			} else if !errors.Is(s.Err(), errDecompressedSizeExceeded) {
+				r.emitErrorToken(ctx, scanErr)
+			}
+			return false
 		}
 
-		var err error
-		tokenBodies[numTokensBatched], err = r.decoder.Bytes(s.Bytes())
-		tokenOffsets[numTokensBatched+1] = s.Pos()
-		if err != nil {
-			r.set.Logger.Error("failed to decode token", zap.Error(err))
-			r.Offset = s.Pos() // move past the bad token or we may be stuck
-			continue
+		if stall.stalled(s.Pos()) {
+			if !stall.exceeded() {
+				continue
+			}
+			r.logStalled(stall)
+			if numTokensBatched > 0 {
+				if err := r.emitBatch(ctx, tokenBodies[:numTokensBatched], tokenAttributes[:numTokensBatched], tokenOffsets); r.emitInterrupted(ctx, err) {
+					r.interruptBatch(err, batch, s.Pos())
+					return false
+				} else if err != nil {
+					r.set.Logger.Error("failed to emit token", zap.Error(err))
+				}
+				r.Offset = s.Pos()
+			}
+			return false
+		}
+
+		if r.isEncodingSwitch(s.Bytes()) {
+			if numTokensBatched > 0 {
+				// The marker is read again once the batch is emitted, so that the encoding is switched then
+				if err := r.emitBatch(ctx, tokenBodies[:numTokensBatched], tokenAttributes[:numTokensBatched], tokenOffsets); r.emitInterrupted(ctx, err) {
+					r.interruptBatch(err, batch, tokenOffsets[numTokensBatched])
+					return false
+				} else if err != nil {
+					r.set.Logger.Error("failed to emit token", zap.Error(err))
+				}
+			}
+			r.Offset = s.Pos()
+			return r.switchEncoding()
+		}
+
+		tokenStart := tokenOffsets[numTokensBatched]
+		var errorAttributes map[string]any
+		var endedRun *RepeatRun
+		marker := -1
+		decoded, err := r.decode(s.Bytes())
+		if err == nil && r.lineGzip != nil {
+			decoded, err = r.expandLineGzip(decoded)
+		}
+		switch {
+		case err != nil:
+			if errorAttributes = r.decodeError(err, s.Bytes(), tokenStart); errorAttributes == nil {
+				// move past the bad token or we may be stuck
+				r.skippedBytes += s.Pos() - tokenStart
+				tokenOffsets[numTokensBatched] = s.Pos()
+				if numTokensBatched == 0 {
+					r.Offset, batch = s.Pos(), r.saveBatchState()
+				}
+				continue
+			}
+			// An empty error record takes the place of the token, so that it is emitted in order
+			decodedTokens, endedRun = r.endRepeats(append(decodedTokens[:0], []byte{}))
+		case r.isRepeatedHeaderStart(decoded, tokenStart):
+			if numTokensBatched > 0 {
+				if err = r.emitBatch(ctx, tokenBodies[:numTokensBatched], tokenAttributes[:numTokensBatched], tokenOffsets); r.emitInterrupted(ctx, err) {
+					r.interruptBatch(err, batch, tokenStart)
+					return false
+				} else if err != nil {
+					r.set.Logger.Error("failed to emit token", zap.Error(err))
+				}
+			}
+			r.Offset = tokenStart
+			r.rearmHeader()
+			return r.headerReader != nil
+		default:
+			// A decoded token may be emitted as several tokens, or not at all, depending on its size
+			decodedTokens = r.limitDecodedSize(decodedTokens[:0], r.normalizeNewlines(r.trimTrailingDelimiter(r.stripBOM(r.stripSwitchBOM(decoded)))))
+			decodedTokens = r.limitLineLength(decodedTokens)
+			decodedTokens = r.dropDuplicates(decodedTokens, tokenStart)
+			decodedTokens = r.dropRotationOverlap(decodedTokens)
+			decodedTokens = r.sample(decodedTokens, tokenStart)
+			decodedTokens, endedRun = r.holdRepeats(decodedTokens, tokenStart, s.Pos())
+			decodedTokens, marker = r.markSessionBoundary(decodedTokens, firstPiece(endedRun != nil, -1))
+			if len(decodedTokens) == 0 {
+				tokenOffsets[numTokensBatched] = s.Pos()
+				if numTokensBatched == 0 {
+					r.Offset, batch = s.Pos(), r.saveBatchState()
+				}
+				continue
+			}
 		}
-		numTokensBatched++
 
-		r.RecordNum++
-		if r.maxBatchSize > 0 && numTokensBatched >= r.maxBatchSize {
-			if err = r.emitFunc(ctx, tokenBodies[:numTokensBatched], r.FileAttributes, r.RecordNum, tokenOffsets); err != nil {
-				r.set.Logger.Error("failed to emit token", zap.Error(err))
+		stop := false
+		for i, token := range decodedTokens {
+			piece := max(i-firstPiece(endedRun != nil, marker), 0)
+			tokenBodies[numTokensBatched] = token
+			tokenOffsets[numTokensBatched], tokenOffsets[numTokensBatched+1] = tokenStart, s.Pos()
+			switch {
+			case i == marker:
+				tokenOffsets[numTokensBatched+1] = tokenStart
+				tokenAttributes[numTokensBatched] = sessionBoundaryAttributes()
+			case i == 0 && endedRun != nil:
+				tokenOffsets[numTokensBatched], tokenOffsets[numTokensBatched+1] = endedRun.Offset, endedRun.End
+				tokenAttributes[numTokensBatched] = r.repeatAttributes(endedRun)
+			case errorAttributes != nil:
+				tokenAttributes[numTokensBatched] = errorAttributes
+			default:
+				tokenAttributes[numTokensBatched] = r.tokenAttributes(token, tokenStart, piece)
+			}
+			if r.includeByteRange {
+				tokenAttributes[numTokensBatched] = withAttribute(tokenAttributes[numTokensBatched], attrs.LogFileByteRange,
+					r.byteRange(tokenOffsets[numTokensBatched], tokenOffsets[numTokensBatched+1], piece))
+			}
+			numTokensBatched++
+			batchBytes += len(token)
+
+			if i != marker {
+				r.RecordNum++
+			}
+			r.noteRecordNum(numTokensBatched - 1)
+			if r.batchFull(numTokensBatched, batchBytes) {
+				// Give other processes a chance to lock the file while the batch is being emitted
+				relock := r.acquireFSLock && r.maxFSLockHold > 0 && time.Since(r.lockAcquiredAt) >= r.maxFSLockHold
+				if relock {
+					r.unlockFile()
+				}
+				if err = r.emitBatch(ctx, tokenBodies[:numTokensBatched], tokenAttributes[:numTokensBatched], tokenOffsets); r.emitInterrupted(ctx, err) {
+					r.interruptBatch(err, batch, s.Pos())
+					return false
+				} else if err != nil {
+					r.set.Logger.Error("failed to emit token", zap.Error(err))
+				}
+				numTokensBatched, batchBytes = 0, 0
+				r.Offset, tokenOffsets[0] = s.Pos(), s.Pos()
+				batch, r.batchHeldSince = r.saveBatchState(), time.Time{}
+				r.publishSnapshot()
+				if relock && !r.relockFile() {
+					stop = true
+				}
 			}
-			numTokensBatched = 0
-			r.Offset, tokenOffsets[0] = s.Pos(), s.Pos()
+		}
+		if stop {
+			return false
 		}
 	}
 }
 
-// Delete will close and delete the file
-func (r *Reader) delete() {
-	r.close()
-	if err := os.Remove(r.fileName); err != nil {
-		r.set.Logger.Error("could not delete", zap.String("filename", r.fileName))
+// relockFile reacquires the lock released at a batch boundary. If the lock cannot be
+// reacquired, reading stops and resumes from the current offset on the next poll.
+func (r *Reader) relockFile() bool {
+	if !r.tryLockFile() {
+		r.set.Logger.Debug("failed to reacquire lock, stopping read until next poll")
+		return false
+	}
+	r.lockAcquiredAt = time.Now()
+	return true
+}
+
+func (r *Reader) closeParts() {
+	if r.parts == nil {
+		return
+	}
+	if err := r.parts.Close(r.file); err != nil {
+		r.set.Logger.Debug("problem closing parts", zap.Error(err))
 	}
+	r.parts = nil
 }
 
 // Close will close the file and return the metadata
@@ -288,6 +841,7 @@ func (r *Reader) Close() *Metadata {
 }
 
 func (r *Reader) close() {
+	r.stopPrefetch()
 	if r.file != nil {
 		if err := r.file.Close(); err != nil {
 			r.set.Logger.Debug("Problem closing reader", zap.Error(err))
@@ -304,7 +858,10 @@ func (r *Reader) close() {
 
 // Read from the file and update the fingerprint if necessary
 func (r *Reader) Read(dst []byte) (n int, err error) {
-	n, err = r.reader.Read(dst)
+	n, err = r.readSource(dst)
+	if r.compressedWindow > 0 {
+		r.decompressedBytes += int64(n)
+	}
 	if n == 0 || err != nil {
 		return
 	}
@@ -324,16 +881,29 @@ func (r *Reader) Validate() bool {
 	if r.file == nil {
 		return false
 	}
-	refreshedFingerprint, err := fingerprint.NewFromFile(r.file, r.fingerprintSize, r.compression != "")
+	refreshedFingerprint, err := r.newFingerprint(r.file)
 	if err != nil {
 		return false
 	}
-	if refreshedFingerprint.StartsWith(r.Fingerprint) {
+	if r.fingerprintMatches(refreshedFingerprint) {
 		return true
 	}
 	return false
 }
 
+// SameFile returns true if the reader's file handle refers to the same file as info,
+// as is the case for hard links to the file.
+func (r *Reader) SameFile(info os.FileInfo) bool {
+	if r.file == nil {
+		return false
+	}
+	readerInfo, err := r.file.Stat()
+	if err != nil {
+		return false
+	}
+	return os.SameFile(readerInfo, info)
+}
+
 func (r *Reader) GetFileName() string {
 	return r.fileName
 }
@@ -347,14 +917,25 @@ func (r *Reader) updateFingerprint() {
 	if r.file == nil {
 		return
 	}
-	refreshedFingerprint, err := fingerprint.NewFromFile(r.file, r.fingerprintSize, r.compression != "")
+	refreshedFingerprint, err := r.newFingerprint(r.file)
 	if err != nil {
 		return
 	}
-	if r.Fingerprint.Len() > 0 && !refreshedFingerprint.StartsWith(r.Fingerprint) {
-		return // fingerprint tampered, likely due to truncation
+	if r.Fingerprint.Len() > 0 && !r.fingerprintMatches(refreshedFingerprint) {
+		// fingerprint tampered, likely due to truncation or an edit in place
+		if r.inPlaceEditPolicy != InPlaceEditRestart && r.inPlaceEditPolicy != InPlaceEditStop {
+			// The file is not read again from the start by this reader, but its new content will be
+			r.flagTruncation()
+		}
+		r.inPlaceEdited(refreshedFingerprint)
+		return
+	}
+	if r.hashFingerprint {
+		refreshedFingerprint = refreshedFingerprint.Hashed()
 	}
 	r.Fingerprint = refreshedFingerprint
+	r.assignFileID()
+	r.assignFileIdentity()
 }
 
 func (r *Reader) getBufPtrFromPool() *[]byte {
@@ -365,3 +946,12 @@ func (r *Reader) getBufPtrFromPool() *[]byte {
 	}
 	return bufP.(*[]byte)
 }
+
+// putBufPtrToPool returns a buffer which was used by the scanner to the pool. If configured, the part of it
+// which the scanner wrote to is zeroed first, so that its content cannot be seen by the next reader to use it.
+func (r *Reader) putBufPtrToPool(bufPtr *[]byte, s *scanner.Scanner) {
+	if r.zeroPooledBuffers {
+		clear((*bufPtr)[:s.Used()])
+	}
+	r.bufPool.Put(bufPtr)
+}
@@ -4,9 +4,15 @@
 package reader
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -15,6 +21,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/component/componenttest"
+	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/unicode"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
@@ -332,7 +339,221 @@ func TestUntermintedLogEntryGrows(t *testing.T) {
 	sink.ExpectNoCalls(t)
 }
 
+func TestThroughputProfiles(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	for i := 0; i < 250; i++ {
+		filetest.WriteString(t, temp, fmt.Sprintf("backfill %d\n", i))
+	}
+
+	var batchSizes []int
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		batchSizes = append(batchSizes, len(tokens))
+		return nil
+	})
+	f.BackfillProfile = &ThroughputProfile{MaxBatchSize: 200}
+	f.FollowProfile = &ThroughputProfile{MaxBatchSize: 2}
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	require.False(t, r.CaughtUp)
+
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []int{200, 50}, batchSizes)
+	require.True(t, r.CaughtUp)
+
+	// Trickle appends are emitted in small batches
+	batchSizes = nil
+	for i := 0; i < 5; i++ {
+		filetest.WriteString(t, temp, fmt.Sprintf("follow %d\n", i))
+	}
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []int{2, 2, 1}, batchSizes)
+
+	// A reader recreated from caught up metadata stays in the follow phase
+	batchSizes = nil
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	for i := 0; i < 3; i++ {
+		filetest.WriteString(t, temp, fmt.Sprintf("follow again %d\n", i))
+	}
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []int{2, 1}, batchSizes)
+}
+
+func TestOnBatchEmitted(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "a\nbb\nccc\ndddd\neeeee\n")
+
+	emitDelay := 5 * time.Millisecond
+	f := newTestFactory(t, func(_ context.Context, _ [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		time.Sleep(emitDelay)
+		return nil
+	})
+	f.BackfillProfile = &ThroughputProfile{MaxBatchSize: 2}
+
+	type batch struct {
+		tokenCount int
+		bytes      int
+	}
+	var batches []batch
+	f.OnBatchEmitted = func(tokenCount, bytes int, duration time.Duration) {
+		batches = append(batches, batch{tokenCount: tokenCount, bytes: bytes})
+		assert.GreaterOrEqual(t, duration, emitDelay)
+		assert.Less(t, duration, time.Second)
+	}
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []batch{{2, 3}, {2, 7}, {1, 5}}, batches)
+}
+
+func TestOnBatchEmittedUnsetDoesNotAllocate(t *testing.T) {
+	r := &Reader{Metadata: &Metadata{}, emitFunc: func(_ context.Context, _ [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		return nil
+	}}
+	g := &emitGroup{tokens: [][]byte{[]byte("a"), []byte("b")}, lastRecordNum: 2, offsets: []int64{0, 2, 4}}
+	allocs := testing.AllocsPerRun(100, func() {
+		g.next = 0
+		require.NoError(t, r.emit(context.Background(), g))
+	})
+	require.Zero(t, allocs)
+}
+
+func TestFanOutEmit(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\ntestlog3\n")
+
+	type batch struct {
+		tokens  [][]byte
+		offsets []int64
+	}
+	record := func(batches *[]batch, err error) emit.Callback {
+		return func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, offsets []int64) error {
+			*batches = append(*batches, batch{slices.Clone(tokens), slices.Clone(offsets[:len(tokens)+1])})
+			return err
+		}
+	}
+	var first, second []batch
+	f := newTestFactory(t, record(&first, errors.New("consumer unavailable")))
+	f.FanOut = []emit.Callback{record(&second, nil)}
+	f.BackfillProfile = &ThroughputProfile{MaxBatchSize: 2}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// Both callbacks receive every batch, and the failure of one is handled as any emit error is
+	r.ReadToEnd(context.Background())
+	expected := []batch{
+		{[][]byte{[]byte("testlog1"), []byte("testlog2")}, []int64{0, 9, 18}},
+		{[][]byte{[]byte("testlog3")}, []int64{18, 27}},
+	}
+	assert.Equal(t, expected, first)
+	assert.Equal(t, expected, second)
+	assert.Equal(t, int64(27), r.Offset)
+}
+
+func TestFanOutEmitInterrupted(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	content := "testlog1\ntestlog2\n"
+	filetest.WriteString(t, temp, content)
+
+	var first, second, third [][]byte
+	ctx, cancel := context.WithCancel(context.Background())
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		first = append(first, tokens...)
+		return nil
+	})
+	f.FanOut = []emit.Callback{
+		func(ctx context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+			// The read is cancelled while the batch is passed to the second callback for the first time
+			if second == nil {
+				second = [][]byte{}
+				cancel()
+				return ctx.Err()
+			}
+			second = append(second, tokens...)
+			return nil
+		},
+		func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+			third = append(third, tokens...)
+			return nil
+		},
+	}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(ctx)
+	expected := [][]byte{[]byte("testlog1"), []byte("testlog2")}
+	assert.Equal(t, expected, first)
+	assert.Empty(t, second)
+	assert.Empty(t, third)
+	assert.Zero(t, r.Offset)
+
+	// On the next read, the batch is only passed to the callbacks which it was not yet passed to
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, expected, first)
+	assert.Equal(t, expected, second)
+	assert.Equal(t, expected, third)
+	assert.Equal(t, int64(len(content)), r.Offset)
+	assert.Equal(t, int64(2), r.RecordNum)
+}
+
+func TestZeroPooledBuffers(t *testing.T) {
+	for _, zero := range []bool{false, true} {
+		t.Run(fmt.Sprintf("zero=%t", zero), func(t *testing.T) {
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+			f, sink := testFactory(t)
+			f.ZeroPooledBuffers = zero
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+
+			bufP := f.BufPool.Get()
+			if bufP == nil {
+				// The race detector makes the pool drop some of the buffers which are returned to it
+				t.Skip("the buffer was not kept by the pool")
+			}
+			buf := (*bufP.(*[]byte))[:cap(*bufP.(*[]byte))]
+			if zero {
+				assert.Equal(t, make([]byte, len(buf)), buf)
+			} else {
+				assert.Equal(t, "testlog1\ntestlog2\n", string(buf[:18]))
+			}
+		})
+	}
+}
+
 func BenchmarkFileRead(b *testing.B) {
+	benchmarkFileRead(b, func(*Factory) {})
+}
+
+func BenchmarkFileReadZeroPooledBuffers(b *testing.B) {
+	benchmarkFileRead(b, func(f *Factory) { f.ZeroPooledBuffers = true })
+}
+
+func benchmarkFileRead(b *testing.B, configure func(*Factory)) {
 	tempDir := b.TempDir()
 
 	temp := filetest.OpenTemp(b, tempDir)
@@ -351,6 +572,7 @@ func BenchmarkFileRead(b *testing.B) {
 		counter.Add(int64(len(tokens)))
 		return nil
 	})
+	configure(f)
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -386,3 +608,276 @@ func newTestFactory(tb testing.TB, callback emit.Callback) *Factory {
 		},
 	}
 }
+
+func TestTimestampDeltas(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "2024-01-01T00:00:00Z first\n2024-01-01T00:00:01Z second\nno timestamp\n2024-01-01T00:00:03.5Z fourth\n")
+
+	f, sink := testFactory(t)
+	f.TimestampParser = func(token []byte) (time.Time, bool) {
+		prefix, _, _ := bytes.Cut(token, []byte(" "))
+		ts, err := time.Parse(time.RFC3339Nano, string(prefix))
+		return ts, err == nil
+	}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	fileName := filepath.Base(temp.Name())
+	sink.ExpectCall(t, []byte("2024-01-01T00:00:00Z first"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectCall(t, []byte("2024-01-01T00:00:01Z second"), map[string]any{
+		attrs.LogFileName:    fileName,
+		attrs.LogFileDeltaNs: time.Second.Nanoseconds(),
+	})
+	// Lines without a timestamp do not have a delta or update the baseline
+	sink.ExpectCall(t, []byte("no timestamp"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectCall(t, []byte("2024-01-01T00:00:03.5Z fourth"), map[string]any{
+		attrs.LogFileName:    fileName,
+		attrs.LogFileDeltaNs: (2500 * time.Millisecond).Nanoseconds(),
+	})
+	sink.ExpectNoCalls(t)
+
+	// The baseline carries over to subsequent reads of the file
+	filetest.WriteString(t, temp, "2024-01-01T00:00:04Z fifth\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("2024-01-01T00:00:04Z fifth"), map[string]any{
+		attrs.LogFileName:    fileName,
+		attrs.LogFileDeltaNs: (500 * time.Millisecond).Nanoseconds(),
+	})
+	sink.ExpectNoCalls(t)
+}
+
+func TestScanTime(t *testing.T) {
+	clock := internaltime.NewAlwaysIncreasingClock()
+	internaltime.Now = clock.Now
+	internaltime.Since = clock.Since
+	defer func() {
+		internaltime.Now = time.Now
+		internaltime.Since = time.Since
+	}()
+
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "first\nsecond\nthird\n")
+
+	var bodies []string
+	var scanTimes []int64
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, attributes map[string]any, _ int64, _ []int64) error {
+		for _, token := range tokens {
+			bodies = append(bodies, string(token))
+			scanTimes = append(scanTimes, attributes[attrs.LogFileScanTimeUnixNano].(int64))
+		}
+		return nil
+	})
+	f.IncludeScanTime = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+
+	// The tokens are read in a single batch, but each is stamped with the time it was scanned
+	require.Equal(t, []string{"first", "second", "third"}, bodies)
+	require.Less(t, scanTimes[0], scanTimes[1])
+	require.Less(t, scanTimes[1], scanTimes[2])
+}
+
+func TestPollCycle(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "first\nsecond\n")
+
+	f, sink := testFactory(t)
+	f.IncludePollCycle = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	fileName := r.FileAttributes[attrs.LogFileName]
+	sink.ExpectCall(t, []byte("first"), map[string]any{attrs.LogFileName: fileName, attrs.LogFilePollCycle: int64(1)})
+	sink.ExpectCall(t, []byte("second"), map[string]any{attrs.LogFileName: fileName, attrs.LogFilePollCycle: int64(1)})
+
+	// The count is kept when the reader is recreated for the next poll, and polls which read nothing are counted
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	filetest.WriteString(t, temp, "third\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("third"), map[string]any{attrs.LogFileName: fileName, attrs.LogFilePollCycle: int64(3)})
+	sink.ExpectNoCalls(t)
+}
+
+func TestVarintDelimitedRecords(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+
+	var data []byte
+	records := []string{"first", "\x00binary\n\xff", strings.Repeat("x", 200), "last"}
+	for _, record := range records {
+		data = binary.AppendUvarint(data, uint64(len(record)))
+		data = append(data, record...)
+	}
+
+	f, sink := testFactory(t, withInitialBufferSize(8), withFlushPeriod(0))
+	f.SplitFunc = split.VarintDelimitedSplitFunc(f.MaxLogSize)
+	f.Encoding = encoding.Nop
+	f.TrimFunc = trim.Nop
+
+	// Write the stream in pieces which break up the varints and the records
+	filetest.WriteString(t, temp, string(data[:3]))
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+
+	cut := 6 + 12 + 1 // the first varint byte of the third record
+	filetest.WriteString(t, temp, string(data[3:cut]))
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte(records[0]), []byte(records[1]))
+	sink.ExpectNoCalls(t)
+
+	filetest.WriteString(t, temp, string(data[cut:]))
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte(records[2]), []byte(records[3]))
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, int64(len(data)), r.Offset)
+}
+
+func TestEscapedTerminatorRecords(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	f, sink := testFactory(t, withFlushPeriod(0))
+	f.SplitFunc = split.EscapedTerminatorSplitFunc(';', '\\', false)
+
+	// The escape is written in one poll, and the terminator which it escapes in the next
+	filetest.WriteString(t, temp, `first;second\`)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("first"))
+	sink.ExpectNoCalls(t)
+
+	filetest.WriteString(t, temp, `;still second;unterminated`)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte(`second\;still second`))
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, int64(len(`first;second\;still second;`)), r.Offset)
+}
+
+func TestFirstRecord(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "first\nsecond\n")
+
+	f, sink := testFactory(t)
+	f.IncludeFileFirstRecord = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	fileName := filepath.Base(temp.Name())
+	sink.ExpectCall(t, []byte("first"), map[string]any{attrs.LogFileName: fileName, attrs.LogFileFirstRecord: true})
+	sink.ExpectCall(t, []byte("second"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectNoCalls(t)
+
+	// Reading resumed from persisted metadata does not flag another record
+	encoded, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	filetest.WriteString(t, temp, "third\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte("third"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectNoCalls(t)
+}
+
+func TestCumulativeCounters(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "one\ntwo\nthree\nfour\nfive\n")
+
+	type counters struct {
+		records int64
+		bytes   int64
+	}
+	var batches []counters
+	f := newTestFactory(t, func(_ context.Context, _ [][]byte, attributes map[string]any, lastRecordNumber int64, _ []int64) error {
+		records := attributes[attrs.LogFileCumulativeRecords].(int64)
+		assert.Equal(t, lastRecordNumber, records)
+		batches = append(batches, counters{records, attributes[attrs.LogFileCumulativeBytes].(int64)})
+		return nil
+	})
+	f.IncludeCumulativeCounters = true
+	f.BackfillProfile = &ThroughputProfile{MaxBatchSize: 2}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []counters{{2, 6}, {4, 15}, {5, 19}}, batches)
+
+	// The counters carry on from persisted metadata
+	encoded, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	filetest.WriteString(t, temp, "six\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []counters{{2, 6}, {4, 15}, {5, 19}, {6, 22}}, batches)
+	for i := 1; i < len(batches); i++ {
+		assert.Greater(t, batches[i].records, batches[i-1].records)
+		assert.Greater(t, batches[i].bytes, batches[i-1].bytes)
+	}
+}
+
+func TestCumulativeBytesDroppedTokens(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "one\n"+strings.Repeat("a", 30)+"\ntwo\n")
+
+	var cumulativeBytes []int64
+	f := newTestFactory(t, func(_ context.Context, _ [][]byte, attributes map[string]any, _ int64, _ []int64) error {
+		cumulativeBytes = append(cumulativeBytes, attributes[attrs.LogFileCumulativeBytes].(int64))
+		return nil
+	})
+	f.IncludeCumulativeCounters = true
+	f.MaxLineLength = 20
+	f.LineLengthPolicy = DecodedSizePolicyDrop
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The dropped line is not counted
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []int64{6}, cumulativeBytes)
+	assert.Equal(t, int64(6), r.CumulativeBytes)
+}
@@ -0,0 +1,111 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unix && !aix && !solaris
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestMaxFSLockHold(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	for i := 0; i < 6; i++ {
+		filetest.WriteString(t, temp, fmt.Sprintf("line %d\n", i))
+	}
+
+	// A separate handle is used to probe the lock held by the reader
+	probe := filetest.OpenFile(t, temp.Name())
+	canLock := func() bool {
+		if err := unix.Flock(int(probe.Fd()), unix.LOCK_EX|unix.LOCK_NB); err != nil {
+			return false
+		}
+		require.NoError(t, unix.Flock(int(probe.Fd()), unix.LOCK_UN))
+		return true
+	}
+
+	var batches [][]string
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		// The lock is released while the batch is being emitted
+		assert.True(t, canLock())
+		batch := make([]string, 0, len(tokens))
+		for _, token := range tokens {
+			batch = append(batch, string(token))
+		}
+		batches = append(batches, batch)
+		return nil
+	})
+	splitFunc := f.SplitFunc
+	f.SplitFunc = func(data []byte, atEOF bool) (int, []byte, error) {
+		// The lock is held while the file is being scanned
+		assert.False(t, canLock())
+		return splitFunc(data, atEOF)
+	}
+	f.AcquireFSLock = true
+	f.MaxFSLockHold = time.Nanosecond
+	f.BackfillProfile = &ThroughputProfile{MaxBatchSize: 2}
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	require.Equal(t, [][]string{{"line 0", "line 1"}, {"line 2", "line 3"}, {"line 4", "line 5"}}, batches)
+	assert.True(t, canLock())
+}
+
+func TestMaxFSLockHoldRelockFails(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	for i := 0; i < 4; i++ {
+		filetest.WriteString(t, temp, fmt.Sprintf("line %d\n", i))
+	}
+
+	// Another process grabs the lock as soon as the reader releases it
+	other, err := os.Open(temp.Name())
+	require.NoError(t, err)
+	defer other.Close()
+
+	var batches int
+	f := newTestFactory(t, func(_ context.Context, _ [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		batches++
+		if batches == 1 {
+			require.NoError(t, unix.Flock(int(other.Fd()), unix.LOCK_EX|unix.LOCK_NB))
+		}
+		return nil
+	})
+	f.AcquireFSLock = true
+	f.MaxFSLockHold = time.Nanosecond
+	f.BackfillProfile = &ThroughputProfile{MaxBatchSize: 2}
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// Reading stops after the first batch because the lock cannot be reacquired
+	r.ReadToEnd(context.Background())
+	require.Equal(t, 1, batches)
+	require.Equal(t, int64(len("line 0\nline 1\n")), r.Offset)
+
+	// Reading resumes where it left off once the lock is available again
+	require.NoError(t, unix.Flock(int(other.Fd()), unix.LOCK_UN))
+	r.ReadToEnd(context.Background())
+	require.Equal(t, 2, batches)
+	require.Equal(t, int64(len("line 0\nline 1\nline 2\nline 3\n")), r.Offset)
+}
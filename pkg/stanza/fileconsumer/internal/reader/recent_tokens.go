@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "sync"
+
+// tokenRing retains copies of the most recently emitted tokens of a file.
+// It is safe for concurrent use.
+type tokenRing struct {
+	mu     sync.Mutex
+	tokens [][]byte
+	next   int
+	full   bool
+}
+
+func newTokenRing(size int) *tokenRing {
+	return &tokenRing{tokens: make([][]byte, size)}
+}
+
+// add copies tokens into the ring, evicting the oldest tokens once it is full.
+func (t *tokenRing) add(tokens [][]byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, token := range tokens {
+		// Reuse the evicted token's storage where possible
+		t.tokens[t.next] = append(t.tokens[t.next][:0], token...)
+		t.next++
+		if t.next == len(t.tokens) {
+			t.next = 0
+			t.full = true
+		}
+	}
+}
+
+// get returns copies of the retained tokens, oldest first.
+func (t *tokenRing) get() [][]byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	oldest, count := 0, t.next
+	if t.full {
+		oldest, count = t.next, len(t.tokens)
+	}
+	result := make([][]byte, count)
+	for i := range result {
+		result[i] = append([]byte(nil), t.tokens[(oldest+i)%len(t.tokens)]...)
+	}
+	return result
+}
+
+// RecentTokens returns the most recently emitted tokens of the file, oldest first, or nil if the factory
+// was not configured to retain them. A reader which was closed has no metadata, and so retains nothing.
+func (m *Metadata) RecentTokens() [][]byte {
+	if m == nil || m.recentTokens == nil {
+		return nil
+	}
+	return m.recentTokens.get()
+}
@@ -0,0 +1,114 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestTokenRing(t *testing.T) {
+	ring := newTokenRing(3)
+	assert.Empty(t, ring.get())
+
+	ring.add([][]byte{[]byte("a"), []byte("b")})
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b")}, ring.get())
+
+	ring.add([][]byte{[]byte("c"), []byte("d"), []byte("e")})
+	assert.Equal(t, [][]byte{[]byte("c"), []byte("d"), []byte("e")}, ring.get())
+
+	ring.add([][]byte{[]byte("f")})
+	assert.Equal(t, [][]byte{[]byte("d"), []byte("e"), []byte("f")}, ring.get())
+}
+
+func TestTokenRingCopiesTokens(t *testing.T) {
+	ring := newTokenRing(2)
+	token := []byte("original")
+	ring.add([][]byte{token})
+
+	// Emitted tokens may be backed by a buffer which is reused
+	copy(token, "modified")
+	got := ring.get()
+	assert.Equal(t, [][]byte{[]byte("original")}, got)
+
+	// Callers may not mutate the retained tokens
+	copy(got[0], "modified")
+	assert.Equal(t, [][]byte{[]byte("original")}, ring.get())
+}
+
+func TestTokenRingConcurrentAccess(t *testing.T) {
+	ring := newTokenRing(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ring.add([][]byte{[]byte(fmt.Sprintf("token %d", j))})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.LessOrEqual(t, len(ring.get()), 10)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, ring.get(), 10)
+}
+
+func TestRecentTokens(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	for i := 0; i < 5; i++ {
+		filetest.WriteString(t, temp, fmt.Sprintf("line %d\n", i))
+	}
+
+	f, sink := testFactory(t)
+	f.RecentTokensSize = 3
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("line 0"), []byte("line 1"), []byte("line 2"), []byte("line 3"), []byte("line 4"))
+	assert.Equal(t, [][]byte{[]byte("line 2"), []byte("line 3"), []byte("line 4")}, r.RecentTokens())
+
+	// The recent tokens are retained across readers created from the same metadata, but not by the closed reader
+	m := r.Close()
+	assert.Nil(t, r.RecentTokens())
+	filetest.WriteString(t, temp, "line 5\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("line 5"))
+	assert.Equal(t, [][]byte{[]byte("line 3"), []byte("line 4"), []byte("line 5")}, r.RecentTokens())
+}
+
+func TestRecentTokensUnset(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "line\n")
+
+	f, sink := testFactory(t)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("line"))
+	assert.Nil(t, r.RecentTokens())
+}
@@ -0,0 +1,21 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"encoding/binary"
+)
+
+// recordID identifies a piece of the token which starts at the given offset by combining the offset and the index
+// of the piece with the fingerprint of the file. The fingerprint does not change once it is full, so the same file
+// content produces the same record ids, including across restarts. The ids of the records of a file which is
+// shorter than the fingerprint change as the file grows.
+func (r *Reader) recordID(offset int64, piece int) []byte {
+	fp := r.Fingerprint.Bytes()
+	id := make([]byte, len(fp)+16)
+	copy(id, fp)
+	binary.BigEndian.PutUint64(id[len(fp):], uint64(offset))
+	binary.BigEndian.PutUint64(id[len(fp)+8:], uint64(piece))
+	return id
+}
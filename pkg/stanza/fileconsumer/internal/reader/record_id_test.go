@@ -0,0 +1,123 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestUUIDNamespace(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "same line\nsame line\nanother line\n")
+
+	namespace := uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8")
+	readUUIDs := func() []string {
+		f, sink := testFactory(t)
+		f.UUIDNamespace = &namespace
+		file := filetest.OpenFile(t, temp.Name())
+		fp, err := f.NewFingerprint(file)
+		require.NoError(t, err)
+		r, err := f.NewReader(file, fp)
+		require.NoError(t, err)
+		defer r.Close()
+
+		r.ReadToEnd(context.Background())
+		var ids []string
+		for i := 0; i < 3; i++ {
+			_, attributes := sink.NextCall(t)
+			require.Contains(t, attributes, attrs.LogFileUUID)
+			ids = append(ids, attributes[attrs.LogFileUUID].(string))
+		}
+		sink.ExpectNoCalls(t)
+		return ids
+	}
+
+	first := readUUIDs()
+	for _, id := range first {
+		parsed, err := uuid.Parse(id)
+		require.NoError(t, err)
+		require.Equal(t, uuid.Version(5), parsed.Version())
+	}
+
+	// Identical lines at different offsets have distinct ids
+	require.Len(t, map[string]struct{}{first[0]: {}, first[1]: {}, first[2]: {}}, 3)
+
+	// Reading the same file again produces the same ids
+	require.Equal(t, first, readUUIDs())
+}
+
+func TestUUIDStable(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	// The first line fills the fingerprint, so the fingerprint is final
+	const fingerprintSize = 16
+	filetest.WriteString(t, temp, strings.Repeat("a", fingerprintSize)+"\naaaaabbbbbcc\n")
+
+	namespace := uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8")
+	f, sink := testFactory(t, withSinkChanSize(200), withFingerprintSize(fingerprintSize))
+	f.UUIDNamespace = &namespace
+	f.MaxDecodedSize = 5
+	f.DecodedSizePolicy = DecodedSizePolicySplit
+	readUUIDs := func(r *Reader, n int) []string {
+		r.ReadToEnd(context.Background())
+		var ids []string
+		for range n {
+			_, attributes := sink.NextCall(t)
+			ids = append(ids, attributes[attrs.LogFileUUID].(string))
+		}
+		sink.ExpectNoCalls(t)
+		return ids
+	}
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	// The line is split into pieces which share its offset, so each piece has its own id
+	first := readUUIDs(r, 4+3)
+	require.Len(t, map[string]struct{}{first[4]: {}, first[5]: {}, first[6]: {}}, 3)
+
+	// The ids of the records read before the file grew are the same
+	filetest.WriteString(t, temp, strings.Repeat("c\n", 100))
+	readUUIDs(r, 100)
+	fp, err = f.NewFingerprint(temp)
+	require.NoError(t, err)
+	reread, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer reread.Close()
+	require.Equal(t, first, readUUIDs(reread, 4+3+100)[:4+3])
+}
+
+func TestUUIDSharedPrefix(t *testing.T) {
+	namespace := uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8")
+	f, sink := testFactory(t)
+	f.UUIDNamespace = &namespace
+
+	// The files share their first line, and differ within the fingerprint
+	banner := strings.Repeat("=", 100) + "\n"
+	var ids []string
+	for _, content := range []string{banner + "first file\n", banner + "second file\n"} {
+		temp := filetest.OpenTemp(t, t.TempDir())
+		filetest.WriteString(t, temp, content)
+		fp, err := f.NewFingerprint(temp)
+		require.NoError(t, err)
+		r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+		require.NoError(t, err)
+		r.ReadToEnd(context.Background())
+		_, attributes := sink.NextCall(t)
+		ids = append(ids, attributes[attrs.LogFileUUID].(string))
+		sink.NextCall(t)
+		r.Close()
+	}
+	require.NotEqual(t, ids[0], ids[1])
+}
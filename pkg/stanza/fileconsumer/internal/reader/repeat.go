@@ -0,0 +1,94 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+)
+
+// RepeatRun is a run of consecutive identical tokens which has not been emitted yet. It is persisted with
+// the rest of the metadata, since the file has already been read past the tokens of the run.
+type RepeatRun struct {
+	Token []byte
+	Count int64
+	// Offset is the start of the first token of the run, and End is the end of the last token of the run
+	Offset int64
+	End    int64
+
+	// heldSince is when the run was started, or restored from a checkpoint
+	heldSince time.Time
+}
+
+func (run *RepeatRun) clone() *RepeatRun {
+	if run == nil {
+		return nil
+	}
+	c := *run
+	return &c
+}
+
+// holdRepeats absorbs a token which repeats the pending run. It returns the tokens to emit, and the run which
+// ended, if any, in which case the first of the tokens is the token of the run. A token which does not repeat the
+// run starts a new one, unless it was split into several tokens, which are emitted as they are.
+func (r *Reader) holdRepeats(tokens [][]byte, offset, end int64) ([][]byte, *RepeatRun) {
+	if !r.coalesceRepeats || len(tokens) == 0 {
+		return tokens, nil
+	}
+	if len(tokens) > 1 {
+		return r.endRepeats(tokens)
+	}
+
+	if run := r.RepeatRun; run != nil && bytes.Equal(tokens[0], run.Token) {
+		run.Count++
+		run.End = end
+		if r.maxRepeatCount > 0 && run.Count >= r.maxRepeatCount {
+			return r.endRepeats(tokens[:0])
+		}
+		return tokens[:0], nil
+	}
+	// The token is only valid until the next scan
+	next := &RepeatRun{Token: bytes.Clone(tokens[0]), Count: 1, Offset: offset, End: end, heldSince: time.Now()}
+	tokens, ended := r.endRepeats(tokens[:0])
+	r.RepeatRun = next
+	return tokens, ended
+}
+
+// endRepeats returns the tokens to emit with the token of the pending run ahead of them, and ends the run.
+func (r *Reader) endRepeats(tokens [][]byte) ([][]byte, *RepeatRun) {
+	run := r.RepeatRun
+	if run == nil {
+		return tokens, nil
+	}
+	r.RepeatRun = nil
+	return append([][]byte{run.Token}, tokens...), run
+}
+
+// flushRepeatsAtEOF ends the pending run at the end of the file, once it has been held for the repeat flush timeout.
+// A run is always ended before the file is deleted, since it could not be continued. It is kept if the scan failed.
+func (r *Reader) flushRepeatsAtEOF(scanErr error) *RepeatRun {
+	run := r.RepeatRun
+	if run == nil || scanErr != nil {
+		return nil
+	}
+	if run.heldSince.IsZero() {
+		run.heldSince = time.Now()
+	}
+	if !r.deleteAtEOF && time.Since(run.heldSince) < r.repeatFlushTimeout {
+		return nil
+	}
+	r.RepeatRun = nil
+	return run
+}
+
+// repeatAttributes returns the attributes of the token of a run, along with the number of times it was repeated.
+func (r *Reader) repeatAttributes(run *RepeatRun) map[string]any {
+	attributes := r.tokenAttributes(run.Token, run.Offset, 0)
+	if run.Count > 1 {
+		attributes = withAttribute(attributes, attrs.LogFileRepeatCount, run.Count)
+	}
+	return attributes
+}
@@ -0,0 +1,107 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestCoalesceRepeats(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "a\na\na\nb\nc\nc\n")
+
+	f, sink := testFactory(t)
+	f.CoalesceRepeats = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The last run is ended at the end of the file
+	r.ReadToEnd(context.Background())
+	fileName := filepath.Base(temp.Name())
+	sink.ExpectCall(t, []byte("a"), map[string]any{attrs.LogFileName: fileName, attrs.LogFileRepeatCount: int64(3)})
+	sink.ExpectCall(t, []byte("b"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectCall(t, []byte("c"), map[string]any{attrs.LogFileName: fileName, attrs.LogFileRepeatCount: int64(2)})
+	sink.ExpectNoCalls(t)
+	require.Nil(t, r.RepeatRun)
+	require.Equal(t, int64(12), r.Offset)
+	require.Equal(t, int64(3), r.RecordNum)
+}
+
+func TestCoalesceRepeatsMaxCount(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "a\na\na\na\na\n")
+
+	f, sink := testFactory(t)
+	f.CoalesceRepeats = true
+	f.MaxRepeatCount = 2
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	fileName := filepath.Base(temp.Name())
+	sink.ExpectCall(t, []byte("a"), map[string]any{attrs.LogFileName: fileName, attrs.LogFileRepeatCount: int64(2)})
+	sink.ExpectCall(t, []byte("a"), map[string]any{attrs.LogFileName: fileName, attrs.LogFileRepeatCount: int64(2)})
+	sink.ExpectCall(t, []byte("a"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectNoCalls(t)
+}
+
+func TestCoalesceRepeatsAcrossReads(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "a\na\n")
+
+	f, sink := testFactory(t)
+	f.CoalesceRepeats = true
+	f.RepeatFlushTimeout = time.Hour
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+
+	// The run is held at the end of the file, since it may continue
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(4), r.Offset)
+
+	// The run is continued after a restart
+	encoded, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	require.Equal(t, &RepeatRun{Token: []byte("a"), Count: 2, Offset: 0, End: 4}, m.RepeatRun)
+	filetest.WriteString(t, temp, "a\nb\n")
+
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	fileName := filepath.Base(temp.Name())
+	sink.ExpectCall(t, []byte("a"), map[string]any{attrs.LogFileName: fileName, attrs.LogFileRepeatCount: int64(3)})
+	sink.ExpectNoCalls(t)
+
+	// Once the option is disabled, the tokens of the pending run are read again
+	m = r.Close()
+	require.Equal(t, int64(8), m.Offset)
+	f.CoalesceRepeats = false
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	require.Nil(t, r.RepeatRun)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("b"))
+	sink.ExpectNoCalls(t)
+}
@@ -0,0 +1,145 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/flush"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/tokenlen"
+)
+
+const defaultMaxResumeSearchSize = 1024 * 1024
+
+// resumeByContent checks that the stored offset still applies to the file, which may not be the case if
+// its name was reused by a new file. If the file no longer starts with the fingerprint, or is shorter than
+// the offset, the fingerprint's content is searched for near the start of the file. If it is found, the
+// offset is moved along with it. Otherwise the file is read again from the start.
+func (r *Reader) resumeByContent(maxSearchSize int) error {
+	info, err := r.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	current, err := r.newFingerprint(r.file)
+	if err != nil {
+		return err
+	}
+	if current.StartsWith(r.Fingerprint) && r.Offset <= info.Size() {
+		return nil
+	}
+
+	// The content of a hashed fingerprint is not retained, so it cannot be searched for
+	if r.Fingerprint.Len() > 0 && !r.Fingerprint.IsHashed() {
+		buf := make([]byte, min(info.Size(), int64(maxSearchSize)))
+		n, err := r.file.ReadAt(buf, 0)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("read: %w", err)
+		}
+		if i := bytes.Index(buf[:n], r.Fingerprint.Bytes()); i > 0 && int64(i)+r.Offset <= info.Size() {
+			r.set.Logger.Info("content has moved within the file, realigning offset", zap.Int64("offset", r.Offset), zap.Int("shift", i))
+			r.Offset += int64(i)
+			r.Fingerprint = current
+			return nil
+		}
+	}
+
+	r.set.Logger.Info("stored offset does not match the content of the file, reading it from the start", zap.Int64("offset", r.Offset), zap.Int64("size", info.Size()))
+	r.resetToStart(current)
+	return nil
+}
+
+// resetToStart discards the progress made through the file, so that it is read again from the start
+// as the content identified by the given fingerprint.
+func (r *Reader) resetToStart(fp *fingerprint.Fingerprint) {
+	r.flagTruncation()
+	r.Fingerprint = fp
+	r.Offset = 0
+	r.DecompressedOffset = 0
+	r.RecordNum = 0
+	r.CumulativeBytes = 0
+	r.BytesRead = 0
+	r.HeaderFinalized = false
+	r.TokenLenState = tokenlen.State{}
+	r.FlushState = flush.State{LastDataChange: time.Now()}
+	r.MidLineOffset = 0
+	r.FileID, r.FileIDSize = "", 0
+	r.FileIdentity, r.FileIdentitySize = "", 0
+	r.assignFileID()
+	r.assignFileIdentity()
+	if r.rotationOverlap != nil {
+		r.rotationOverlap.arm()
+	}
+	// The rest of a batch which is in flight was read from the previous content, but a stuck call still holds the file
+	if r.inFlight != nil {
+		r.inFlight.groups, r.inFlight.after = nil, nil
+	}
+}
+
+// maxLoggedFragmentSize is the most of a skipped partial line which is logged.
+const maxLoggedFragmentSize = 256
+
+// alignResumeOffset checks that the stored offset is at the start of a line, which is the case if the bytes
+// before it are the line terminator. Otherwise the partial line is skipped by moving the offset past the next
+// terminator within the search size. If there is none, the line may still be being written, so the offset is
+// left as it is. An offset at the end of a token which was flushed or split before its terminator is left as
+// it is, since the rest of the line was not emitted.
+func (r *Reader) alignResumeOffset(terminator []byte, maxSearchSize int) error {
+	if r.MidLineOffset == r.Offset {
+		return nil
+	}
+	info, err := r.file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+	if r.Offset > info.Size() {
+		return nil
+	}
+	start := max(r.Offset-int64(len(terminator)), 0)
+	buf := make([]byte, min(info.Size()-start, int64(len(terminator)+maxSearchSize)))
+	n, err := r.file.ReadAt(buf, start)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("read: %w", err)
+	}
+	buf = buf[:n]
+	if r.Offset >= int64(len(terminator)) && bytes.HasPrefix(buf, terminator) {
+		return nil
+	}
+
+	i := bytes.Index(buf[min(1, len(buf)):], terminator)
+	if i < 0 {
+		r.set.Logger.Warn("resume offset is in the middle of a line with no terminator, resuming from it", zap.Int64("offset", r.Offset))
+		return nil
+	}
+	end := start + 1 + int64(i)
+	// The offset may be within the terminator, in which case nothing but the rest of the terminator is skipped
+	fragment := buf[min(r.Offset, end)-start : end-start]
+	r.set.Logger.Warn("resume offset is in the middle of a line, skipping the partial line",
+		zap.Int64("offset", r.Offset), zap.Int64("skipped", end-r.Offset),
+		zap.ByteString("fragment", fragment[:min(len(fragment), maxLoggedFragmentSize)]))
+	r.Offset = end + int64(len(terminator))
+	// Any partial token which was pending belongs to the skipped line
+	r.TokenLenState = tokenlen.State{}
+	r.FlushState = flush.State{LastDataChange: time.Now()}
+	return nil
+}
+
+// trackMidLine wraps a bufio.SplitFunc to record whether the token it returns ends before the line terminator,
+// as it does when it is flushed or split at the maximum log size.
+func (r *Reader) trackMidLine(splitFunc bufio.SplitFunc, terminator []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := splitFunc(data, atEOF)
+		if advance > 0 {
+			r.scanMidLine = !bytes.HasSuffix(data[:advance], terminator)
+		}
+		return advance, token, err
+	}
+}
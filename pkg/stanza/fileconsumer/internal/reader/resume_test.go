@@ -0,0 +1,242 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestResumeByContent(t *testing.T) {
+	testCases := []struct {
+		name           string
+		fingerprint    string
+		offset         int64
+		content        string
+		expectOffset   int64
+		expectTokens   []string
+		expectRecordNo int64
+	}{
+		{
+			name:           "Valid",
+			fingerprint:    "header\n",
+			offset:         int64(len("header\nline1\n")),
+			content:        "header\nline1\nline2\n",
+			expectOffset:   int64(len("header\nline1\n")),
+			expectTokens:   []string{"line2"},
+			expectRecordNo: 2,
+		},
+		{
+			// The name was reused by a new file which starts the same way, but is shorter than the offset
+			name:           "ReusedNameSmallerContent",
+			fingerprint:    "header\n",
+			offset:         int64(len("header\nline1\nline2\nline3\n")),
+			content:        "header\nnew\n",
+			expectOffset:   0,
+			expectTokens:   []string{"header", "new"},
+			expectRecordNo: 2,
+		},
+		{
+			// Content was inserted before what had been read
+			name:           "Realigned",
+			fingerprint:    "line1\n",
+			offset:         int64(len("line1\n")),
+			content:        "inserted\nline1\nline2\n",
+			expectOffset:   int64(len("inserted\nline1\n")),
+			expectTokens:   []string{"line2"},
+			expectRecordNo: 2,
+		},
+		{
+			name:           "NotFound",
+			fingerprint:    "line1\n",
+			offset:         int64(len("line1\n")),
+			content:        "other\n",
+			expectOffset:   0,
+			expectTokens:   []string{"other"},
+			expectRecordNo: 1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+			filetest.WriteString(t, temp, tc.content)
+
+			f, sink := testFactory(t, withFingerprintSize(7))
+			f.ResumeByContent = true
+			m := &Metadata{
+				Fingerprint:    fingerprint.New([]byte(tc.fingerprint)),
+				Offset:         tc.offset,
+				RecordNum:      1,
+				FileAttributes: map[string]any{},
+			}
+			r, err := f.NewReaderFromMetadata(temp, m)
+			require.NoError(t, err)
+			defer r.Close()
+			assert.Equal(t, tc.expectOffset, r.Offset)
+
+			r.ReadToEnd(context.Background())
+			for _, token := range tc.expectTokens {
+				sink.ExpectToken(t, []byte(token))
+			}
+			sink.ExpectNoCalls(t)
+			assert.Equal(t, tc.expectRecordNo, r.RecordNum)
+			assert.Equal(t, []byte(tc.content[:min(7, len(tc.content))]), r.Fingerprint.Bytes())
+		})
+	}
+}
+
+func TestAlignResumeOffset(t *testing.T) {
+	content := "line1\nline2\r\nline3\n"
+	testCases := []struct {
+		name         string
+		terminator   string
+		offset       int64
+		restored     bool
+		expectOffset int64
+		expectTokens []string
+	}{
+		{
+			name:         "AtLineStart",
+			terminator:   "\n",
+			offset:       int64(len("line1\n")),
+			restored:     true,
+			expectOffset: int64(len("line1\n")),
+			expectTokens: []string{"line2", "line3"},
+		},
+		{
+			name:         "MidLine",
+			terminator:   "\n",
+			offset:       int64(len("line1\nli")),
+			restored:     true,
+			expectOffset: int64(len("line1\nline2\r\n")),
+			expectTokens: []string{"line3"},
+		},
+		{
+			name:         "MidFirstLine",
+			terminator:   "\n",
+			offset:       int64(len("l")),
+			restored:     true,
+			expectOffset: int64(len("line1\n")),
+			expectTokens: []string{"line2", "line3"},
+		},
+		{
+			name:         "WithinTerminator",
+			terminator:   "\r\n",
+			offset:       int64(len("line1\nline2\r")),
+			restored:     true,
+			expectOffset: int64(len("line1\nline2\r\n")),
+			expectTokens: []string{"line3"},
+		},
+		{
+			// A partial line is only expected when resuming from a checkpoint
+			name:         "NotRestored",
+			terminator:   "\n",
+			offset:       int64(len("line1\nli")),
+			expectOffset: int64(len("line1\nli")),
+			expectTokens: []string{"ne2", "line3"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, content)
+
+			f, sink := testFactory(t, withFingerprintSize(len(content)))
+			f.ResumeLineTerminator = []byte(tc.terminator)
+			m := &Metadata{
+				Fingerprint:    fingerprint.New([]byte(content)),
+				Offset:         tc.offset,
+				FileAttributes: map[string]any{},
+			}
+			if tc.restored {
+				m.MarkRestored()
+			}
+			r, err := f.NewReaderFromMetadata(temp, m)
+			require.NoError(t, err)
+			defer r.Close()
+			assert.Equal(t, tc.expectOffset, r.Offset)
+
+			r.ReadToEnd(context.Background())
+			for _, token := range tc.expectTokens {
+				sink.ExpectToken(t, []byte(token))
+			}
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
+
+func TestAlignResumeOffsetAfterSplit(t *testing.T) {
+	testCases := []struct {
+		name         string
+		opts         []testFactoryOpt
+		flushTimeout time.Duration
+		content      string
+		expectTokens []string
+		expectOffset int64
+		rest         string
+		expectRest   []string
+	}{
+		{
+			name:         "Flushed",
+			flushTimeout: time.Nanosecond,
+			content:      "line1\npart",
+			expectTokens: []string{"line1", "part"},
+			expectOffset: int64(len("line1\npart")),
+			rest:         "ial\nline2\n",
+			expectRest:   []string{"ial", "line2"},
+		},
+		{
+			name:         "MaxLogSize",
+			opts:         []testFactoryOpt{withMaxLogSize(6)},
+			content:      "line1\nlongline",
+			expectTokens: []string{"line1", "longli"},
+			expectOffset: int64(len("line1\nlongli")),
+			rest:         "\nline2\n",
+			expectRest:   []string{"ne", "line2"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, tc.content)
+
+			f, sink := testFactory(t, tc.opts...)
+			f.ResumeLineTerminator = []byte("\n")
+			f.FlushTimeout = tc.flushTimeout
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+			require.NoError(t, err)
+			for range 2 {
+				r.ReadToEnd(context.Background())
+			}
+			for _, token := range tc.expectTokens {
+				sink.ExpectToken(t, []byte(token))
+			}
+			m := r.Close()
+			require.Equal(t, tc.expectOffset, m.Offset)
+
+			// The offset is in the middle of a line, but the start of the line was emitted, so the rest of it is not skipped
+			filetest.WriteString(t, temp, tc.rest)
+			m.MarkRestored()
+			r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+			require.NoError(t, err)
+			defer r.Close()
+			assert.Equal(t, tc.expectOffset, r.Offset)
+			r.ReadToEnd(context.Background())
+			for _, token := range tc.expectRest {
+				sink.ExpectToken(t, []byte(token))
+			}
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
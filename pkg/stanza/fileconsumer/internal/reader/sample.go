@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// sampling returns true if only a fraction of the tokens of the file are emitted.
+func (r *Reader) sampling() bool {
+	return r.sampleRate > 0 && r.sampleRate < 1
+}
+
+// sample drops the tokens decoded from the token at the given offset unless it is sampled. The decision
+// is made from the identity of the file and the offset of the token, so the same tokens are sampled when the
+// file is read again. The tokens which are dropped are still counted as records, so the record numbers of the
+// sampled tokens are the same as if every token was emitted.
+func (r *Reader) sample(tokens [][]byte, offset int64) [][]byte {
+	if !r.sampling() || len(tokens) == 0 {
+		return tokens
+	}
+	if r.sampled(offset) {
+		return tokens
+	}
+	r.RecordNum += int64(len(tokens))
+	return tokens[:0]
+}
+
+// sampled returns true if the token at the given offset is sampled. The identity of the file does not change
+// once its fingerprint is full, so the decision for a token then does not depend on how much of the file had
+// been written when it was read.
+func (r *Reader) sampled(offset int64) bool {
+	var key [16]byte
+	binary.BigEndian.PutUint64(key[:8], xxhash.Sum64String(r.Identity()))
+	binary.BigEndian.PutUint64(key[8:], uint64(offset))
+	return float64(xxhash.Sum64(key[:])) < r.sampleRate*math.MaxUint64
+}
+
+// noteRecordNum keeps the record number of the i-th token of a batch, since the record numbers of
+// the batch are not contiguous when tokens are sampled out of it. A session boundary keeps the number
+// of the record before it.
+func (r *Reader) noteRecordNum(i int) {
+	if r.batchRecordNums != nil {
+		r.batchRecordNums[i] = r.RecordNum
+	}
+}
+
+// batchRecordNum returns the record number of the i-th token of a batch, which ends with the current record.
+func (r *Reader) batchRecordNum(i, batchLen int) int64 {
+	if r.batchRecordNums != nil {
+		return r.batchRecordNums[i]
+	}
+	return r.RecordNum - int64(batchLen-i-1)
+}
@@ -0,0 +1,127 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestSampleRate(t *testing.T) {
+	const numLines = 2000
+	var content strings.Builder
+	for i := range numLines {
+		fmt.Fprintf(&content, "line %d\n", i+1)
+	}
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, content.String())
+
+	// The record number of each token is the line number, whether or not the lines before it were sampled
+	read := func(m *Metadata) ([]string, *Metadata) {
+		var sampled []string
+		f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, lastRecordNum int64, _ []int64) error {
+			for i, token := range tokens {
+				require.Equal(t, fmt.Sprintf("line %d", lastRecordNum-int64(len(tokens)-i-1)), string(token))
+				sampled = append(sampled, string(token))
+			}
+			return nil
+		})
+		f.SampleRate = 0.25
+		file := filetest.OpenFile(t, temp.Name())
+		var r *Reader
+		if m == nil {
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err = f.NewReader(file, fp)
+			require.NoError(t, err)
+		} else {
+			var err error
+			r, err = f.NewReaderFromMetadata(file, m)
+			require.NoError(t, err)
+		}
+		r.ReadToEnd(context.Background())
+		return sampled, r.Close()
+	}
+
+	sampled, m := read(nil)
+	require.InDelta(t, numLines/4, len(sampled), numLines/20)
+	require.Equal(t, int64(numLines), m.RecordNum)
+	require.Equal(t, int64(len(content.String())), m.Offset)
+
+	// The same lines are sampled when the file is read again
+	m.Offset, m.RecordNum = 0, 0
+	resampled, _ := read(m)
+	require.Equal(t, sampled, resampled)
+}
+
+func TestSampleRateDisabled(t *testing.T) {
+	for _, rate := range []float64{0, 1} {
+		t.Run(fmt.Sprint(rate), func(t *testing.T) {
+			f, sink := testFactory(t)
+			f.SampleRate = rate
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, "a\nb\nc\n")
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+			require.NoError(t, err)
+			defer r.Close()
+
+			r.ReadToEnd(context.Background())
+			sink.ExpectTokens(t, []byte("a"), []byte("b"), []byte("c"))
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
+
+func TestSampleRateGrowingFile(t *testing.T) {
+	const numLines = 400
+	lines := make([]string, numLines)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d\n", i+1)
+	}
+	temp := filetest.OpenTemp(t, t.TempDir())
+
+	var sampled []string
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		for _, token := range tokens {
+			sampled = append(sampled, string(token))
+		}
+		return nil
+	})
+	f.SampleRate = 0.25
+	f.FingerprintSize = identityTestSize
+
+	// The file is first read once it fills the fingerprint, and then grows
+	filetest.WriteString(t, temp, strings.Join(lines[:20], ""))
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	filetest.WriteString(t, temp, strings.Join(lines[20:], ""))
+	r.ReadToEnd(context.Background())
+	r.Close()
+	grown := sampled
+
+	// The file is read again from the start
+	sampled = nil
+	fp, err = f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err = f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	require.NotEmpty(t, sampled)
+	require.Equal(t, grown, sampled)
+
+	// The decision does not allocate for each token
+	require.Zero(t, testing.AllocsPerRun(100, func() { r.sampled(10) }))
+}
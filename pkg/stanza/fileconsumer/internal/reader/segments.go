@@ -0,0 +1,178 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// segmentReader reads a file which is a concatenation of differently compressed segments, as written by some
+// misbehaving tools. When a segment ends, the codec of the next one is sniffed from its magic number. If there is
+// no known magic number, the rest of the file is read as it is.
+type segmentReader struct {
+	src     *bufio.Reader
+	gzip    *gzip.Reader
+	zstd    *zstd.Decoder
+	frame   zstdFrameReader
+	current io.Reader
+}
+
+// newSegmentReader reads the segments of src, of which the first is read by gzipReader.
+func newSegmentReader(src *bufio.Reader, gzipReader *gzip.Reader) *segmentReader {
+	// The gzip reader reads exactly one member when it is given an io.ByteReader, so the next segment follows it
+	gzipReader.Multistream(false)
+	return &segmentReader{src: src, gzip: gzipReader, current: gzipReader}
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	for {
+		n, err := s.current.Read(p)
+		if !errors.Is(err, io.EOF) {
+			return n, err
+		}
+		// The end of a segment is not the end of the file
+		if n > 0 {
+			return n, nil
+		}
+		if err = s.next(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (s *segmentReader) next() error {
+	magic, _ := s.src.Peek(4)
+	if len(magic) == 0 {
+		return io.EOF
+	}
+	// The end of the file is reached before a magic number could be completed, so it cannot start a segment
+	codec, _ := sniffCompression(magic)
+	switch codec {
+	case detectedGzip:
+		if err := s.gzip.Reset(s.src); err != nil {
+			return err
+		}
+		s.gzip.Multistream(false)
+		s.current = s.gzip
+	case detectedZstd:
+		// The decoder reads every frame which follows, so it is given one frame at a time. With a concurrency
+		// of one it decodes on the caller's goroutine, so it does not need to be closed.
+		s.frame = zstdFrameReader{src: s.src}
+		if s.zstd == nil {
+			decoder, err := zstd.NewReader(&s.frame, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				return err
+			}
+			s.zstd = decoder
+		} else if err := s.zstd.Reset(&s.frame); err != nil {
+			return err
+		}
+		s.current = s.zstd
+	default:
+		s.current = s.src
+	}
+	return nil
+}
+
+const (
+	zstdFrameHeader = iota
+	zstdBlockHeader
+	zstdChecksum
+	zstdFrameEnd
+)
+
+var errZstdReservedBlock = errors.New("zstd: reserved block type")
+
+// zstdFrameReader reads a single zstd frame from src, and returns io.EOF at its end without reading past it.
+// The length of the frame is found by walking its header and block headers, as laid out in RFC 8878. Skippable
+// frames do not start with the zstd magic number, so they are not read as zstd segments.
+type zstdFrameReader struct {
+	src       *bufio.Reader
+	part      int
+	remaining int
+	checksum  bool
+}
+
+func (z *zstdFrameReader) Read(p []byte) (int, error) {
+	for z.remaining == 0 {
+		if err := z.nextPart(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := z.src.Read(p[:min(len(p), z.remaining)])
+	z.remaining -= n
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// nextPart finds the length of the next part of the frame.
+func (z *zstdFrameReader) nextPart() error {
+	switch z.part {
+	case zstdFrameHeader:
+		b, err := z.peek(5)
+		if err != nil {
+			return err
+		}
+		descriptor := b[4]
+		singleSegment := descriptor&0x20 != 0
+		z.checksum = descriptor&0x04 != 0
+		size := 5 + [4]int{0, 1, 2, 4}[descriptor&0x03]
+		if !singleSegment {
+			// window descriptor
+			size++
+		}
+		switch contentSize := descriptor >> 6; {
+		case contentSize == 0 && singleSegment:
+			size++
+		case contentSize > 0:
+			size += 1 << contentSize
+		}
+		z.remaining, z.part = size, zstdBlockHeader
+	case zstdBlockHeader:
+		b, err := z.peek(3)
+		if err != nil {
+			return err
+		}
+		header := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+		size := int(header >> 3)
+		switch blockType := (header >> 1) & 0x03; blockType {
+		case 1:
+			// An RLE block holds a single byte which is repeated
+			size = 1
+		case 3:
+			return errZstdReservedBlock
+		}
+		z.remaining = 3 + size
+		if header&0x01 != 0 {
+			z.part = zstdChecksum
+		}
+	case zstdChecksum:
+		z.part = zstdFrameEnd
+		if z.checksum {
+			z.remaining = 4
+		}
+	default:
+		return io.EOF
+	}
+	return nil
+}
+
+func (z *zstdFrameReader) peek(n int) ([]byte, error) {
+	b, err := z.src.Peek(n)
+	if len(b) < n {
+		if err == nil || errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("zstd: %w", err)
+	}
+	return b, nil
+}
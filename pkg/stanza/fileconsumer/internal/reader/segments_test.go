@@ -0,0 +1,123 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func appendGzipMember(t *testing.T, buf *bytes.Buffer, content string) {
+	gzipWriter := gzip.NewWriter(buf)
+	_, err := gzipWriter.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gzipWriter.Close())
+}
+
+func TestMixedCompression(t *testing.T) {
+	var buf bytes.Buffer
+	appendGzipMember(t, &buf, "gzip line 1\n")
+	appendGzipMember(t, &buf, "gzip line 2\n")
+	buf.WriteString("plain line\n")
+	name := filepath.Join(t.TempDir(), "app.log.gz")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o600))
+
+	f, sink := testFactory(t)
+	f.Compression = "gzip"
+	f.MixedCompression = true
+	file := filetest.OpenFile(t, name)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("gzip line 1"), []byte("gzip line 2"), []byte("plain line"))
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(buf.Len()), r.Offset)
+}
+
+func appendZstdFrame(t *testing.T, buf *bytes.Buffer, content string, opts ...zstd.EOption) {
+	zstdWriter, err := zstd.NewWriter(buf, opts...)
+	require.NoError(t, err)
+	_, err = zstdWriter.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, zstdWriter.Close())
+}
+
+func TestMixedCompressionZstd(t *testing.T) {
+	var buf bytes.Buffer
+	appendGzipMember(t, &buf, "gzip line 1\n")
+	appendZstdFrame(t, &buf, "zstd line 1\n")
+	// A frame with a checksum, which is larger than a block
+	appendZstdFrame(t, &buf, strings.Repeat("zstd line 2\n", 20000), zstd.WithEncoderCRC(true))
+	appendZstdFrame(t, &buf, "zstd line 3\n", zstd.WithEncoderCRC(false), zstd.WithSingleSegment(true))
+	appendGzipMember(t, &buf, "gzip line 2\n")
+	buf.WriteString("plain line\n")
+	name := filepath.Join(t.TempDir(), "app.log.gz")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o600))
+
+	f, sink := testFactory(t, withSinkChanSize(20010))
+	f.Compression = "gzip"
+	f.MixedCompression = true
+	file := filetest.OpenFile(t, name)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	expected := [][]byte{[]byte("gzip line 1"), []byte("zstd line 1")}
+	for range 20000 {
+		expected = append(expected, []byte("zstd line 2"))
+	}
+	expected = append(expected, []byte("zstd line 3"), []byte("gzip line 2"), []byte("plain line"))
+	sink.ExpectTokens(t, expected...)
+	sink.ExpectNoCalls(t)
+	require.Equal(t, int64(buf.Len()), r.Offset)
+}
+
+func TestMixedCompressionTruncatedZstd(t *testing.T) {
+	var buf bytes.Buffer
+	appendGzipMember(t, &buf, "gzip line\n")
+	// Only the start of a zstd frame follows
+	buf.Write([]byte{0x28, 0xb5, 0x2f, 0xfd, 0x24, 0x0a, 0x51, 0x00})
+	name := filepath.Join(t.TempDir(), "app.log.gz")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o600))
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+	f, sink := testFactory(t)
+	f.TelemetrySettings.Logger = zap.New(core)
+	f.Compression = "gzip"
+	f.MixedCompression = true
+	file := filetest.OpenFile(t, name)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+	r, err := f.NewReader(file, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("gzip line"))
+	sink.ExpectNoCalls(t)
+	scanErrors := logs.FilterMessage("failed during scan").All()
+	require.Len(t, scanErrors, 1)
+	require.Contains(t, scanErrors[0].ContextMap()["error"], io.ErrUnexpectedEOF.Error())
+}
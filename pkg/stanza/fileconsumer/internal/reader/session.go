@@ -0,0 +1,73 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"slices"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+)
+
+// SessionBoundaryEvent is the value of the event attribute of the record emitted between two sessions.
+const SessionBoundaryEvent = "session_boundary"
+
+// Sessionizer groups the tokens of a file into sessions which are separated by gaps in their timestamps.
+// The timestamp is located by the locator, which defaults to the start of the token, with the length of
+// the layout. When the timestamp of a token is more than MaxGap after the timestamp of the previous token
+// which had one, an empty record flagged with the session boundary event is emitted before the token.
+// The last timestamp is kept with the metadata of the file, so a gap across a restart is also marked.
+type Sessionizer struct {
+	TimestampLocator
+	Layout string
+	MaxGap time.Duration
+}
+
+func (s *Sessionizer) timestamp(token []byte) (time.Time, bool) {
+	locator := s.TimestampLocator
+	if locator.Pattern == nil && locator.End == 0 {
+		locator.End = locator.Start + len(s.Layout)
+	}
+	value := locator.locate(token)
+	if len(value) == 0 {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(s.Layout, string(value))
+	return ts, err == nil
+}
+
+// markSessionBoundary inserts an empty token before the first of the decoded tokens at or after first, if its
+// timestamp is too long after the last one. It returns the index of the inserted token, or -1 if there is none.
+// Tokens without a timestamp which can be parsed do not start a session and do not end one. The inserted
+// token is not a record, so it does not take a record number. The timestamp is only kept with the metadata once
+// the batch it was read in has been emitted.
+func (r *Reader) markSessionBoundary(decodedTokens [][]byte, first int) ([][]byte, int) {
+	if r.sessionizer == nil || first >= len(decodedTokens) {
+		return decodedTokens, -1
+	}
+	ts, ok := r.sessionizer.timestamp(decodedTokens[first])
+	if !ok {
+		return decodedTokens, -1
+	}
+	last := r.SessionTimestamp
+	if !r.sessionPending.IsZero() {
+		last = r.sessionPending
+	}
+	r.sessionPending = ts
+	if last.IsZero() || ts.Sub(last) <= r.sessionizer.MaxGap {
+		return decodedTokens, -1
+	}
+	return slices.Insert(decodedTokens, first, []byte{}), first
+}
+
+// recordSession keeps the timestamp staged by markSessionBoundary, once the batches it was read in have been emitted.
+func (r *Reader) recordSession() {
+	if !r.sessionPending.IsZero() {
+		r.SessionTimestamp, r.sessionPending = r.sessionPending, time.Time{}
+	}
+}
+
+func sessionBoundaryAttributes() map[string]any {
+	return map[string]any{attrs.LogFileEvent: SessionBoundaryEvent}
+}
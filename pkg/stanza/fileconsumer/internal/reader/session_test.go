@@ -0,0 +1,179 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestSessionBoundary(t *testing.T) {
+	testCases := []struct {
+		name        string
+		sessionizer *Sessionizer
+		lines       []string
+	}{
+		{
+			name: "pattern",
+			sessionizer: &Sessionizer{
+				TimestampLocator: TimestampLocator{Pattern: regexp.MustCompile(`ts=(\S+)`)},
+				Layout:           time.RFC3339,
+				MaxGap:           time.Minute,
+			},
+			lines: []string{
+				"ts=2024-01-01T00:00:00Z first",
+				"ts=2024-01-01T00:00:30Z second",
+				"no timestamp",
+				"ts=2024-01-01T00:10:00Z third",
+				"ts=2024-01-01T00:30:00Z fourth",
+			},
+		},
+		{
+			name: "prefix",
+			sessionizer: &Sessionizer{
+				Layout: time.DateTime,
+				MaxGap: time.Minute,
+			},
+			lines: []string{
+				"2024-01-01 00:00:00 first",
+				"2024-01-01 00:00:30 second",
+				"no timestamp",
+				"2024-01-01 00:10:00 third",
+				"2024-01-01 00:30:00 fourth",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			temp := filetest.OpenTemp(t, t.TempDir())
+			for _, line := range tc.lines[:4] {
+				filetest.WriteString(t, temp, line+"\n")
+			}
+
+			f, sink := testFactory(t)
+			f.Sessionizer = tc.sessionizer
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+			require.NoError(t, err)
+			defer r.Close()
+			r.ReadToEnd(context.Background())
+
+			fileAttributes := map[string]any{attrs.LogFileName: r.FileAttributes[attrs.LogFileName]}
+			boundary := map[string]any{
+				attrs.LogFileName:  r.FileAttributes[attrs.LogFileName],
+				attrs.LogFileEvent: SessionBoundaryEvent,
+			}
+			// A line without a timestamp does not end the session
+			sink.ExpectCall(t, []byte(tc.lines[0]), fileAttributes)
+			sink.ExpectCall(t, []byte(tc.lines[1]), fileAttributes)
+			sink.ExpectCall(t, []byte(tc.lines[2]), fileAttributes)
+			sink.ExpectCall(t, []byte{}, boundary)
+			sink.ExpectCall(t, []byte(tc.lines[3]), fileAttributes)
+			sink.ExpectNoCalls(t)
+
+			// The last timestamp is kept across polls
+			filetest.WriteString(t, temp, tc.lines[4]+"\n")
+			r.ReadToEnd(context.Background())
+			sink.ExpectCall(t, []byte{}, boundary)
+			sink.ExpectCall(t, []byte(tc.lines[4]), fileAttributes)
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
+
+func TestSessionBoundaryAfterRestart(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "2024-01-01 00:00:00 first\n")
+
+	f, sink := testFactory(t)
+	f.Sessionizer = &Sessionizer{Layout: time.DateTime, MaxGap: time.Minute}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("2024-01-01 00:00:00 first"))
+
+	// The last timestamp is restored from the checkpoint, so the gap across the restart is marked
+	encoded, err := json.Marshal(r.Close())
+	require.NoError(t, err)
+	m := new(Metadata)
+	require.NoError(t, json.Unmarshal(encoded, m))
+	filetest.WriteString(t, temp, "2024-01-01 00:10:00 second\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte{}, map[string]any{
+		attrs.LogFileName:  r.FileAttributes[attrs.LogFileName],
+		attrs.LogFileEvent: SessionBoundaryEvent,
+	})
+	sink.ExpectToken(t, []byte("2024-01-01 00:10:00 second"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestSessionBoundaryRecordNum(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "2024-01-01 00:00:00 first\n2024-01-01 00:10:00 second\n2024-01-01 00:10:30 third\n")
+
+	type call struct {
+		tokens        int
+		lastRecordNum int64
+	}
+	var calls []call
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, lastRecordNum int64, _ []int64) error {
+		calls = append(calls, call{len(tokens), lastRecordNum})
+		return nil
+	})
+	f.Sessionizer = &Sessionizer{Layout: time.DateTime, MaxGap: time.Minute}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+
+	// The boundary keeps the number of the record before it, and the records after it are numbered as if it was not there
+	require.Equal(t, []call{{1, 1}, {1, 1}, {2, 3}}, calls)
+	require.Equal(t, int64(3), r.RecordNum)
+}
+
+func TestSessionTimestampKeptOnceEmitted(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "2024-01-01 00:00:00 first\n")
+
+	var r *Reader
+	var during []time.Time
+	f := newTestFactory(t, func(_ context.Context, _ [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		during = append(during, r.SessionTimestamp)
+		return nil
+	})
+	f.Sessionizer = &Sessionizer{Layout: time.DateTime, MaxGap: time.Minute}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err = f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The timestamp of the batch is not kept with the metadata while it is being emitted
+	r.ReadToEnd(context.Background())
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, []time.Time{{}}, during)
+	require.Equal(t, first, r.SessionTimestamp)
+
+	filetest.WriteString(t, temp, "2024-01-01 00:00:30 second\n")
+	r.ReadToEnd(context.Background())
+	require.Equal(t, []time.Time{{}, first}, during)
+	require.Equal(t, first.Add(30*time.Second), r.SessionTimestamp)
+}
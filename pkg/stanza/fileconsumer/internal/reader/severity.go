@@ -0,0 +1,30 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"regexp"
+)
+
+// SeverityMapping assigns a severity number to tokens which match a pattern.
+type SeverityMapping struct {
+	Pattern  *regexp.Regexp
+	Severity int
+}
+
+// SeverityExtractor infers the severity of a token from the first mapping whose pattern matches it.
+// Tokens which do not match any mapping are assigned the default severity.
+type SeverityExtractor struct {
+	Mappings []SeverityMapping
+	Default  int
+}
+
+func (e *SeverityExtractor) Extract(token []byte) int {
+	for _, m := range e.Mappings {
+		if m.Pattern.Match(token) {
+			return m.Severity
+		}
+	}
+	return e.Default
+}
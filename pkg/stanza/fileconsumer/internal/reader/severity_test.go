@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestSeverityExtractor(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "ERROR something broke\nWARN something is off\nhello world\nanother ERROR\nWARN again\n")
+
+	f, sink := testFactory(t)
+	f.SeverityExtractor = &SeverityExtractor{
+		Mappings: []SeverityMapping{
+			{Pattern: regexp.MustCompile(`\bERROR\b`), Severity: 17},
+			{Pattern: regexp.MustCompile(`\bWARN\b`), Severity: 13},
+		},
+		Default: 9,
+	}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+
+	fileName := filepath.Base(temp.Name())
+	expected := []struct {
+		body     string
+		severity int
+	}{
+		{"ERROR something broke", 17},
+		{"WARN something is off", 13},
+		{"hello world", 9},
+		{"another ERROR", 17},
+		{"WARN again", 13},
+	}
+	for _, e := range expected {
+		sink.ExpectCall(t, []byte(e.body), map[string]any{
+			attrs.LogFileName:             fileName,
+			attrs.LogRecordSeverityNumber: e.severity,
+		})
+	}
+	sink.ExpectNoCalls(t)
+}
+
+func TestSeverityExtractorBatching(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "ERROR 1\nERROR 2\nINFO 3\nERROR 4\n")
+
+	var calls []int
+	var recordNums []int64
+	var firstOffsets []int64
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, lastRecordNumber int64, offsets []int64) error {
+		calls = append(calls, len(tokens))
+		recordNums = append(recordNums, lastRecordNumber)
+		firstOffsets = append(firstOffsets, offsets[0])
+		return nil
+	})
+	f.SeverityExtractor = &SeverityExtractor{
+		Mappings: []SeverityMapping{{Pattern: regexp.MustCompile(`ERROR`), Severity: 17}},
+	}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+
+	// Consecutive tokens with the same severity share a call to the emit callback
+	require.Equal(t, []int{2, 1, 1}, calls)
+	require.Equal(t, []int64{2, 3, 4}, recordNums)
+	require.Equal(t, []int64{0, 16, 23}, firstOffsets)
+}
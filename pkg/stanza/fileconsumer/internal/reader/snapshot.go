@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"encoding/hex"
+	"sync"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/flush"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/tokenlen"
+)
+
+// Snapshot is a serializable copy of a reader's state, intended for diagnostics.
+type Snapshot struct {
+	FileName        string
+	Fingerprint     string
+	Offset          int64
+	RecordNum       int64
+	FileType        string
+	HeaderFinalized bool
+	FlushState      flush.State
+	TokenLenState   tokenlen.State
+}
+
+// snapshotState holds the most recently published snapshot of a reader.
+type snapshotState struct {
+	mu       sync.Mutex
+	snapshot Snapshot
+}
+
+// publishSnapshot copies the current state so that it can be read while the file is being read.
+// It is called between batches, so a snapshot taken during a read reflects the last emitted batch.
+func (r *Reader) publishSnapshot() {
+	if r.Metadata == nil {
+		return
+	}
+	s := Snapshot{
+		FileName:        r.fileName,
+		Offset:          r.Offset,
+		RecordNum:       r.RecordNum,
+		FileType:        r.FileType,
+		HeaderFinalized: r.HeaderFinalized,
+		FlushState:      r.FlushState,
+		TokenLenState:   r.TokenLenState,
+	}
+	if r.Fingerprint != nil {
+		s.Fingerprint = hex.EncodeToString(r.Fingerprint.Bytes())
+	}
+
+	r.snapshot.mu.Lock()
+	r.snapshot.snapshot = s
+	r.snapshot.mu.Unlock()
+}
+
+// Snapshot returns a copy of the reader's state. It is safe to call concurrently with ReadToEnd.
+func (r *Reader) Snapshot() Snapshot {
+	r.snapshot.mu.Lock()
+	defer r.snapshot.mu.Unlock()
+	return r.snapshot.snapshot
+}
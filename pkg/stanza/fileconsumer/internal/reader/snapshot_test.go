@@ -0,0 +1,69 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestSnapshot(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	content := "testlog1\ntestlog2\ntestlog3\n"
+	filetest.WriteString(t, temp, content)
+
+	// Take snapshots from another goroutine while the file is being read
+	var r *Reader
+	var snapshots []Snapshot
+	f := newTestFactory(t, func(_ context.Context, _ [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		done := make(chan Snapshot)
+		go func() { done <- r.Snapshot() }()
+		snapshots = append(snapshots, <-done)
+		return nil
+	})
+	f.BackfillProfile = &ThroughputProfile{MaxBatchSize: 2}
+
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err = f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	initial := r.Snapshot()
+	assert.Equal(t, temp.Name(), initial.FileName)
+	assert.Equal(t, hex.EncodeToString([]byte(content)), initial.Fingerprint)
+	assert.Zero(t, initial.Offset)
+	assert.Zero(t, initial.RecordNum)
+	assert.False(t, initial.HeaderFinalized)
+
+	r.ReadToEnd(context.Background())
+
+	// Snapshots taken during a read reflect the last batch which was fully emitted
+	require.Len(t, snapshots, 2)
+	assert.Zero(t, snapshots[0].Offset)
+	assert.Equal(t, int64(len("testlog1\ntestlog2\n")), snapshots[1].Offset)
+	assert.Equal(t, int64(2), snapshots[1].RecordNum)
+
+	final := r.Snapshot()
+	assert.Equal(t, int64(len(content)), final.Offset)
+	assert.Equal(t, int64(3), final.RecordNum)
+	assert.Equal(t, r.FlushState.LastDataChange, final.FlushState.LastDataChange)
+
+	data, err := json.Marshal(final)
+	require.NoError(t, err)
+	var roundTripped Snapshot
+	require.NoError(t, json.Unmarshal(data, &roundTripped))
+	assert.Equal(t, final.Fingerprint, roundTripped.Fingerprint)
+	assert.Equal(t, final.Offset, roundTripped.Offset)
+	assert.Equal(t, final.RecordNum, roundTripped.RecordNum)
+	assert.Equal(t, final.TokenLenState, roundTripped.TokenLenState)
+	assert.True(t, final.FlushState.LastDataChange.Equal(roundTripped.FlushState.LastDataChange))
+}
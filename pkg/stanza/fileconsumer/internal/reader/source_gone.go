@@ -0,0 +1,34 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"errors"
+	"os"
+)
+
+// SourceGone returns true if the file was found to be deleted when the reader last stopped reading, after
+// reading the open handle to the end and emitting everything read from it, so there is nothing left to read.
+func (r *Reader) SourceGone() bool {
+	return r.sourceGone
+}
+
+// checkSourceGone records whether the file has been deleted while it was open. A file which was only
+// renamed still has a link, so it is not considered gone and may still be written to. A read which stopped
+// before the end of the file, or which is holding tokens back, leaves more to be read, so the file is not
+// considered gone until a later read reaches the end.
+func (r *Reader) checkSourceGone() {
+	r.sourceGone = false
+	if r.file == nil || !r.readToEOF || !r.batchHeldSince.IsZero() {
+		return
+	}
+	if _, err := os.Stat(r.fileName); !errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	info, err := r.file.Stat()
+	if err != nil {
+		return
+	}
+	r.sourceGone = unlinked(info)
+}
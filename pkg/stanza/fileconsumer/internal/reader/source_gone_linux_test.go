@@ -0,0 +1,98 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package reader
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestSourceGone(t *testing.T) {
+	testCases := []struct {
+		name     string
+		modify   func(t *testing.T, path string)
+		expected bool
+	}{
+		{
+			name:     "Present",
+			modify:   func(*testing.T, string) {},
+			expected: false,
+		},
+		{
+			name: "Deleted",
+			modify: func(t *testing.T, path string) {
+				require.NoError(t, os.Remove(path))
+			},
+			expected: true,
+		},
+		{
+			name: "Renamed",
+			modify: func(t *testing.T, path string) {
+				require.NoError(t, os.Rename(path, path+".1"))
+			},
+			expected: false,
+		},
+		{
+			name: "Replaced",
+			modify: func(t *testing.T, path string) {
+				require.NoError(t, os.Remove(path))
+				require.NoError(t, os.WriteFile(path, []byte("replacement\n"), 0o600))
+			},
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+			filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+			f, sink := testFactory(t)
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+
+			// The file is modified after it is opened but before it is read
+			tc.modify(t, temp.Name())
+
+			r.ReadToEnd(context.Background())
+			sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+			sink.ExpectNoCalls(t)
+			assert.Equal(t, tc.expected, r.SourceGone())
+			assert.Equal(t, int64(len("testlog1\ntestlog2\n")), r.Offset)
+		})
+	}
+}
+
+func TestSourceGoneBeforeEOF(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	f, sink := testFactory(t)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(temp.Name()))
+
+	// A read which stops before the end of the file leaves the rest to be read
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ReadToEnd(ctx)
+	assert.False(t, r.SourceGone())
+	assert.Zero(t, r.Offset)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+	assert.True(t, r.SourceGone())
+}
@@ -0,0 +1,14 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build windows
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "os"
+
+// The link count is not available from os.FileInfo on windows. A file which is open and missing
+// from its path is pending deletion, since open files are not renamed.
+func unlinked(os.FileInfo) bool {
+	return true
+}
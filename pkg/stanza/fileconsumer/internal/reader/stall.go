@@ -0,0 +1,40 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "go.uber.org/zap"
+
+const defaultMaxStalledScans = 100
+
+// stallGuard detects a split func which keeps returning tokens without advancing through the file,
+// which would otherwise keep the scan loops from ever reaching the end of the file.
+type stallGuard struct {
+	max   int
+	pos   int64
+	count int
+}
+
+func (r *Reader) newStallGuard() stallGuard {
+	return stallGuard{max: r.maxStalledScans, pos: r.Offset}
+}
+
+// stalled returns true if the token found at pos did not advance through the file. Such a token is
+// not emitted, since it would be the same as the one before it.
+func (g *stallGuard) stalled(pos int64) bool {
+	if pos != g.pos {
+		g.pos, g.count = pos, 0
+		return false
+	}
+	g.count++
+	return true
+}
+
+// exceeded returns true once the maximum number of consecutive tokens were found at the same position.
+func (g *stallGuard) exceeded() bool {
+	return g.count >= g.max
+}
+
+func (r *Reader) logStalled(g stallGuard) {
+	r.set.Logger.Error("split func is not advancing, stopping read until next poll", zap.Int64("offset", g.pos), zap.Int("max_stalled_scans", g.max))
+}
@@ -0,0 +1,96 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/header"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/parser/regex"
+)
+
+// stuckSplitFunc consumes the first byte, and then returns the same token forever without advancing
+func stuckSplitFunc(data []byte, _ bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+	if data[0] == '\n' {
+		return 1, []byte("first"), nil
+	}
+	return 0, []byte("stuck"), nil
+}
+
+func TestMaxStalledScans(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "\nstuck\n")
+
+	var tokens []string
+	f := newTestFactory(t, func(_ context.Context, batch [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		for _, token := range batch {
+			tokens = append(tokens, string(token))
+		}
+		return nil
+	})
+	f.SplitFunc = stuckSplitFunc
+	f.MaxStalledScans = 5
+	core, logs := observer.New(zapcore.ErrorLevel)
+	f.TelemetrySettings.Logger = zap.New(core)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The tokens which did not advance are not emitted, and the poll stops at the position which could not be passed
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, []string{"first"}, tokens)
+	assert.Equal(t, int64(1), r.Offset)
+	require.Equal(t, 1, logs.FilterMessage("split func is not advancing, stopping read until next poll").Len())
+	assert.Equal(t, temp.Name(), logs.All()[0].ContextMap()["path"])
+}
+
+func TestMaxStalledScansHeader(t *testing.T) {
+	f, sink := testFactory(t)
+
+	regexConf := regex.NewConfig()
+	regexConf.Regex = "^#(?P<header>.*)"
+	enc, err := textutils.LookupEncoding("utf-8")
+	require.NoError(t, err)
+	set := componenttest.NewNopTelemetrySettings()
+	set.Logger = zaptest.NewLogger(t)
+	h, err := header.NewConfig(set, "^#", []operator.Config{{Builder: regexConf}}, enc)
+	require.NoError(t, err)
+	h.SplitFunc = func(data []byte, _ bool) (int, []byte, error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+		return 0, []byte("#stuck"), nil
+	}
+	f.HeaderConfig = h
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "#stuck\naaa\n")
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, int64(0), r.Offset)
+	assert.False(t, r.HeaderFinalized)
+}
@@ -0,0 +1,36 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"context"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/metadata"
+)
+
+// startReadTimer returns a func which adds the time since it was called to the read duration of the file.
+func (r *Reader) startReadTimer() func() {
+	start := time.Now()
+	return func() {
+		r.ReadDuration += time.Since(start)
+	}
+}
+
+// RecordSummary records what was read from the file, once it is no longer tracked. The counts and the read
+// duration are kept with the metadata, so they include the reads made by every reader of the file.
+func (m *Metadata) RecordSummary(ctx context.Context, telemetryBuilder *metadata.TelemetryBuilder) {
+	if telemetryBuilder == nil {
+		return
+	}
+	telemetryBuilder.FileconsumerFileRecords.Record(ctx, m.RecordNum)
+	telemetryBuilder.FileconsumerFileBytes.Record(ctx, m.BytesRead)
+	telemetryBuilder.FileconsumerFileReadDuration.Record(ctx, m.ReadDuration.Seconds())
+	if m.DecodeErrors > 0 {
+		telemetryBuilder.FileconsumerFileDecodeErrors.Add(ctx, m.DecodeErrors)
+	}
+	if !m.readToEOF {
+		telemetryBuilder.FileconsumerFilesInterrupted.Add(ctx, 1)
+	}
+}
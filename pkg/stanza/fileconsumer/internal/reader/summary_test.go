@@ -0,0 +1,129 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestRecordSummary(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	defer func() { require.NoError(t, tel.Shutdown(context.Background())) }()
+	tb, err := metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	defer tb.Shutdown()
+
+	content := "first\nsecond\ncaf\xe9\nthird\n"
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, content)
+
+	f, sink := testFactory(t)
+	f.Encoding = strictUTF8{}
+	f.TelemetryBuilder = tb
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("first"), []byte("second"), []byte("third"))
+
+	// The counts are kept with the metadata, so the reads of a later reader of the file are included
+	filetest.WriteString(t, temp, "fourth\n")
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), r.Close())
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("fourth"))
+
+	// Closing a reader records nothing
+	m := r.Close()
+	_, err = tel.GetMetric("otelcol_fileconsumer_file_records")
+	require.Error(t, err)
+
+	m.RecordSummary(context.Background(), tb)
+	records, err := tel.GetMetric("otelcol_fileconsumer_file_records")
+	require.NoError(t, err)
+	recordsDps := records.Data.(metricdata.Histogram[int64]).DataPoints
+	require.Len(t, recordsDps, 1)
+	assert.Equal(t, uint64(1), recordsDps[0].Count)
+	assert.Equal(t, int64(4), recordsDps[0].Sum)
+
+	bytesRead, err := tel.GetMetric("otelcol_fileconsumer_file_bytes")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)+len("fourth\n")), bytesRead.Data.(metricdata.Histogram[int64]).DataPoints[0].Sum)
+
+	decodeErrors, err := tel.GetMetric("otelcol_fileconsumer_file_decode_errors")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), decodeErrors.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+
+	duration, err := tel.GetMetric("otelcol_fileconsumer_file_read_duration")
+	require.NoError(t, err)
+	assert.Positive(t, duration.Data.(metricdata.Histogram[float64]).DataPoints[0].Sum)
+
+	// The file was read to the end
+	_, err = tel.GetMetric("otelcol_fileconsumer_files_interrupted")
+	require.Error(t, err)
+}
+
+func TestRecordSummaryInterrupted(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	defer func() { require.NoError(t, tel.Shutdown(context.Background())) }()
+	tb, err := metadata.NewTelemetryBuilder(tel.NewTelemetrySettings())
+	require.NoError(t, err)
+	defer tb.Shutdown()
+
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\n")
+
+	f, sink := testFactory(t)
+	f.TelemetryBuilder = tb
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.ReadToEnd(ctx)
+	sink.ExpectNoCalls(t)
+	r.Close().RecordSummary(context.Background(), tb)
+
+	interrupted, err := tel.GetMetric("otelcol_fileconsumer_files_interrupted")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), interrupted.Data.(metricdata.Sum[int64]).DataPoints[0].Value)
+}
+
+func TestDecodeErrorsHeldBatch(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "valid\ncaf\xe9\nafter\n")
+
+	f, sink := testFactory(t)
+	f.Encoding = strictUTF8{}
+	f.MinBatchSize = 10
+	f.MinBatchTimeout = 100 * time.Millisecond
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// The bad token is read again with the rest of the held batch, but is only counted once
+	r.ReadToEnd(context.Background())
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+	assert.Zero(t, r.DecodeErrors)
+
+	time.Sleep(150 * time.Millisecond)
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("valid"), []byte("after"))
+	assert.Equal(t, int64(1), r.DecodeErrors)
+}
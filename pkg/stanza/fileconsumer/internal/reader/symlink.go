@@ -0,0 +1,105 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// SymlinkModeFollow reads the target of a symlink as if the target itself had been matched.
+	// Attributes, rotation tracking and delete_at_eof all refer to the target.
+	SymlinkModeFollow = "follow"
+	// SymlinkModeSkip ignores symlinks entirely.
+	SymlinkModeSkip = "skip"
+	// SymlinkModeLinkName reads the target of a symlink as SymlinkModeFollow does,
+	// and records the name of the link in the log.file.symlink.name attribute.
+	SymlinkModeLinkName = "link_name"
+)
+
+// ErrSymlinkSkipped is returned when opening a symlink with SymlinkModeSkip.
+var ErrSymlinkSkipped = errors.New("symlink skipped")
+
+func isSymlink(path string) (bool, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, err
+	}
+	return info.Mode()&os.ModeSymlink != 0, nil
+}
+
+// openSymlinkTarget is used with SymlinkModeFollow and SymlinkModeLinkName to replace a file opened through a symlink with a file opened at the target path,
+// so that the name used to delete and track the file refers to the same inode as the file handle.
+// The original file is closed if it is replaced, or if it cannot be. The path of the link is returned, or an
+// empty string if the file was not opened through a symlink.
+func (f *Factory) openSymlinkTarget(file *os.File) (*os.File, string, error) {
+	if f.SymlinkMode != SymlinkModeFollow && f.SymlinkMode != SymlinkModeLinkName {
+		return file, "", nil
+	}
+	linkPath := file.Name()
+	if symlink, err := isSymlink(linkPath); err != nil || !symlink {
+		return file, "", nil
+	}
+
+	target, err := openTarget(file, linkPath)
+	// Nothing is returned for the caller to close on an error
+	_ = file.Close()
+	if err != nil {
+		return nil, "", err
+	}
+	return target, linkPath, nil
+}
+
+// openTarget opens the target of the symlink at linkPath, which must still be the file which was opened through it.
+func openTarget(file *os.File, linkPath string) (*os.File, error) {
+	targetPath, err := filepath.EvalSymlinks(linkPath)
+	if err != nil {
+		return nil, fmt.Errorf("resolve symlink: %w", err)
+	}
+	target, err := os.Open(targetPath) // #nosec - operator must read in files defined by user
+	if err != nil {
+		return nil, fmt.Errorf("open symlink target: %w", err)
+	}
+
+	// The link may have been pointed elsewhere since the file was opened
+	fileInfo, err := file.Stat()
+	if err != nil {
+		_ = target.Close()
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	targetInfo, err := target.Stat()
+	if err != nil {
+		_ = target.Close()
+		return nil, fmt.Errorf("stat symlink target: %w", err)
+	}
+	if !os.SameFile(fileInfo, targetInfo) {
+		_ = target.Close()
+		return nil, errors.New("symlink target changed while opening")
+	}
+	return target, nil
+}
+
+// fileType identifies a gzip file by its name. For a file which was opened through a symlink, the names of both
+// the link and its target are considered, so that a link and its target do not both need the gzip extension.
+func (f *Factory) fileType(name, symlinkPath string) string {
+	names := []string{name, symlinkPath}
+	if symlinkPath == "" {
+		// The file was opened at the path of the link, which may not have been resolved
+		if target, err := filepath.EvalSymlinks(name); err == nil {
+			names = append(names, target)
+		}
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if filepath.Ext(name) == gzipExtension || (f.MultipartGzip && gzipPartPattern.MatchString(name)) {
+			return gzipExtension
+		}
+	}
+	return ""
+}
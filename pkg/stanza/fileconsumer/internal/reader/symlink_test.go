@@ -0,0 +1,194 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestSymlinkModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Symlinks require elevated privileges on windows")
+	}
+
+	testCases := []struct {
+		name           string
+		mode           string
+		expectFileName string
+		expectLinkName bool
+		expectDeleted  string
+	}{
+		{name: "Default", mode: "", expectFileName: "link.log", expectDeleted: "link.log"},
+		{name: "Follow", mode: SymlinkModeFollow, expectFileName: "target.log", expectDeleted: "target.log"},
+		{name: "LinkName", mode: SymlinkModeLinkName, expectFileName: "target.log", expectLinkName: true, expectDeleted: "target.log"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			targetPath := filepath.Join(tempDir, "target.log")
+			linkPath := filepath.Join(tempDir, "link.log")
+			require.NoError(t, os.WriteFile(targetPath, []byte("testlog1\ntestlog2\n"), 0o600))
+			require.NoError(t, os.Symlink(targetPath, linkPath))
+
+			f, sink := testFactory(t)
+			f.SymlinkMode = tc.mode
+			f.DeleteAtEOF = true
+
+			file, err := f.Open(linkPath)
+			require.NoError(t, err)
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err := f.NewReader(file, fp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.expectFileName, filepath.Base(r.GetFileName()))
+			require.True(t, r.Validate())
+
+			r.ReadToEnd(context.Background())
+
+			expectAttrs := map[string]any{attrs.LogFileName: tc.expectFileName}
+			if tc.expectLinkName {
+				expectAttrs[attrs.LogFileSymlinkName] = "link.log"
+			}
+			sink.ExpectCall(t, []byte("testlog1"), expectAttrs)
+			sink.ExpectCall(t, []byte("testlog2"), expectAttrs)
+			sink.ExpectNoCalls(t)
+
+			// Only the file which was read to the end is deleted
+			for _, name := range []string{"target.log", "link.log"} {
+				_, err = os.Lstat(filepath.Join(tempDir, name))
+				if name == tc.expectDeleted {
+					require.ErrorIs(t, err, os.ErrNotExist)
+				} else {
+					require.NoError(t, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSymlinkModeSkip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Symlinks require elevated privileges on windows")
+	}
+
+	tempDir := t.TempDir()
+	targetPath := filepath.Join(tempDir, "target.log")
+	linkPath := filepath.Join(tempDir, "link.log")
+	require.NoError(t, os.WriteFile(targetPath, []byte("testlog1\n"), 0o600))
+	require.NoError(t, os.Symlink(targetPath, linkPath))
+
+	f, _ := testFactory(t)
+	f.SymlinkMode = SymlinkModeSkip
+
+	_, err := f.Open(linkPath)
+	require.ErrorIs(t, err, ErrSymlinkSkipped)
+
+	// Regular files are still opened
+	file, err := f.Open(targetPath)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
+
+func TestSymlinkFollowRepointed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Symlinks require elevated privileges on windows")
+	}
+
+	tempDir := t.TempDir()
+	targetPath := filepath.Join(tempDir, "target.log")
+	otherPath := filepath.Join(tempDir, "other.log")
+	linkPath := filepath.Join(tempDir, "link.log")
+	require.NoError(t, os.WriteFile(targetPath, []byte("testlog1\n"), 0o600))
+	require.NoError(t, os.WriteFile(otherPath, []byte("other1\n"), 0o600))
+	require.NoError(t, os.Symlink(targetPath, linkPath))
+
+	f, _ := testFactory(t)
+	f.SymlinkMode = SymlinkModeFollow
+
+	file := filetest.OpenFile(t, linkPath)
+	fp, err := f.NewFingerprint(file)
+	require.NoError(t, err)
+
+	// The link now points at a different file than the one which was opened
+	require.NoError(t, os.Remove(linkPath))
+	require.NoError(t, os.Symlink(otherPath, linkPath))
+
+	_, err = f.NewReader(file, fp)
+	require.Error(t, err)
+	// The file is not returned to the caller, so it is closed
+	_, err = file.Stat()
+	require.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestSymlinkedGzip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Symlinks require elevated privileges on windows")
+	}
+
+	testCases := []struct {
+		name        string
+		mode        string
+		compression string
+		linkName    string
+		targetName  string
+	}{
+		{name: "Default", compression: "gzip", linkName: "current.log.gz", targetName: "target.log.gz"},
+		{name: "Follow", mode: SymlinkModeFollow, compression: "gzip", linkName: "current.log.gz", targetName: "target.log.gz"},
+		{name: "AutoDefault", compression: "auto", linkName: "current.log.gz", targetName: "target.log.gz"},
+		{name: "AutoFollow", mode: SymlinkModeFollow, compression: "auto", linkName: "current.log.gz", targetName: "target.log.gz"},
+		// Only one of the link and its target needs the gzip extension
+		{name: "AutoFollowTargetWithoutExtension", mode: SymlinkModeFollow, compression: "auto", linkName: "current.log.gz", targetName: "target.log"},
+		{name: "AutoLinkNameTargetWithoutExtension", mode: SymlinkModeLinkName, compression: "auto", linkName: "current.log.gz", targetName: "target.log"},
+		{name: "AutoDefaultLinkWithoutExtension", compression: "auto", linkName: "current.log", targetName: "target.log.gz"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			targetPath := filepath.Join(tempDir, tc.targetName)
+			linkPath := filepath.Join(tempDir, tc.linkName)
+			require.NoError(t, os.WriteFile(targetPath, gzipMember(t, "testlog1\n"), 0o600))
+			require.NoError(t, os.Symlink(targetPath, linkPath))
+
+			f, sink := testFactory(t)
+			f.SymlinkMode = tc.mode
+			f.Compression = tc.compression
+
+			file, err := f.Open(linkPath)
+			require.NoError(t, err)
+			fp, err := f.NewFingerprint(file)
+			require.NoError(t, err)
+			r, err := f.NewReader(file, fp)
+			require.NoError(t, err)
+			r.ReadToEnd(context.Background())
+			sink.ExpectToken(t, []byte("testlog1"))
+			sink.ExpectNoCalls(t)
+
+			// Only the member appended to the live file is read on the next poll
+			target, err := os.OpenFile(targetPath, os.O_APPEND|os.O_WRONLY, 0o600)
+			require.NoError(t, err)
+			_, err = target.Write(gzipMember(t, "testlog2\n"))
+			require.NoError(t, err)
+			require.NoError(t, target.Close())
+
+			file, err = f.Open(linkPath)
+			require.NoError(t, err)
+			r, err = f.NewReaderFromMetadata(file, r.Close())
+			require.NoError(t, err)
+			defer r.Close()
+			r.ReadToEnd(context.Background())
+			sink.ExpectToken(t, []byte("testlog2"))
+			sink.ExpectNoCalls(t)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"regexp"
+	"time"
+)
+
+// TimestampLocator locates the timestamp within a token. The timestamp is located by the first submatch of
+// the pattern, or by the whole match if the pattern has no submatches. Without a pattern, it is located by
+// the range of bytes from Start to End.
+type TimestampLocator struct {
+	Pattern *regexp.Regexp
+	Start   int
+	End     int
+}
+
+// locate returns the bytes of the timestamp of the token, or nil if the token has none.
+func (l *TimestampLocator) locate(token []byte) []byte {
+	if l.Pattern == nil {
+		if l.Start < 0 || l.End > len(token) || l.Start >= l.End {
+			return nil
+		}
+		return token[l.Start:l.End]
+	}
+	match := l.Pattern.FindSubmatchIndex(token)
+	switch {
+	case match == nil:
+		return nil
+	case len(match) >= 4 && match[2] >= 0:
+		return token[match[2]:match[3]]
+	default:
+		return token[match[0]:match[1]]
+	}
+}
+
+// TimestampNormalizer extracts a timestamp from each token and normalizes it to RFC3339 in UTC. The
+// timestamp is located by the locator, and then parsed with each of the layouts in turn.
+type TimestampNormalizer struct {
+	TimestampLocator
+	Layouts []string
+}
+
+// normalizeTimestamp returns the normalized timestamp of a token, or false if the token does not have a
+// timestamp which can be parsed. The lines of a file usually share a layout, so the layout which last
+// parsed a timestamp of the file is tried first.
+func (r *Reader) normalizeTimestamp(token []byte) (string, bool) {
+	value := r.timestampNormalizer.locate(token)
+	if len(value) == 0 {
+		return "", false
+	}
+	layouts := r.timestampNormalizer.Layouts
+	if r.timestampLayout < len(layouts) {
+		if ts, err := time.Parse(layouts[r.timestampLayout], string(value)); err == nil {
+			return ts.UTC().Format(time.RFC3339Nano), true
+		}
+	}
+	for i, layout := range layouts {
+		if i == r.timestampLayout {
+			continue
+		}
+		if ts, err := time.Parse(layout, string(value)); err == nil {
+			r.timestampLayout = i
+			return ts.UTC().Format(time.RFC3339Nano), true
+		}
+	}
+	return "", false
+}
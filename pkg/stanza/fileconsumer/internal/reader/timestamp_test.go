@@ -0,0 +1,67 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestTimestampNormalizer(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "ts=2024-03-01T12:30:00+02:00 first\n"+
+		"ts=2024-03-01 10:31:00.250 second\n"+
+		"ts=01/Mar/2024:10:32:00 +0000 third\n"+
+		"ts=yesterday unparseable\n"+
+		"no timestamp\n"+
+		"ts=01/Mar/2024:10:33:00 +0000 fourth\n")
+
+	f, sink := testFactory(t)
+	f.TimestampNormalizer = &TimestampNormalizer{
+		TimestampLocator: TimestampLocator{Pattern: regexp.MustCompile(`^ts=(.+) \w+$`)},
+		Layouts:          []string{time.RFC3339, "2006-01-02 15:04:05", "02/Jan/2006:15:04:05 -0700"},
+	}
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+
+	fileName := filepath.Base(temp.Name())
+	expectTimestamp := func(ts string) map[string]any {
+		return map[string]any{attrs.LogFileName: fileName, attrs.LogFileTimestamp: ts}
+	}
+	sink.ExpectCall(t, []byte("ts=2024-03-01T12:30:00+02:00 first"), expectTimestamp("2024-03-01T10:30:00Z"))
+	sink.ExpectCall(t, []byte("ts=2024-03-01 10:31:00.250 second"), expectTimestamp("2024-03-01T10:31:00.25Z"))
+	sink.ExpectCall(t, []byte("ts=01/Mar/2024:10:32:00 +0000 third"), expectTimestamp("2024-03-01T10:32:00Z"))
+	sink.ExpectCall(t, []byte("ts=yesterday unparseable"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectCall(t, []byte("no timestamp"), map[string]any{attrs.LogFileName: fileName})
+	sink.ExpectCall(t, []byte("ts=01/Mar/2024:10:33:00 +0000 fourth"), expectTimestamp("2024-03-01T10:33:00Z"))
+	sink.ExpectNoCalls(t)
+
+	// The layout which last parsed a timestamp is tried first
+	require.Equal(t, 2, r.timestampLayout)
+}
+
+func TestTimestampNormalizerByteRange(t *testing.T) {
+	n := &TimestampNormalizer{TimestampLocator: TimestampLocator{Start: 1, End: 20}, Layouts: []string{"2006-01-02 15:04:05"}}
+	r := &Reader{timestampNormalizer: n, Metadata: &Metadata{}}
+	ts, ok := r.normalizeTimestamp([]byte("[2024-03-01 10:30:00] message"))
+	require.True(t, ok)
+	require.Equal(t, "2024-03-01T10:30:00Z", ts)
+
+	// The line is too short to hold the range
+	_, ok = r.normalizeTimestamp([]byte("[2024-03-01"))
+	require.False(t, ok)
+}
@@ -0,0 +1,57 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+)
+
+var errReaderClosed = errors.New("reader is closed")
+
+// TokenBatch is a batch of tokens which would otherwise have been passed to the emit callback in a single call.
+type TokenBatch struct {
+	Bodies [][]byte
+	// Offsets holds the offset in the file at which each token starts
+	Offsets       []int64
+	Attributes    map[string]any
+	LastRecordNum int64
+}
+
+// Tokens reads the file to the end in a goroutine, sending each batch to the returned channel rather than to
+// the emit callback. Up to the factory's TokenBatchBuffer batches are held in the channel before reading waits
+// for them to be received. The channel is closed when the end of the file is reached or ctx is cancelled. A batch
+// which is not received before ctx is cancelled is not consumed, so it is read again. The reader must not be used
+// in any other way until the channel is closed.
+func (r *Reader) Tokens(ctx context.Context) (<-chan TokenBatch, error) {
+	if r.file == nil {
+		return nil, errReaderClosed
+	}
+	ch := make(chan TokenBatch, max(r.tokenBatchBuffer, 0))
+	emitFunc, fanOut := r.emitFunc, r.fanOut
+	r.fanOut = nil
+	r.emitFunc = func(ctx context.Context, tokens [][]byte, attributes map[string]any, lastRecordNum int64, offsets []int64) error {
+		batch := TokenBatch{
+			// The slices and attributes are reused by the reader, so they are copied before being handed over
+			Bodies:        slices.Clone(tokens),
+			Offsets:       slices.Clone(offsets[:len(tokens)]),
+			Attributes:    maps.Clone(attributes),
+			LastRecordNum: lastRecordNum,
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ch <- batch:
+			return nil
+		}
+	}
+	go func() {
+		defer close(ch)
+		defer func() { r.emitFunc, r.fanOut = emitFunc, fanOut }()
+		r.ReadToEnd(ctx)
+	}()
+	return ch, nil
+}
@@ -0,0 +1,143 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestTokens(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	var content string
+	for i := range 10 {
+		content += fmt.Sprintf("line %d\n", i)
+	}
+	filetest.WriteString(t, temp, content)
+
+	var expected []TokenBatch
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, attributes map[string]any, lastRecordNum int64, offsets []int64) error {
+		expected = append(expected, TokenBatch{
+			Bodies:        append([][]byte(nil), tokens...),
+			Offsets:       append([]int64(nil), offsets[:len(tokens)]...),
+			Attributes:    attributes,
+			LastRecordNum: lastRecordNum,
+		})
+		return nil
+	})
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.maxBatchSize = 3
+	r.ReadToEnd(context.Background())
+	r.Close()
+	require.Len(t, expected, 4)
+
+	f.TokenBatchBuffer = 1
+	r, err = f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.maxBatchSize = 3
+	batches, err := r.Tokens(context.Background())
+	require.NoError(t, err)
+
+	var actual []TokenBatch
+	for batch := range batches {
+		actual = append(actual, batch)
+	}
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, int64(len(content)), r.Offset)
+	assert.Equal(t, map[string]any{attrs.LogFileName: r.FileAttributes[attrs.LogFileName]}, actual[0].Attributes)
+
+	// The callback is used again once the channel is closed
+	filetest.WriteString(t, temp, "more\n")
+	expected = nil
+	r.ReadToEnd(context.Background())
+	require.Len(t, expected, 1)
+	assert.Equal(t, [][]byte{[]byte("more")}, expected[0].Bodies)
+}
+
+func TestTokensCancel(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	content := "first\nsecond\nthird\n"
+	filetest.WriteString(t, temp, content)
+
+	var emitted []string
+	f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, _ []int64) error {
+		for _, token := range tokens {
+			emitted = append(emitted, string(token))
+		}
+		return nil
+	})
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.maxBatchSize = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	batches, err := r.Tokens(ctx)
+	require.NoError(t, err)
+	batch := <-batches
+	assert.Equal(t, [][]byte{[]byte("first")}, batch.Bodies)
+	cancel()
+
+	// The channel is closed without the rest of the file being read
+	var received []string
+	select {
+	case <-drain(batches, &received):
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "channel was not closed after the context was cancelled")
+	}
+	received = append([]string{"first"}, received...)
+
+	// The offset is only advanced past the batches which were received, so the rest are read again
+	var consumed int64
+	for _, token := range received {
+		consumed += int64(len(token) + 1)
+	}
+	assert.Equal(t, consumed, r.Offset)
+	r.ReadToEnd(context.Background())
+	assert.Equal(t, []string{"first", "second", "third"}, append(received, emitted...))
+	assert.Equal(t, int64(len(content)), r.Offset)
+}
+
+func TestTokensClosedReader(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	f, _ := testFactory(t)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.Close()
+
+	_, err = r.Tokens(context.Background())
+	assert.ErrorIs(t, err, errReaderClosed)
+}
+
+func drain(batches <-chan TokenBatch, received *[]string) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for batch := range batches {
+			for _, body := range batch.Bodies {
+				*received = append(*received, string(body))
+			}
+		}
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,42 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// TokenTransform returns the body to emit in place of a token. It may be called concurrently for
+// tokens in the same batch, so it must not depend on the order in which tokens are transformed.
+type TokenTransform func(token []byte) []byte
+
+// transformTokens replaces each token with its transformed body. With more than one worker, the tokens
+// of a batch are shared between up to that many goroutines. Each token is replaced in place, so tokens
+// stay aligned with their attributes and offsets.
+func (r *Reader) transformTokens(tokens [][]byte) {
+	if r.tokenTransform == nil {
+		return
+	}
+	workers := min(r.transformWorkers, len(tokens))
+	if workers <= 1 {
+		for i, token := range tokens {
+			tokens[i] = r.tokenTransform(token)
+		}
+		return
+	}
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < len(tokens); i = int(next.Add(1) - 1) {
+				tokens[i] = r.tokenTransform(tokens[i])
+			}
+		}()
+	}
+	wg.Wait()
+}
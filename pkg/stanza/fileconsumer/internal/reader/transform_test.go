@@ -0,0 +1,112 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestTokenTransform(t *testing.T) {
+	for _, workers := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("Workers%d", workers), func(t *testing.T) {
+			tempDir := t.TempDir()
+			temp := filetest.OpenTemp(t, tempDir)
+
+			var content strings.Builder
+			var expectedOffsets []int64
+			for i := 0; i < 250; i++ {
+				expectedOffsets = append(expectedOffsets, int64(content.Len()))
+				fmt.Fprintf(&content, "line%d\n", i)
+			}
+			filetest.WriteString(t, temp, content.String())
+
+			var bodies []string
+			var offsets []int64
+			f := newTestFactory(t, func(_ context.Context, tokens [][]byte, _ map[string]any, _ int64, tokenOffsets []int64) error {
+				for i, token := range tokens {
+					bodies = append(bodies, string(token))
+					offsets = append(offsets, tokenOffsets[i])
+				}
+				return nil
+			})
+
+			var active, maxActive atomic.Int32
+			f.TransformWorkers = workers
+			f.TokenTransform = func(token []byte) []byte {
+				n := active.Add(1)
+				defer active.Add(-1)
+				for {
+					m := maxActive.Load()
+					if n <= m || maxActive.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				// Later tokens in the batch finish first
+				time.Sleep(time.Duration(len(token)%3) * time.Millisecond)
+				return bytes.ToUpper(token)
+			}
+
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			r, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+			r.ReadToEnd(context.Background())
+
+			require.Len(t, bodies, 250)
+			for i, body := range bodies {
+				assert.Equal(t, fmt.Sprintf("LINE%d", i), body)
+			}
+			assert.Equal(t, expectedOffsets, offsets)
+			assert.LessOrEqual(t, maxActive.Load(), int32(max(workers, 1)))
+		})
+	}
+}
+
+func BenchmarkTokenTransform(b *testing.B) {
+	transforms := []struct {
+		name      string
+		transform TokenTransform
+	}{
+		{"None", nil},
+		{"Noop", func(token []byte) []byte { return token }},
+		{"Expensive", func(token []byte) []byte {
+			sum := sha256.Sum256(token)
+			for i := 0; i < 1000; i++ {
+				sum = sha256.Sum256(sum[:])
+			}
+			return sum[:]
+		}},
+	}
+
+	tokens := make([][]byte, DefaultMaxBatchSize)
+	for i := range tokens {
+		tokens[i] = []byte(fmt.Sprintf("token %d with some more content to transform", i))
+	}
+
+	for _, tc := range transforms {
+		for _, workers := range []int{1, 4} {
+			b.Run(fmt.Sprintf("%s/Workers%d", tc.name, workers), func(b *testing.B) {
+				r := &Reader{tokenTransform: tc.transform, transformWorkers: workers}
+				batch := make([][]byte, len(tokens))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					copy(batch, tokens)
+					r.transformTokens(batch)
+				}
+			})
+		}
+	}
+}
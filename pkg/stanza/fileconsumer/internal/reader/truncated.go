@@ -0,0 +1,17 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import "bufio"
+
+// flagTruncatedTokens wraps a bufio.SplitFunc to record whether the token it returns will be clipped to maxLength.
+// It must be wrapped by trim.ToLength with the same length, which does the clipping.
+func (r *Reader) flagTruncatedTokens(splitFunc bufio.SplitFunc, maxLength int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		advance, token, err := splitFunc(data, atEOF)
+		noToken := advance == 0 && token == nil && err == nil
+		r.truncatedToken = (noToken && len(data) >= maxLength) || len(token) > maxLength
+		return advance, token, err
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestTruncatedTokens(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "short\n0123456789abcdef\nexactly10!\n")
+
+	f, sink := testFactory(t, withMaxLogSize(10))
+	f.IncludeFileTruncated = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+
+	fileAttrs := map[string]any{attrs.LogFileName: r.FileAttributes[attrs.LogFileName]}
+	truncatedAttrs := map[string]any{attrs.LogFileName: r.FileAttributes[attrs.LogFileName], attrs.LogFileTruncated: true}
+	sink.ExpectCall(t, []byte("short"), fileAttrs)
+	sink.ExpectCall(t, []byte("0123456789"), truncatedAttrs)
+	// The remainder of a clipped token is emitted as a token of its own
+	sink.ExpectCall(t, []byte("abcdef"), fileAttrs)
+	// A token which exactly fits is not clipped
+	sink.ExpectCall(t, []byte("exactly10!"), fileAttrs)
+	sink.ExpectNoCalls(t)
+}
+
+func TestTruncatedTokensNotIncluded(t *testing.T) {
+	tempDir := t.TempDir()
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "0123456789abcdef\n")
+
+	f, sink := testFactory(t, withMaxLogSize(10))
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+
+	r.ReadToEnd(context.Background())
+
+	fileAttrs := map[string]any{attrs.LogFileName: r.FileAttributes[attrs.LogFileName]}
+	sink.ExpectCall(t, []byte("0123456789"), fileAttrs)
+	sink.ExpectCall(t, []byte("abcdef"), fileAttrs)
+	sink.ExpectNoCalls(t)
+}
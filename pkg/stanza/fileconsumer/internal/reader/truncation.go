@@ -0,0 +1,47 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
+
+import (
+	"context"
+	"maps"
+
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+)
+
+// TruncatedEvent is the value of the event attribute of the record emitted when a file is found to be truncated.
+const TruncatedEvent = "truncated"
+
+// flagTruncation records that the file is about to be read again from the start because it was truncated,
+// or no longer holds the content which was read. The event is emitted before anything else is read.
+func (r *Reader) flagTruncation() {
+	if !r.emitTruncation || r.file == nil {
+		return
+	}
+	info, err := r.file.Stat()
+	if err != nil {
+		r.set.Logger.Debug("failed to stat truncated file", zap.Error(err))
+		return
+	}
+	r.TruncatedSize, r.TruncationPending = info.Size(), true
+}
+
+// emitTruncationEvent emits an empty record flagged with the truncated event and the new size of the file, so that
+// consumers which aggregate the records of a file know to reset. It is emitted once for each truncation.
+func (r *Reader) emitTruncationEvent(ctx context.Context) {
+	if !r.TruncationPending {
+		return
+	}
+	r.TruncationPending = false
+
+	attributes := make(map[string]any, len(r.FileAttributes)+2)
+	maps.Copy(attributes, r.FileAttributes)
+	attributes[attrs.LogFileEvent] = TruncatedEvent
+	attributes[attrs.LogFileSize] = r.TruncatedSize
+	if err := r.emit(ctx, &emitGroup{tokens: [][]byte{{}}, attributes: attributes, lastRecordNum: r.RecordNum, offsets: []int64{0, 0}}); err != nil {
+		r.set.Logger.Error("failed to emit truncation event", zap.Error(err))
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package reader
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
+)
+
+func TestTruncationEvent(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	f, sink := testFactory(t)
+	f.InPlaceEditPolicy = InPlaceEditRestart
+	f.EmitTruncationEvents = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+	fileName := r.FileAttributes[attrs.LogFileName]
+
+	truncate := func(content string) {
+		require.NoError(t, temp.Truncate(0))
+		_, err = temp.Seek(0, io.SeekStart)
+		require.NoError(t, err)
+		filetest.WriteString(t, temp, content)
+	}
+	expectEvent := func(size int) {
+		sink.ExpectCall(t, []byte{}, map[string]any{
+			attrs.LogFileName:  fileName,
+			attrs.LogFileEvent: TruncatedEvent,
+			attrs.LogFileSize:  int64(size),
+		})
+	}
+
+	// The event is emitted before the file is read again from the start
+	truncate("new1\n")
+	r.ReadToEnd(context.Background())
+	expectEvent(len("new1\n"))
+	sink.ExpectToken(t, []byte("new1"))
+	sink.ExpectNoCalls(t)
+
+	// It is only emitted once for each truncation
+	filetest.WriteString(t, temp, "new2\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("new2"))
+	sink.ExpectNoCalls(t)
+
+	truncate("")
+	r.ReadToEnd(context.Background())
+	expectEvent(0)
+	sink.ExpectNoCalls(t)
+	filetest.WriteString(t, temp, "other\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("other"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestTruncationEventOnResume(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	f, sink := testFactory(t)
+	f.ResumeByContent = true
+	f.EmitTruncationEvents = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+	m := r.Close()
+
+	// The file shrank while the reader was closed
+	require.NoError(t, os.WriteFile(temp.Name(), []byte("x\n"), 0o600))
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte{}, map[string]any{
+		attrs.LogFileName:  r.FileAttributes[attrs.LogFileName],
+		attrs.LogFileEvent: TruncatedEvent,
+		attrs.LogFileSize:  int64(len("x\n")),
+	})
+	sink.ExpectToken(t, []byte("x"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestTruncationEventDefaultPolicy(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\n")
+
+	f, sink := testFactory(t)
+	f.EmitTruncationEvents = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog1"))
+
+	// The file no longer starts with its fingerprint once it is read past the offset
+	require.NoError(t, temp.Truncate(0))
+	_, err = temp.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "newlog01\nnew2\n")
+	r.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("new2"))
+	sink.ExpectCall(t, []byte{}, map[string]any{
+		attrs.LogFileName:  r.FileAttributes[attrs.LogFileName],
+		attrs.LogFileEvent: TruncatedEvent,
+		attrs.LogFileSize:  int64(len("newlog01\nnew2\n")),
+	})
+	sink.ExpectNoCalls(t)
+
+	r.ReadToEnd(context.Background())
+	sink.ExpectNoCalls(t)
+}
+
+func TestTruncationEventPersisted(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	f, sink := testFactory(t)
+	f.ResumeByContent = true
+	f.EmitTruncationEvents = true
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	r, err := f.NewReader(filetest.OpenFile(t, temp.Name()), fp)
+	require.NoError(t, err)
+	r.ReadToEnd(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+	m := r.Close()
+
+	// The truncation is found when the reader is created, but the reader is closed before it is read
+	require.NoError(t, os.WriteFile(temp.Name(), []byte("x\n"), 0o600))
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	m = r.Close()
+	require.True(t, m.TruncationPending)
+
+	r, err = f.NewReaderFromMetadata(filetest.OpenFile(t, temp.Name()), m)
+	require.NoError(t, err)
+	defer r.Close()
+	r.ReadToEnd(context.Background())
+	sink.ExpectCall(t, []byte{}, map[string]any{
+		attrs.LogFileName:  r.FileAttributes[attrs.LogFileName],
+		attrs.LogFileEvent: TruncatedEvent,
+		attrs.LogFileSize:  int64(len("x\n")),
+	})
+	sink.ExpectToken(t, []byte("x"))
+	sink.ExpectNoCalls(t)
+}
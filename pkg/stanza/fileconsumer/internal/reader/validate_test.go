@@ -140,3 +140,60 @@ func TestInvalidateUnreadable(t *testing.T) {
 	// Invalidate unreadable file
 	assert.False(t, reader.Validate())
 }
+
+func TestValidateFingerprintMatch(t *testing.T) {
+	testCases := []struct {
+		name   string
+		match  FingerprintMatchFunc
+		banner string
+		valid  bool
+	}{
+		{name: "StrictUnchanged", banner: "banner 12:00:00", valid: true},
+		{name: "StrictChanged", banner: "banner 12:00:01", valid: false},
+		{name: "WithinTolerance", match: HammingFingerprintMatch(2), banner: "banner 12:00:11", valid: true},
+		{name: "BeyondTolerance", match: HammingFingerprintMatch(2), banner: "banner 12:01:11", valid: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			temp := filetest.OpenTemp(t, t.TempDir())
+			filetest.WriteString(t, temp, "banner 12:00:00\ntestlog1\n")
+
+			f, sink := testFactory(t)
+			f.FingerprintMatch = tc.match
+			fp, err := f.NewFingerprint(temp)
+			require.NoError(t, err)
+			reader, err := f.NewReader(temp, fp)
+			require.NoError(t, err)
+			reader.ReadToEnd(context.Background())
+			sink.ExpectTokens(t, []byte("banner 12:00:00"), []byte("testlog1"))
+
+			// The banner is rewritten in place
+			_, err = temp.WriteAt([]byte(tc.banner), 0)
+			require.NoError(t, err)
+			assert.Equal(t, tc.valid, reader.Validate())
+		})
+	}
+}
+
+func TestUpdateFingerprintMatch(t *testing.T) {
+	temp := filetest.OpenTemp(t, t.TempDir())
+	filetest.WriteString(t, temp, "banner 12:00:00\n")
+
+	f, sink := testFactory(t, withFingerprintSize(64))
+	f.FingerprintMatch = HammingFingerprintMatch(2)
+	fp, err := f.NewFingerprint(temp)
+	require.NoError(t, err)
+	reader, err := f.NewReader(temp, fp)
+	require.NoError(t, err)
+	reader.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("banner 12:00:00"))
+
+	// The file is still the same file, so reading continues and the fingerprint follows the banner
+	_, err = temp.WriteAt([]byte("banner 12:00:01"), 0)
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "testlog1\n")
+	reader.ReadToEnd(context.Background())
+	sink.ExpectToken(t, []byte("testlog1"))
+	sink.ExpectNoCalls(t)
+	assert.Equal(t, []byte("banner 12:00:01\ntestlog1\n"), reader.Fingerprint.Bytes())
+}
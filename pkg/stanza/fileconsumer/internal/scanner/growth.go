@@ -0,0 +1,48 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package scanner // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/scanner"
+
+const (
+	// GrowthDouble doubles the size of the buffer each time it grows. It is the default.
+	GrowthDouble = "double"
+	// GrowthLinear grows the buffer by a fixed increment.
+	GrowthLinear = "linear"
+	// GrowthSteps grows the buffer through a list of sizes, and then by doubling.
+	GrowthSteps = "steps"
+)
+
+// startBufSize is the size of the buffer when it first grows from being empty, as in bufio.
+const startBufSize = 4096
+
+// Growth determines how the buffer of a scanner grows when a token does not fit in it.
+type Growth struct {
+	Strategy string
+	// Increment is the number of bytes added each time the buffer grows with GrowthLinear.
+	Increment int
+	// Steps are the sizes, in increasing order, through which the buffer grows with GrowthSteps.
+	Steps []int
+	// MaxGrows is the number of times the buffer may grow before a token is considered too long.
+	// Zero means the buffer grows until it reaches the maximum token size.
+	MaxGrows int
+}
+
+// next returns the size to which a buffer of the given size grows. The result is always larger than size.
+func (g Growth) next(size int) int {
+	switch g.Strategy {
+	case GrowthLinear:
+		if g.Increment > 0 {
+			return size + g.Increment
+		}
+	case GrowthSteps:
+		for _, step := range g.Steps {
+			if step > size {
+				return step
+			}
+		}
+	}
+	if size == 0 {
+		return startBufSize
+	}
+	return size * 2
+}
@@ -13,22 +13,54 @@ import (
 
 const DefaultBufferSize = 16 * 1024
 
-// Scanner is a scanner that maintains position
+// maxConsecutiveEmptyReads is the number of reads or tokens without progress after which scanning stops, as in bufio.
+const maxConsecutiveEmptyReads = 100
+
+// errTooManyEmptyTokens stops scanning when the split func keeps returning empty tokens at the end of the input
+// without advancing.
+var errTooManyEmptyTokens = errors.New("too many empty tokens without progressing")
+
+// Scanner is a scanner that maintains position. It behaves as a bufio.Scanner, except that the way its
+// buffer grows can be configured with SetGrowth, and that a split func which does not progress stops scanning
+// with an error rather than a panic.
 type Scanner struct {
 	pos int64
-	*bufio.Scanner
+
+	r            io.Reader
+	split        bufio.SplitFunc
+	maxTokenSize int
+	token        []byte
+	buf          []byte
+	start, end   int
+	err          error
+	empties      int
+	done         bool
+
+	growth Growth
+	grows  int
+	// used is the number of bytes at the start of the buffer given to New which have been written to
+	used int
+
+	contextSize int
+	// tail holds the last bytes which were consumed, and context those which preceded the current token
+	tail    []byte
+	context []byte
 }
 
 // New creates a new positional scanner
 func New(r io.Reader, maxLogSize int, buf []byte, startOffset int64, splitFunc bufio.SplitFunc) *Scanner {
-	s := &Scanner{Scanner: bufio.NewScanner(r), pos: startOffset}
-	s.Buffer(buf, maxLogSize)
-	scanFunc := func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	s := &Scanner{r: r, maxTokenSize: maxLogSize, buf: buf[0:cap(buf)], pos: startOffset}
+	s.split = func(data []byte, atEOF bool) (advance int, token []byte, err error) {
 		advance, token, err = splitFunc(data, atEOF)
 		s.pos += int64(advance)
+		if s.contextSize > 0 {
+			if token != nil {
+				s.context = append(s.context[:0], s.tail...)
+			}
+			s.keepTail(data[:advance])
+		}
 		return
 	}
-	s.Split(scanFunc)
 	return s
 }
 
@@ -37,6 +69,165 @@ func (s *Scanner) Pos() int64 {
 	return s.pos
 }
 
+// KeepContext retains up to size bytes which preceded each token, so they can be retrieved with ContextBefore.
+// It must be called before the first call to Scan.
+func (s *Scanner) KeepContext(size int) {
+	s.contextSize = size
+	s.tail = make([]byte, 0, size)
+	s.context = make([]byte, 0, size)
+}
+
+// ContextBefore returns up to the configured number of bytes which came before the current token. Bytes read before
+// the scanner was created are not available. The returned slice is only valid until the next call to Scan.
+func (s *Scanner) ContextBefore() []byte {
+	return s.context
+}
+
+func (s *Scanner) keepTail(consumed []byte) {
+	if len(consumed) >= s.contextSize {
+		s.tail = append(s.tail[:0], consumed[len(consumed)-s.contextSize:]...)
+		return
+	}
+	if excess := len(s.tail) + len(consumed) - s.contextSize; excess > 0 {
+		s.tail = s.tail[:copy(s.tail, s.tail[excess:])]
+	}
+	s.tail = append(s.tail, consumed...)
+}
+
+// SetGrowth configures how the buffer grows when a token does not fit in it.
+// It must be called before the first call to Scan.
+func (s *Scanner) SetGrowth(g Growth) {
+	s.growth = g
+}
+
+// Used returns the number of bytes at the start of the buffer given to New which may have been written to.
+// Once the buffer has grown, it is no longer written to.
+func (s *Scanner) Used() int {
+	return s.used
+}
+
+// Bytes returns the most recent token generated by a call to Scan. The underlying array may point to
+// data that will be overwritten by a subsequent call to Scan.
+func (s *Scanner) Bytes() []byte {
+	return s.token
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	if errors.Is(s.err, io.EOF) {
+		return nil
+	}
+	return s.err
+}
+
+// Scan advances the scanner to the next token, as bufio.Scanner.Scan does.
+func (s *Scanner) Scan() bool {
+	if s.done {
+		return false
+	}
+	for {
+		// See if we can get a token with what we already have, or recover a last token after an error
+		if s.end > s.start || s.err != nil {
+			advance, token, err := s.split(s.buf[s.start:s.end], s.err != nil)
+			if err != nil {
+				if errors.Is(err, bufio.ErrFinalToken) {
+					s.token = token
+					s.done = true
+					return token != nil
+				}
+				s.setErr(err)
+				return false
+			}
+			if !s.advance(advance) {
+				return false
+			}
+			s.token = token
+			if token != nil {
+				if s.err == nil || advance > 0 {
+					s.empties = 0
+				} else {
+					s.empties++
+					if s.empties > maxConsecutiveEmptyReads {
+						s.setErr(errTooManyEmptyTokens)
+						return false
+					}
+				}
+				return true
+			}
+		}
+		if s.err != nil {
+			s.start, s.end = 0, 0
+			return false
+		}
+
+		// Make room for more data, first by shifting what is held to the start of the buffer and
+		// then by growing the buffer
+		if s.start > 0 && (s.end == len(s.buf) || s.start > len(s.buf)/2) {
+			copy(s.buf, s.buf[s.start:s.end])
+			s.end -= s.start
+			s.start = 0
+		}
+		if s.end == len(s.buf) {
+			if len(s.buf) >= s.maxTokenSize || (s.growth.MaxGrows > 0 && s.grows >= s.growth.MaxGrows) {
+				s.setErr(bufio.ErrTooLong)
+				return false
+			}
+			newBuf := make([]byte, min(s.growth.next(len(s.buf)), s.maxTokenSize))
+			copy(newBuf, s.buf[s.start:s.end])
+			s.buf = newBuf
+			s.end -= s.start
+			s.start = 0
+			s.grows++
+		}
+
+		for loop := 0; ; {
+			n, err := s.r.Read(s.buf[s.end:len(s.buf)])
+			if n < 0 || len(s.buf)-s.end < n {
+				s.setErr(bufio.ErrBadReadCount)
+				break
+			}
+			s.end += n
+			if s.grows == 0 {
+				s.used = max(s.used, s.end)
+			}
+			if err != nil {
+				s.setErr(err)
+				break
+			}
+			if n > 0 {
+				s.empties = 0
+				break
+			}
+			loop++
+			if loop > maxConsecutiveEmptyReads {
+				s.setErr(io.ErrNoProgress)
+				break
+			}
+		}
+	}
+}
+
+// advance consumes n bytes of the buffer, and reports whether the advance was legal.
+func (s *Scanner) advance(n int) bool {
+	if n < 0 {
+		s.setErr(bufio.ErrNegativeAdvance)
+		return false
+	}
+	if n > s.end-s.start {
+		s.setErr(bufio.ErrAdvanceTooFar)
+		return false
+	}
+	s.start += n
+	return true
+}
+
+// setErr records the first error encountered.
+func (s *Scanner) setErr(err error) {
+	if s.err == nil || errors.Is(s.err, io.EOF) {
+		s.err = err
+	}
+}
+
 func (s *Scanner) Error() error {
 	err := s.Err()
 	if errors.Is(err, bufio.ErrTooLong) {
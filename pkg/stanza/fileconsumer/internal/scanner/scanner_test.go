@@ -7,6 +7,8 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -126,3 +128,155 @@ func TestScannerError(t *testing.T) {
 	assert.False(t, scanner.Scan())
 	assert.EqualError(t, scanner.Error(), "scanner error: some err")
 }
+
+func TestScannerNoProgress(t *testing.T) {
+	// The split func returns an empty token at EOF without ever advancing
+	split := func(_ []byte, atEOF bool) (int, []byte, error) {
+		if !atEOF {
+			return 0, nil, nil
+		}
+		return 0, []byte{}, nil
+	}
+	scanner := New(bytes.NewReader([]byte("testlog")), 100, make([]byte, 0, 100), 0, split)
+	var tokens int
+	assert.NotPanics(t, func() {
+		for scanner.Scan() {
+			tokens++
+		}
+	})
+	assert.Equal(t, maxConsecutiveEmptyReads, tokens)
+	assert.ErrorIs(t, scanner.Err(), errTooManyEmptyTokens)
+	assert.EqualError(t, scanner.Error(), "scanner error: too many empty tokens without progressing")
+}
+
+func TestScannerContextBefore(t *testing.T) {
+	stream := []byte("first\nsecond\n\nthird\nfourth line is longer\nfifth\n")
+	for _, contextSize := range []int{1, 4, 8, 100} {
+		for _, bufferSize := range []int{8, DefaultBufferSize} {
+			t.Run(fmt.Sprintf("context%d_buffer%d", contextSize, bufferSize), func(t *testing.T) {
+				scanner := New(bytes.NewReader(stream), 100, make([]byte, 0, bufferSize), 0, simpleSplit([]byte("\n")))
+				scanner.KeepContext(contextSize)
+
+				var start int64
+				for scanner.Scan() {
+					expected := stream[max(0, start-int64(contextSize)):start]
+					assert.Equal(t, expected, scanner.ContextBefore(), "token starting at %d", start)
+					start = scanner.Pos()
+				}
+				assert.NoError(t, scanner.Error())
+				assert.Equal(t, int64(len(stream)), start)
+			})
+		}
+	}
+}
+
+// sizeRecorder records the size of the scanner's buffer each time it is read into.
+type sizeRecorder struct {
+	r       io.Reader
+	scanner *Scanner
+	sizes   []int
+}
+
+func (r *sizeRecorder) Read(p []byte) (int, error) {
+	if size := len(r.scanner.buf); len(r.sizes) == 0 || r.sizes[len(r.sizes)-1] != size {
+		r.sizes = append(r.sizes, size)
+	}
+	return r.r.Read(p)
+}
+
+func TestScannerGrowth(t *testing.T) {
+	token := bytes.Repeat([]byte("a"), 1000)
+	testCases := []struct {
+		name          string
+		growth        Growth
+		maxSize       int
+		expectedSizes []int
+		expectTooLong bool
+	}{
+		{
+			name:          "default",
+			maxSize:       10000,
+			expectedSizes: []int{100, 200, 400, 800, 1600},
+		},
+		{
+			name:          "double",
+			growth:        Growth{Strategy: GrowthDouble},
+			maxSize:       10000,
+			expectedSizes: []int{100, 200, 400, 800, 1600},
+		},
+		{
+			name:          "linear",
+			growth:        Growth{Strategy: GrowthLinear, Increment: 300},
+			maxSize:       10000,
+			expectedSizes: []int{100, 400, 700, 1000, 1300},
+		},
+		{
+			name:          "linear_capped",
+			growth:        Growth{Strategy: GrowthLinear, Increment: 600},
+			maxSize:       1000,
+			expectedSizes: []int{100, 700, 1000},
+			expectTooLong: true,
+		},
+		{
+			name:          "steps",
+			growth:        Growth{Strategy: GrowthSteps, Steps: []int{150, 1200}},
+			maxSize:       10000,
+			expectedSizes: []int{100, 150, 1200},
+		},
+		{
+			name:          "steps_exhausted",
+			growth:        Growth{Strategy: GrowthSteps, Steps: []int{150}},
+			maxSize:       10000,
+			expectedSizes: []int{100, 150, 300, 600, 1200},
+		},
+		{
+			name:          "max_grows",
+			growth:        Growth{MaxGrows: 2},
+			maxSize:       10000,
+			expectedSizes: []int{100, 200, 400},
+			expectTooLong: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stream := append(append(append([]byte{}, token...), '\n'), []byte("next\n")...)
+			reader := &sizeRecorder{r: bytes.NewReader(stream)}
+			scanner := New(reader, tc.maxSize, make([]byte, 0, 100), 0, simpleSplit([]byte("\n")))
+			scanner.SetGrowth(tc.growth)
+			reader.scanner = scanner
+
+			if tc.expectTooLong {
+				assert.False(t, scanner.Scan())
+				assert.EqualError(t, scanner.Error(), "log entry too large")
+			} else {
+				assert.True(t, scanner.Scan())
+				assert.Equal(t, token, scanner.Bytes())
+				assert.True(t, scanner.Scan())
+				assert.Equal(t, []byte("next"), scanner.Bytes())
+				assert.False(t, scanner.Scan())
+				assert.NoError(t, scanner.Error())
+			}
+			assert.Equal(t, tc.expectedSizes, reader.sizes)
+		})
+	}
+}
+
+func TestGrowthFromEmpty(t *testing.T) {
+	assert.Equal(t, startBufSize, Growth{}.next(0))
+	assert.Equal(t, 10, Growth{Strategy: GrowthLinear, Increment: 10}.next(0))
+	assert.Equal(t, 50, Growth{Strategy: GrowthSteps, Steps: []int{50}}.next(0))
+}
+
+func TestScannerUsed(t *testing.T) {
+	// Only the part of the buffer which the content fits in is written to
+	scanner := New(bytes.NewReader([]byte("ab\ncd\n")), 1000, make([]byte, 0, 100), 0, simpleSplit([]byte("\n")))
+	for scanner.Scan() {
+	}
+	assert.Equal(t, 6, scanner.Used())
+
+	// The whole buffer is written to before it grows
+	scanner = New(bytes.NewReader(append(bytes.Repeat([]byte("a"), 150), '\n')), 1000, make([]byte, 0, 100), 0, simpleSplit([]byte("\n")))
+	for scanner.Scan() {
+	}
+	assert.Equal(t, 100, scanner.Used())
+}
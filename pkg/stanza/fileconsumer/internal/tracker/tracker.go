@@ -12,6 +12,7 @@ import (
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/archive"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fileset"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/fingerprint"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/metadata"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/reader"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator"
 )
@@ -40,7 +41,8 @@ type Tracker interface {
 
 // fileTracker tracks known offsets for files that are being consumed by the manager.
 type fileTracker struct {
-	set component.TelemetrySettings
+	set              component.TelemetrySettings
+	telemetryBuilder *metadata.TelemetryBuilder
 
 	maxBatchFiles int
 
@@ -49,9 +51,15 @@ type fileTracker struct {
 	knownFiles        []*fileset.Fileset[*reader.Metadata]
 
 	archive archive.Archive
+
+	startsWith func(a, b *fingerprint.Fingerprint) bool
+	equal      func(a, b *fingerprint.Fingerprint) bool
 }
 
-func NewFileTracker(ctx context.Context, set component.TelemetrySettings, maxBatchFiles, pollsToArchive int, persister operator.Persister) Tracker {
+// NewFileTracker creates a tracker which keeps the readers and metadata of known files. If match is set, it is
+// used instead of a strict comparison to match a file to a known file, as it is when a reader validates its file.
+// The summary of each file is recorded through the telemetry builder once the file is no longer tracked.
+func NewFileTracker(ctx context.Context, set component.TelemetrySettings, telemetryBuilder *metadata.TelemetryBuilder, maxBatchFiles, pollsToArchive int, persister operator.Persister, match reader.FingerprintMatchFunc) Tracker {
 	knownFiles := make([]*fileset.Fileset[*reader.Metadata], 3)
 	for i := 0; i < len(knownFiles); i++ {
 		knownFiles[i] = fileset.New[*reader.Metadata](maxBatchFiles)
@@ -60,15 +68,39 @@ func NewFileTracker(ctx context.Context, set component.TelemetrySettings, maxBat
 
 	t := &fileTracker{
 		set:               set,
+		telemetryBuilder:  telemetryBuilder,
 		maxBatchFiles:     maxBatchFiles,
 		currentPollFiles:  fileset.New[*reader.Reader](maxBatchFiles),
 		previousPollFiles: fileset.New[*reader.Reader](maxBatchFiles),
 		knownFiles:        knownFiles,
 		archive:           archive.New(ctx, set.Logger.Named("archive"), pollsToArchive, persister),
+		startsWith:        startsWith(match),
+		equal:             equal(match),
 	}
 	return t
 }
 
+// startsWith returns the comparison of a file's fingerprint with the fingerprint of a known file.
+func startsWith(match reader.FingerprintMatchFunc) func(a, b *fingerprint.Fingerprint) bool {
+	if match == nil {
+		return fileset.StartsWith
+	}
+	return func(a, b *fingerprint.Fingerprint) bool {
+		return match(b, a)
+	}
+}
+
+// equal returns the comparison of the fingerprints of two files in the same poll, which are duplicates if
+// they are of the same length and match.
+func equal(match reader.FingerprintMatchFunc) func(a, b *fingerprint.Fingerprint) bool {
+	if match == nil {
+		return fileset.Equal
+	}
+	return func(a, b *fingerprint.Fingerprint) bool {
+		return a.Len() == b.Len() && match(b, a)
+	}
+}
+
 func (*fileTracker) Name() string {
 	return FileTracker
 }
@@ -79,16 +111,16 @@ func (t *fileTracker) Add(reader *reader.Reader) {
 }
 
 func (t *fileTracker) GetCurrentFile(fp *fingerprint.Fingerprint) *reader.Reader {
-	return t.currentPollFiles.Match(fp, fileset.Equal)
+	return t.currentPollFiles.Match(fp, t.equal)
 }
 
 func (t *fileTracker) GetOpenFile(fp *fingerprint.Fingerprint) *reader.Reader {
-	return t.previousPollFiles.Match(fp, fileset.StartsWith)
+	return t.previousPollFiles.Match(fp, t.startsWith)
 }
 
 func (t *fileTracker) GetClosedFile(fp *fingerprint.Fingerprint) *reader.Metadata {
 	for i := 0; i < len(t.knownFiles); i++ {
-		if oldMetadata := t.knownFiles[i].Match(fp, fileset.StartsWith); oldMetadata != nil {
+		if oldMetadata := t.knownFiles[i].Match(fp, t.startsWith); oldMetadata != nil {
 			return oldMetadata
 		}
 	}
@@ -134,6 +166,9 @@ func (t *fileTracker) EndPoll(ctx context.Context) {
 	// t.knownFiles[0] -> t.knownFiles[1] -> t.knownFiles[2]
 
 	// Instead of throwing it away, archive it.
+	for _, m := range t.knownFiles[2].Get() {
+		m.RecordSummary(ctx, t.telemetryBuilder)
+	}
 	t.archive.WriteFiles(ctx, t.knownFiles[2])
 	copy(t.knownFiles[1:], t.knownFiles)
 	t.knownFiles[0] = fileset.New[*reader.Metadata](t.maxBatchFiles)
@@ -152,16 +187,20 @@ func (t *fileTracker) TotalReaders() int {
 // poll will create fresh readers with no previously tracked offsets.
 type noStateTracker struct {
 	set              component.TelemetrySettings
+	telemetryBuilder *metadata.TelemetryBuilder
 	maxBatchFiles    int
 	currentPollFiles *fileset.Fileset[*reader.Reader]
+	equal            func(a, b *fingerprint.Fingerprint) bool
 }
 
-func NewNoStateTracker(set component.TelemetrySettings, maxBatchFiles int) Tracker {
+func NewNoStateTracker(set component.TelemetrySettings, telemetryBuilder *metadata.TelemetryBuilder, maxBatchFiles int, match reader.FingerprintMatchFunc) Tracker {
 	set.Logger = set.Logger.With(zap.String("tracker", "noStateTracker"))
 	return &noStateTracker{
 		set:              set,
+		telemetryBuilder: telemetryBuilder,
 		maxBatchFiles:    maxBatchFiles,
 		currentPollFiles: fileset.New[*reader.Reader](maxBatchFiles),
+		equal:            equal(match),
 	}
 }
 
@@ -179,12 +218,13 @@ func (t *noStateTracker) CurrentPollFiles() []*reader.Reader {
 }
 
 func (t *noStateTracker) GetCurrentFile(fp *fingerprint.Fingerprint) *reader.Reader {
-	return t.currentPollFiles.Match(fp, fileset.Equal)
+	return t.currentPollFiles.Match(fp, t.equal)
 }
 
 func (t *noStateTracker) EndConsume() (filesClosed int) {
 	for r, _ := t.currentPollFiles.Pop(); r != nil; r, _ = t.currentPollFiles.Pop() {
-		r.Close()
+		// The file is not tracked past the poll
+		r.Close().RecordSummary(context.Background(), t.telemetryBuilder)
 		filesClosed++
 	}
 	return
@@ -16,7 +16,15 @@ func (t *fileTracker) EndConsume() (filesClosed int) {
 	filesClosed = t.ClosePreviousFiles()
 
 	// t.currentPollFiles -> t.previousPollFiles
-	t.previousPollFiles = t.currentPollFiles
-	t.currentPollFiles = fileset.New[*reader.Reader](t.maxBatchFiles)
+	t.previousPollFiles = fileset.New[*reader.Reader](t.maxBatchFiles)
+	for r, _ := t.currentPollFiles.Pop(); r != nil; r, _ = t.currentPollFiles.Pop() {
+		// Deleted files which have been read to the end are not kept open to be read as lost files
+		if r.SourceGone() {
+			t.knownFiles[0].Add(r.Close())
+			filesClosed++
+			continue
+		}
+		t.previousPollFiles.Add(r)
+	}
 	return
 }
@@ -19,6 +19,8 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest/observer"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/internal/checkpoint"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/internal/filetest"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/testutil"
 )
@@ -496,3 +498,183 @@ func TestFileMovedWhileOff_BigFiles(t *testing.T) {
 	sink2.ExpectTokens(t, log2, log3)
 	require.NoError(t, operator2.Stop())
 }
+
+func TestFileMovedAcrossDirectories(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("Rotation tests have been flaky on Windows. See https://github.com/open-telemetry/opentelemetry-collector-contrib/issues/16331")
+	}
+	t.Parallel()
+
+	sourceDir := t.TempDir()
+	archiveDir := t.TempDir()
+	cfg := NewConfig().includeDir(sourceDir).includeDir(archiveDir)
+	cfg.StartAt = "beginning"
+	cfg.IncludeFilePath = true
+	persister := testutil.NewUnscopedMockPersister()
+
+	temp := filetest.OpenTemp(t, sourceDir)
+	sourceName := temp.Name()
+	filetest.WriteString(t, temp, "testlog1\n")
+
+	operator, sink := testManager(t, cfg)
+	require.NoError(t, operator.Start(persister))
+	sink.ExpectCall(t, []byte("testlog1"), map[string]any{
+		attrs.LogFileName: filepath.Base(sourceName),
+		attrs.LogFilePath: sourceName,
+	})
+	require.NoError(t, operator.Stop())
+
+	// The file is written to and then moved to another directory while the operator is stopped
+	filetest.WriteString(t, temp, "testlog2\n")
+	require.NoError(t, temp.Close())
+	archiveName := filepath.Join(archiveDir, filepath.Base(sourceName))
+	require.NoError(t, os.Rename(sourceName, archiveName))
+
+	// Reading resumes from the persisted offset, since the fingerprint is the same
+	operator2, sink2 := testManager(t, cfg)
+	require.NoError(t, operator2.Start(persister))
+	sink2.ExpectCall(t, []byte("testlog2"), map[string]any{
+		attrs.LogFileName: filepath.Base(archiveName),
+		attrs.LogFilePath: archiveName,
+	})
+	sink2.ExpectNoCalls(t)
+	require.NoError(t, operator2.Stop())
+
+	// The record number carries on as well
+	persisted, err := checkpoint.Load(context.Background(), persister)
+	require.NoError(t, err)
+	require.Len(t, persisted, 1)
+	assert.Equal(t, int64(len("testlog1\ntestlog2\n")), persisted[0].Offset)
+	assert.Equal(t, int64(2), persisted[0].RecordNum)
+}
+
+// TestHardLinkThenTruncate tests rotation where the file is hard linked to a backup and the
+// original is then truncated. Both paths refer to the same inode, so the truncation applies
+// to both, and the content written after it is read once, from the start.
+func TestHardLinkThenTruncate(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("Rotation tests have been flaky on Windows. See https://github.com/open-telemetry/opentelemetry-collector-contrib/issues/16331")
+	}
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.StartAt = "beginning"
+	operator, sink := testManager(t, cfg)
+	operator.persister = testutil.NewUnscopedMockPersister()
+
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+
+	require.NoError(t, os.Link(temp.Name(), temp.Name()+".1"))
+	require.NoError(t, temp.Truncate(0))
+	_, err := temp.Seek(0, 0)
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "testlog3\n")
+
+	operator.poll(context.Background())
+	sink.ExpectToken(t, []byte("testlog3"))
+	sink.ExpectNoCalls(t)
+
+	filetest.WriteString(t, temp, "testlog4\n")
+	operator.poll(context.Background())
+	sink.ExpectToken(t, []byte("testlog4"))
+	sink.ExpectNoCalls(t)
+}
+
+// TestHardLinkThenReplace tests rotation where the file is hard linked to a backup and the
+// original path is then replaced by a new file. The backup keeps the inode, so reading it
+// resumes from the previous offset, while the new file is read from the start.
+func TestHardLinkThenReplace(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("Rotation tests have been flaky on Windows. See https://github.com/open-telemetry/opentelemetry-collector-contrib/issues/16331")
+	}
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.StartAt = "beginning"
+	operator, sink := testManager(t, cfg)
+	operator.persister = testutil.NewUnscopedMockPersister()
+
+	temp := filetest.OpenTemp(t, tempDir)
+	originalName := temp.Name()
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+
+	// A line is written which has not been read when the file is rotated
+	filetest.WriteString(t, temp, "testlog3\n")
+	require.NoError(t, os.Link(originalName, originalName+".1"))
+	require.NoError(t, temp.Close())
+	require.NoError(t, os.Remove(originalName))
+	replacement, err := os.OpenFile(originalName, os.O_CREATE|os.O_RDWR, 0o600)
+	require.NoError(t, err)
+	defer replacement.Close()
+	filetest.WriteString(t, replacement, "testlog4\n")
+
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte("testlog3"), []byte("testlog4"))
+	sink.ExpectNoCalls(t)
+}
+
+func TestFileDeletedBeforeRead(t *testing.T) {
+	if runtime.GOOS == windowsOS {
+		t.Skip("Deleting files while open is unsupported on Windows")
+	}
+	t.Parallel()
+
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.StartAt = "beginning"
+	operator, sink := testManager(t, cfg)
+
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog1\ntestlog2\n")
+	require.NoError(t, temp.Close())
+
+	// The file is deleted after the manager opens it, but before it is read
+	ctx := context.Background()
+	operator.makeReaders(ctx, []string{temp.Name()})
+	require.NoError(t, os.Remove(temp.Name()))
+	for _, r := range operator.tracker.CurrentPollFiles() {
+		r.ReadToEnd(ctx)
+		require.True(t, r.SourceGone())
+	}
+	sink.ExpectTokens(t, []byte("testlog1"), []byte("testlog2"))
+
+	// The reader is closed rather than being kept open to be read as a lost file
+	require.Equal(t, 1, operator.tracker.EndConsume())
+	require.Empty(t, operator.tracker.PreviousPollFiles())
+	require.Equal(t, 1, operator.tracker.TotalReaders())
+
+	operator.poll(ctx)
+	sink.ExpectNoCalls(t)
+}
+
+func TestCopyTruncateRotationOverlap(t *testing.T) {
+	t.Parallel()
+	tempDir := t.TempDir()
+	cfg := NewConfig().includeDir(tempDir)
+	cfg.StartAt = "beginning"
+	operator, sink := testManager(t, cfg)
+	operator.readerFactory.RotationOverlapSize = 3
+
+	temp := filetest.OpenTemp(t, tempDir)
+	filetest.WriteString(t, temp, "testlog1 of the file which is rotated\ntestlog2\ntestlog3\n")
+	operator.poll(context.Background())
+	sink.ExpectTokens(t, []byte("testlog1 of the file which is rotated"), []byte("testlog2"), []byte("testlog3"))
+
+	// The file is truncated by a copytruncate rotation, and the last lines are written to it again
+	require.NoError(t, temp.Truncate(0))
+	_, err := temp.Seek(0, 0)
+	require.NoError(t, err)
+	filetest.WriteString(t, temp, "testlog2\ntestlog3\ntestlog4\n")
+	operator.poll(context.Background())
+	sink.ExpectToken(t, []byte("testlog4"))
+	sink.ExpectNoCalls(t)
+}
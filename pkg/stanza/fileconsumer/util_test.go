@@ -23,7 +23,7 @@ func testManager(t *testing.T, cfg *Config, opts ...Option) (*Manager, *emittest
 func testManagerWithSink(t *testing.T, cfg *Config, sink *emittest.Sink, opts ...Option) *Manager {
 	set := componenttest.NewNopTelemetrySettings()
 	input, err := cfg.Build(set, sink.Callback, opts...)
-	input.tracker = tracker.NewFileTracker(context.Background(), set, cfg.MaxBatches, cfg.PollsToArchive, testutil.NewUnscopedMockPersister())
+	input.tracker = tracker.NewFileTracker(context.Background(), set, input.telemetryBuilder, cfg.MaxBatches, cfg.PollsToArchive, testutil.NewUnscopedMockPersister(), input.readerFactory.FingerprintMatch)
 	require.NoError(t, err)
 	t.Cleanup(func() { input.tracker.ClosePreviousFiles() })
 	return input
@@ -70,6 +70,21 @@ func (i *Input) convertTokens(tokens [][]byte, attributes map[string]any, lastRe
 		}
 
 		for k, v := range attributes {
+			if k == attrs.LogRecordSeverityNumber {
+				if severity, ok := v.(int); ok {
+					ent.Severity = entry.Severity(severity)
+				}
+				continue
+			}
+			if k == attrs.LogFileResourceAttributes {
+				resource, _ := v.(map[string]any)
+				for rk, rv := range resource {
+					if err = ent.Set(entry.NewResourceField(rk), rv); err != nil {
+						i.Logger().Error("set resource attribute", zap.Error(err))
+					}
+				}
+				continue
+			}
 			if err = ent.Set(entry.NewAttributeField(k), v); err != nil {
 				i.Logger().Error("set attribute", zap.Error(err))
 			}
@@ -14,7 +14,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/entry"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/fileconsumer/attrs"
+	operatorpkg "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/operator/parser/regex"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/stanza/testutil"
 )
 
@@ -197,6 +200,69 @@ func TestAddFileRecordNumber(t *testing.T) {
 	require.Equal(t, int64(6), e.Attributes["log.file.record_number"])
 }
 
+// TestHeaderResourceAttributes tests that attributes parsed from the header which are mapped to resource
+// attributes are set on the resource of each entry
+func TestHeaderResourceAttributes(t *testing.T) {
+	t.Parallel()
+	operator, logReceived, tempDir := newTestFileOperator(t, func(cfg *Config) {
+		regexCfg := regex.NewConfig()
+		regexCfg.Regex = "^#service: (?P<service>.*)"
+		cfg.Header = &fileconsumer.HeaderConfig{
+			Pattern:            "^#",
+			MetadataOperators:  []operatorpkg.Config{{Builder: regexCfg}},
+			ResourceAttributes: map[string]string{"service": "service.name"},
+		}
+	})
+
+	temp := openTemp(t, tempDir)
+	writeString(t, temp, "#service: checkout\ntestlog1\n")
+
+	require.NoError(t, operator.Start(testutil.NewUnscopedMockPersister()))
+	defer func() {
+		require.NoError(t, operator.Stop())
+	}()
+
+	e := waitForOne(t, logReceived)
+	require.Equal(t, "testlog1", e.Body)
+	require.Equal(t, map[string]any{"service.name": "checkout"}, e.Resource)
+	require.NotContains(t, e.Attributes, "service")
+	require.NotContains(t, e.Attributes, attrs.LogFileResourceAttributes)
+}
+
+// TestResourceAttributes tests that only the attributes held under the resource attributes key are set on the
+// resource, and that other attributes are kept whatever their names
+func TestResourceAttributes(t *testing.T) {
+	t.Parallel()
+	operator, _, _ := newTestFileOperator(t, nil)
+
+	entries, err := operator.convertTokens([][]byte{[]byte("testlog1")}, map[string]any{
+		attrs.LogFileName:               "file.log",
+		"resource.team":                 "payments",
+		attrs.LogFileResourceAttributes: map[string]any{"service.name": "checkout"},
+	}, 1, []int64{0, 9})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, map[string]any{"service.name": "checkout"}, entries[0].Resource)
+	require.Equal(t, map[string]any{attrs.LogFileName: "file.log", "resource.team": "payments"}, entries[0].Attributes)
+}
+
+// TestRecordSeverity tests that a severity inferred for the record is set as the severity of the entry
+func TestRecordSeverity(t *testing.T) {
+	t.Parallel()
+	operator, _, _ := newTestFileOperator(t, nil)
+
+	entries, err := operator.convertTokens([][]byte{[]byte("error"), []byte("again")}, map[string]any{
+		attrs.LogFileName:             "file.log",
+		attrs.LogRecordSeverityNumber: int(entry.Error),
+	}, 2, []int64{0, 6, 12})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, e := range entries {
+		require.Equal(t, entry.Error, e.Severity)
+		require.Equal(t, map[string]any{attrs.LogFileName: "file.log"}, e.Attributes)
+	}
+}
+
 // ReadExistingLogs tests that, when starting from beginning, we
 // read all the lines that are already there
 func TestReadExistingLogs(t *testing.T) {
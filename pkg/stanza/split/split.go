@@ -6,8 +6,10 @@ package split // import "github.com/open-telemetry/opentelemetry-collector-contr
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
 
 	"golang.org/x/text/encoding"
@@ -110,6 +112,161 @@ func LineStartSplitFunc(re *regexp.Regexp, omitPattern, flushAtEOF bool) bufio.S
 	}
 }
 
+// LineStartTimestampSplitFunc creates a bufio.SplitFunc that splits an incoming stream of lines into
+// tokens that start with a line which begins with a match to the timestamp regex pattern provided.
+// Lines which do not begin with a timestamp are treated as continuations of the preceding token.
+// Unlike LineStartSplitFunc, a timestamp which appears part way through a line does not start a new token.
+func LineStartTimestampSplitFunc(re *regexp.Regexp, flushAtEOF bool) bufio.SplitFunc {
+	startsWithTimestamp := func(line []byte) bool {
+		loc := re.FindIndex(line)
+		return loc != nil && loc[0] == 0
+	}
+
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		// The first line always belongs to the current token, whether or not it begins with a timestamp,
+		// so that lines preceding the first timestamp are not lost.
+		lineEnd := bytes.IndexByte(data, '\n')
+		for lineEnd >= 0 {
+			next := lineEnd + 1
+			if next == len(data) {
+				break
+			}
+
+			nextLineEnd := bytes.IndexByte(data[next:], '\n')
+			nextLine := data[next:]
+			if nextLineEnd >= 0 {
+				nextLine = data[next : next+nextLineEnd]
+			}
+			if startsWithTimestamp(nextLine) {
+				return next, data[:next], nil
+			}
+			if nextLineEnd < 0 {
+				// The next line is incomplete, so it may still turn out to begin with a timestamp
+				break
+			}
+			lineEnd = next + nextLineEnd
+		}
+
+		// Flush if no more data is expected
+		if len(data) != 0 && atEOF && flushAtEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil // read more data and try again
+	}
+}
+
+// LookaheadSplitFunc creates a bufio.SplitFunc that splits an incoming stream into tokens which end where the
+// regex pattern provided matches. Unlike a delimiter, the match is not consumed, and instead begins the next token.
+// A match which is split across reads may match differently once more data is read, so a match is not trusted
+// while it ends at the end of the data, or until at least window bytes of data follow it, unless no more data is
+// expected. Matches which are no longer than
+// the window are found as they would be in the whole stream.
+func LookaheadSplitFunc(re *regexp.Regexp, window int, flushAtEOF bool) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		// A match at the start of the data begins the current token, so the token ends at the following match.
+		// The data is searched as a whole, rather than from an offset, so that anchors keep their meaning.
+		for _, loc := range re.FindAllIndex(data, 2) {
+			if loc[0] == 0 {
+				continue
+			}
+			if !atEOF && (loc[1] == len(data) || len(data)-loc[1] < window) {
+				break
+			}
+			return loc[0], data[:loc[0]], nil
+		}
+
+		// Flush if no more data is expected
+		if len(data) != 0 && atEOF && flushAtEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil // read more data and try again
+	}
+}
+
+// EscapedTerminatorSplitFunc creates a bufio.SplitFunc that splits an incoming stream into tokens which end with the
+// terminator byte, unless the terminator is preceded by the escape byte. The escape byte also escapes itself, so an
+// escaped escape byte does not prevent the following terminator from ending the token. The unescaped terminator is
+// consumed, while escaped terminators and their escape bytes are passed through in the token as they are.
+func EscapedTerminatorSplitFunc(terminator, escape byte, flushAtEOF bool) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		for i := 0; i < len(data); i++ {
+			switch data[i] {
+			case escape:
+				// An escape at the end of the buffered data may escape a terminator which has not been read yet
+				if i+1 == len(data) && !atEOF {
+					return 0, nil, nil
+				}
+				i++
+			case terminator:
+				return i + 1, data[:i], nil
+			}
+		}
+
+		// Flush if no more data is expected
+		if len(data) != 0 && atEOF && flushAtEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil // read more data and try again
+	}
+}
+
+// YAMLDocumentSplitFunc creates a bufio.SplitFunc that splits an incoming stream of YAML documents into tokens
+// containing one document each. Documents are separated by a "---" line, which may be followed by content on the same
+// line, or ended by a "..." line. The separators themselves are not included in the tokens, and empty documents are
+// skipped. Since the final document has no separator after it, it is only returned when more data is available or
+// when flushing.
+func YAMLDocumentSplitFunc(flushAtEOF bool) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		bodyStart := 0
+		for pos := 0; pos < len(data); {
+			lineEnd := bytes.IndexByte(data[pos:], '\n')
+			next := pos + lineEnd + 1
+			if lineEnd < 0 {
+				if !atEOF {
+					break // the line is incomplete, so it may still turn out to be a separator
+				}
+				next = len(data)
+			}
+
+			line := bytes.TrimSuffix(data[pos:next], []byte("\n"))
+			line = bytes.TrimSuffix(line, []byte("\r"))
+			switch {
+			case isYAMLDocumentStart(line) && isBlank(data[bodyStart:pos]):
+				// The current document begins with its separator, and any empty document before it is skipped
+				bodyStart = next
+				if rest := bytes.TrimLeft(line[len("---"):], " \t"); len(rest) > 0 {
+					bodyStart = pos + len(line) - len(rest)
+				}
+			case isYAMLDocumentStart(line):
+				// The next document begins with its separator
+				return pos, data[bodyStart:pos], nil
+			case bytes.Equal(line, []byte("...")) && isBlank(data[bodyStart:pos]):
+				bodyStart = next
+			case bytes.Equal(line, []byte("...")):
+				return next, data[bodyStart:pos], nil
+			}
+			pos = next
+		}
+
+		// Flush if no more data is expected
+		if len(data) != 0 && atEOF && flushAtEOF {
+			if isBlank(data[bodyStart:]) {
+				return len(data), nil, nil
+			}
+			return len(data), data[bodyStart:], nil
+		}
+		return 0, nil, nil // read more data and try again
+	}
+}
+
+func isYAMLDocumentStart(line []byte) bool {
+	return bytes.HasPrefix(line, []byte("---")) && (len(line) == 3 || line[3] == ' ' || line[3] == '\t')
+}
+
+func isBlank(data []byte) bool {
+	return len(bytes.TrimSpace(data)) == 0
+}
+
 // LineEndSplitFunc creates a bufio.SplitFunc that splits an incoming stream into
 // tokens that end with a match to the regex pattern provided
 func LineEndSplitFunc(re *regexp.Regexp, omitPattern, flushAtEOF bool) bufio.SplitFunc {
@@ -193,6 +350,40 @@ func NoSplitFunc(maxLogSize int) bufio.SplitFunc {
 	}
 }
 
+// VarintDelimitedSplitFunc creates a bufio.SplitFunc that splits an incoming stream of varint length-prefixed
+// records, as written by protobuf's delimited format, into tokens containing the undelimited records.
+// A record whose prefix and body together exceed maxLogSize is an error, since the stream cannot be truncated
+// without losing track of where the next record begins. For the same reason, incomplete records are never flushed.
+// A prefix which is not a valid varint is skipped, up to the byte at which it overflowed, so that reading goes on.
+// Records are opaque bytes, so this should be used with the nop encoding, without trimming and with flushing disabled.
+func VarintDelimitedSplitFunc(maxLogSize int) bufio.SplitFunc {
+	return func(data []byte, _ bool) (advance int, token []byte, err error) {
+		if len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		length, prefixLen := binary.Uvarint(data)
+		if prefixLen < 0 {
+			return -prefixLen, nil, nil
+		}
+		if prefixLen == 0 {
+			return 0, nil, nil // read more data and try again
+		}
+		if maxLogSize > 0 && (prefixLen > maxLogSize || length > uint64(maxLogSize-prefixLen)) {
+			return 0, nil, fmt.Errorf("record of length %d exceeds max log size %d", length, maxLogSize)
+		}
+		if length > uint64(math.MaxInt-prefixLen) {
+			return 0, nil, fmt.Errorf("record of length %d is too large", length)
+		}
+
+		end := prefixLen + int(length)
+		if len(data) < end {
+			return 0, nil, nil // read more data and try again
+		}
+		return end, data[prefixLen:end], nil
+	}
+}
+
 func encodedNewline(enc encoding.Encoding) ([]byte, error) {
 	out := make([]byte, 10)
 	nDst, _, err := enc.NewEncoder().Transform(out, []byte{'\n'}, true)
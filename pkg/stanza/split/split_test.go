@@ -4,7 +4,11 @@
 package split
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
+	"math"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -274,6 +278,378 @@ func TestLineStartSplitFunc(t *testing.T) {
 	}
 }
 
+func TestLineStartTimestampSplitFunc(t *testing.T) {
+	testCases := []struct {
+		name       string
+		pattern    string
+		flushAtEOF bool
+		input      []byte
+		steps      []splittest.Step
+	}{
+		{
+			name:    "OneLineRecords",
+			pattern: `\d{4}-\d{2}-\d{2} `,
+			input:   []byte("2024-01-01 one\n2024-01-02 two\n2024-01-03 three\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("2024-01-01 one\n"),
+				splittest.ExpectToken("2024-01-02 two\n"),
+			},
+		},
+		{
+			name:    "MultilineRecords",
+			pattern: `\d{4}-\d{2}-\d{2} `,
+			input:   []byte("2024-01-01 panic\n  at foo\n  at bar\n2024-01-02 recovered\ncontinued\n2024-01-03 next\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("2024-01-01 panic\n  at foo\n  at bar\n"),
+				splittest.ExpectToken("2024-01-02 recovered\ncontinued\n"),
+			},
+		},
+		{
+			name:    "TimestampMidLine",
+			pattern: `\d{4}-\d{2}-\d{2} `,
+			input:   []byte("2024-01-01 started at 2024-01-01 00:00\nretrying since 2024-01-01 00:01\n2024-01-02 next\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("2024-01-01 started at 2024-01-01 00:00\nretrying since 2024-01-01 00:01\n"),
+			},
+		},
+		{
+			name:    "LinesBeforeFirstTimestamp",
+			pattern: `\d{4}-\d{2}-\d{2} `,
+			input:   []byte("preamble\nmore preamble\n2024-01-01 one\n2024-01-02 two\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("preamble\nmore preamble\n"),
+				splittest.ExpectToken("2024-01-01 one\n"),
+			},
+		},
+		{
+			name:    "IncompleteLastRecord",
+			pattern: `\d{4}-\d{2}-\d{2} `,
+			input:   []byte("2024-01-01 one\n  continued"),
+		},
+		{
+			name:       "FlushAtEOF",
+			pattern:    `\d{4}-\d{2}-\d{2} `,
+			flushAtEOF: true,
+			input:      []byte("2024-01-01 one\n2024-01-02 two\n  continued"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("2024-01-01 one\n"),
+				splittest.ExpectToken("2024-01-02 two\n  continued"),
+			},
+		},
+		{
+			name:    "CarriageReturn",
+			pattern: `\[\d{2}:\d{2}:\d{2}\]`,
+			input:   []byte("[10:00:00] one\r\n\tdetail\r\n[10:00:01] two\r\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("[10:00:00] one\r\n\tdetail\r\n"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		re := regexp.MustCompile(tc.pattern)
+		splitFunc := LineStartTimestampSplitFunc(re, tc.flushAtEOF)
+		t.Run(tc.name, splittest.New(splitFunc, tc.input, tc.steps...))
+	}
+}
+
+func TestLookaheadSplitFunc(t *testing.T) {
+	testCases := []struct {
+		name       string
+		pattern    string
+		flushAtEOF bool
+		input      []byte
+		steps      []splittest.Step
+	}{
+		{
+			name:    "PatternBeginsNextRecord",
+			pattern: `BEGIN `,
+			input:   []byte("BEGIN one BEGIN two BEGIN three"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("BEGIN one "),
+				splittest.ExpectToken("BEGIN two "),
+			},
+		},
+		{
+			name:    "ContentBeforeFirstMatch",
+			pattern: `BEGIN `,
+			input:   []byte("preamble\nBEGIN one\nBEGIN two\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("preamble\n"),
+				splittest.ExpectToken("BEGIN one\n"),
+			},
+		},
+		{
+			name:    "AnchoredPattern",
+			pattern: `(?m)^\d+: `,
+			input:   []byte("1: first 2: not a record\ncontinued\n2: second\n3: third\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("1: first 2: not a record\ncontinued\n"),
+				splittest.ExpectToken("2: second\n"),
+			},
+		},
+		{
+			// The pattern goes on to match earlier in the data, once the rest of "bcd" is read
+			name:    "MatchSplitAcrossReads",
+			pattern: `c|bcd`,
+			input:   []byte("1bcd2"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("1"),
+			},
+		},
+		{
+			// The match of "b" does not reach the end of the data, but the match of "abbbbc" begins earlier
+			name:    "LongerMatchBeginsEarlier",
+			pattern: `ab+c|b`,
+			input:   []byte("1abbbbc2"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("1"),
+			},
+		},
+		{
+			name:    "PartialMatchAtStartOfLine",
+			pattern: `(?m)^\d{4}-\d{2} `,
+			input:   []byte("2024-01 first\nnot 2024-02 a record\n2024-03 second\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("2024-01 first\nnot 2024-02 a record\n"),
+			},
+		},
+		{
+			name:       "FlushAtEOF",
+			pattern:    `BEGIN `,
+			flushAtEOF: true,
+			input:      []byte("BEGIN one BEGIN two"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("BEGIN one "),
+				splittest.ExpectToken("BEGIN two"),
+			},
+		},
+		{
+			name:    "NoMatch",
+			pattern: `BEGIN `,
+			input:   []byte("no records here"),
+		},
+	}
+
+	for _, tc := range testCases {
+		re := regexp.MustCompile(tc.pattern)
+		splitFunc := LookaheadSplitFunc(re, 16, tc.flushAtEOF)
+		t.Run(tc.name, splittest.New(splitFunc, tc.input, tc.steps...))
+	}
+}
+
+func TestLookaheadSplitFuncWindow(t *testing.T) {
+	splitFunc := LookaheadSplitFunc(regexp.MustCompile(`BEGIN `), 8, false)
+
+	// The match is not trusted until the window is filled
+	advance, token, err := splitFunc([]byte("one BEGIN two"), false)
+	require.NoError(t, err)
+	assert.Zero(t, advance)
+	assert.Nil(t, token)
+
+	advance, token, err = splitFunc([]byte("one BEGIN two thre"), false)
+	require.NoError(t, err)
+	assert.Equal(t, len("one "), advance)
+	assert.Equal(t, []byte("one "), token)
+
+	// Unless no more data is expected
+	advance, token, err = splitFunc([]byte("one BEGIN two"), true)
+	require.NoError(t, err)
+	assert.Equal(t, len("one "), advance)
+	assert.Equal(t, []byte("one "), token)
+}
+
+func TestEscapedTerminatorSplitFunc(t *testing.T) {
+	testCases := []struct {
+		name       string
+		flushAtEOF bool
+		input      []byte
+		steps      []splittest.Step
+	}{
+		{
+			name:  "Terminated",
+			input: []byte("one;two;three"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("one;"), "one"),
+				splittest.ExpectAdvanceToken(len("two;"), "two"),
+			},
+		},
+		{
+			name:  "EscapedTerminator",
+			input: []byte(`one\;still one;two\;;`),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len(`one\;still one;`), `one\;still one`),
+				splittest.ExpectAdvanceToken(len(`two\;;`), `two\;`),
+			},
+		},
+		{
+			name:  "EscapedEscape",
+			input: []byte(`one\\\\;two;`),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len(`one\\\\;`), `one\\\\`),
+				splittest.ExpectAdvanceToken(len("two;"), "two"),
+			},
+		},
+		{
+			name:  "EmptyRecords",
+			input: []byte(";;one;"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(1, ""),
+				splittest.ExpectAdvanceToken(1, ""),
+				splittest.ExpectAdvanceToken(len("one;"), "one"),
+			},
+		},
+		{
+			name:       "FlushAtEOF",
+			flushAtEOF: true,
+			input:      []byte(`one;unterminated\;`),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("one;"), "one"),
+				splittest.ExpectToken(`unterminated\;`),
+			},
+		},
+		{
+			name:       "FlushTrailingEscapeAtEOF",
+			flushAtEOF: true,
+			input:      []byte(`one\`),
+			steps: []splittest.Step{
+				splittest.ExpectToken(`one\`),
+			},
+		},
+		{
+			name:  "NoTerminator",
+			input: []byte(`no records here\;`),
+		},
+	}
+
+	for _, tc := range testCases {
+		splitFunc := EscapedTerminatorSplitFunc(';', '\\', tc.flushAtEOF)
+		t.Run(tc.name, splittest.New(splitFunc, tc.input, tc.steps...))
+	}
+
+	// The escape which ends the buffered data may escape the terminator which follows it
+	splitFunc := EscapedTerminatorSplitFunc(';', '\\', false)
+	advance, token, err := splitFunc([]byte(`one\`), false)
+	require.NoError(t, err)
+	require.Zero(t, advance)
+	require.Nil(t, token)
+	advance, token, err = splitFunc([]byte(`one\;two;`), false)
+	require.NoError(t, err)
+	require.Equal(t, len(`one\;two;`), advance)
+	require.Equal(t, []byte(`one\;two`), token)
+}
+
+func TestYAMLDocumentSplitFunc(t *testing.T) {
+	testCases := []struct {
+		name       string
+		flushAtEOF bool
+		input      []byte
+		steps      []splittest.Step
+	}{
+		{
+			name:  "SeparatedDocuments",
+			input: []byte("---\na: 1\n---\nb: 2\n---\nc: 3\n"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\na: 1\n"), "a: 1\n"),
+				splittest.ExpectAdvanceToken(len("---\nb: 2\n"), "b: 2\n"),
+			},
+		},
+		{
+			name:  "NoLeadingSeparator",
+			input: []byte("a: 1\n---\nb: 2\n---\n"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("a: 1\n"),
+				splittest.ExpectAdvanceToken(len("---\nb: 2\n"), "b: 2\n"),
+			},
+		},
+		{
+			name:  "NestedIndentation",
+			input: []byte("---\nroot:\n  child:\n    - item: 1\n      value: x\n  other: y\n---\nnext: true\n---\n"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\nroot:\n  child:\n    - item: 1\n      value: x\n  other: y\n"), "root:\n  child:\n    - item: 1\n      value: x\n  other: y\n"),
+				splittest.ExpectAdvanceToken(len("---\nnext: true\n"), "next: true\n"),
+			},
+		},
+		{
+			name:  "IndentedSeparatorIsContent",
+			input: []byte("---\nblock: |\n  ---\n  text\n---\n"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\nblock: |\n  ---\n  text\n"), "block: |\n  ---\n  text\n"),
+			},
+		},
+		{
+			name:  "ContentOnSeparatorLine",
+			input: []byte("--- !tagged\na: 1\n--- plain scalar\n---\n"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("--- !tagged\na: 1\n"), "!tagged\na: 1\n"),
+				splittest.ExpectAdvanceToken(len("--- plain scalar\n"), "plain scalar\n"),
+			},
+		},
+		{
+			name:  "DocumentEndMarker",
+			input: []byte("---\na: 1\n...\n---\nb: 2\n...\n"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\na: 1\n...\n"), "a: 1\n"),
+				splittest.ExpectAdvanceToken(len("---\nb: 2\n...\n"), "b: 2\n"),
+			},
+		},
+		{
+			name:  "EmptyDocuments",
+			input: []byte("---\n---\n\n---\na: 1\n...\n...\n---\nb: 2\n---\n"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\n---\n\n---\na: 1\n...\n"), "a: 1\n"),
+				splittest.ExpectAdvanceToken(len("...\n---\nb: 2\n"), "b: 2\n"),
+			},
+		},
+		{
+			name:       "EmptyFinalDocumentFlushAtEOF",
+			flushAtEOF: true,
+			input:      []byte("---\na: 1\n---\n\n"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\na: 1\n"), "a: 1\n"),
+				splittest.ExpectAdvanceNil(len("---\n\n")),
+			},
+		},
+		{
+			name:  "CarriageReturn",
+			input: []byte("---\r\na: 1\r\n---\r\nb: 2\r\n"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\r\na: 1\r\n"), "a: 1\r\n"),
+			},
+		},
+		{
+			name:  "TruncatedFinalDocument",
+			input: []byte("---\na: 1\n---\nb:\n  c: 2\n  d"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\na: 1\n"), "a: 1\n"),
+			},
+		},
+		{
+			name:       "TruncatedFinalDocumentFlushAtEOF",
+			flushAtEOF: true,
+			input:      []byte("---\na: 1\n---\nb:\n  c: 2\n  d"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(len("---\na: 1\n"), "a: 1\n"),
+				splittest.ExpectAdvanceToken(len("---\nb:\n  c: 2\n  d"), "b:\n  c: 2\n  d"),
+			},
+		},
+		{
+			name:       "UnterminatedSeparatorAtEOF",
+			flushAtEOF: true,
+			input:      []byte("a: 1\n--- b"),
+			steps: []splittest.Step{
+				splittest.ExpectToken("a: 1\n"),
+				splittest.ExpectAdvanceToken(len("--- b"), "b"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		splitFunc := YAMLDocumentSplitFunc(tc.flushAtEOF)
+		t.Run(tc.name, splittest.New(splitFunc, tc.input, tc.steps...))
+	}
+}
+
 func TestLineEndSplitFunc_Detailed(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -681,3 +1057,110 @@ func TestNoSplitFunc(t *testing.T) {
 		t.Run(tc.name, splittest.New(splitFunc, tc.input, tc.steps...))
 	}
 }
+
+func TestVarintDelimitedSplitFunc(t *testing.T) {
+	delimited := func(records ...string) []byte {
+		var data []byte
+		for _, record := range records {
+			data = binary.AppendUvarint(data, uint64(len(record)))
+			data = append(data, record...)
+		}
+		return data
+	}
+	long := string(splittest.GenerateBytes(300)) // requires a two byte varint
+
+	testCases := []struct {
+		name       string
+		maxLogSize int
+		input      []byte
+		steps      []splittest.Step
+	}{
+		{
+			name:  "OneRecord",
+			input: delimited("hello"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(6, "hello"),
+			},
+		},
+		{
+			name:  "ManyRecords",
+			input: delimited("one", "two", "three"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(4, "one"),
+				splittest.ExpectAdvanceToken(4, "two"),
+				splittest.ExpectAdvanceToken(6, "three"),
+			},
+		},
+		{
+			name:  "MultiByteVarint",
+			input: delimited(long, "short"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(302, long),
+				splittest.ExpectAdvanceToken(6, "short"),
+			},
+		},
+		{
+			name:  "EmptyRecord",
+			input: delimited("", "after"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(1, ""),
+				splittest.ExpectAdvanceToken(6, "after"),
+			},
+		},
+		{
+			name:  "BinaryRecord",
+			input: delimited("\x00\n\xff\x08"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(5, "\x00\n\xff\x08"),
+			},
+		},
+		{
+			name:  "IncompleteRecord",
+			input: delimited("complete", "incomplete")[:15],
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(9, "complete"),
+			},
+		},
+		{
+			name:  "IncompleteVarint",
+			input: delimited("complete", long)[:10],
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(9, "complete"),
+			},
+		},
+		{
+			// The prefix overflows at its eleventh byte, which is skipped along with the rest of the prefix
+			name:  "InvalidVarint",
+			input: append(bytes.Repeat([]byte{0xff}, 11), delimited("after")...),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceNil(11),
+				splittest.ExpectAdvanceToken(6, "after"),
+			},
+		},
+		{
+			name:       "RecordFitsMaxLogSize",
+			maxLogSize: 6,
+			input:      delimited("hello"),
+			steps: []splittest.Step{
+				splittest.ExpectAdvanceToken(6, "hello"),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		splitFunc := VarintDelimitedSplitFunc(tc.maxLogSize)
+		t.Run(tc.name, splittest.New(splitFunc, tc.input, tc.steps...))
+	}
+}
+
+func TestVarintDelimitedSplitFuncErrors(t *testing.T) {
+	// The prefix is included in the size of the record
+	_, _, err := VarintDelimitedSplitFunc(5)(binary.AppendUvarint(nil, 5), false)
+	require.EqualError(t, err, "record of length 5 exceeds max log size 5")
+
+	_, _, err = VarintDelimitedSplitFunc(1)(binary.AppendUvarint(nil, 300), false)
+	require.EqualError(t, err, "record of length 300 exceeds max log size 1")
+
+	_, _, err = VarintDelimitedSplitFunc(0)(binary.AppendUvarint(nil, math.MaxUint64), false)
+	require.EqualError(t, err, "record of length 18446744073709551615 is too large")
+}